@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/kubefed/pkg/controller/agent"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+	"sigs.k8s.io/kubefed/pkg/version"
+)
+
+type agentOptions struct {
+	hostKubeconfig     string
+	kubeconfig         string
+	clusterName        string
+	kubefedNamespace   string
+	resyncPeriod       time.Duration
+	healthCheckPeriod  time.Duration
+	healthCheckTimeout time.Duration
+}
+
+// NewAgentCommand creates a *cobra.Command object with default parameters
+func NewAgentCommand() *cobra.Command {
+	verFlag := false
+	opts := &agentOptions{}
+
+	cmd := &cobra.Command{
+		Use: "kubefed-agent",
+		Long: `The Kubefed agent runs in a member cluster whose connection mode
+is Pull.  It writes the resources propagated to the cluster by the
+control plane and reports their state and the health of the cluster
+to the host cluster`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintf(os.Stdout, "Kubefed agent version: %s\n", fmt.Sprintf("%#v", version.Get()))
+			if verFlag {
+				os.Exit(0)
+			}
+
+			if err := runAgent(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Add the command line flags from other dependencies(klog, etc.)
+	cmd.Flags().AddGoFlagSet(flag.CommandLine)
+
+	cmd.Flags().BoolVar(&verFlag, "version", false, "Prints the Version info of the agent")
+	cmd.Flags().StringVar(&opts.hostKubeconfig, "host-kubeconfig", "", "Path to a kubeconfig for the host cluster.")
+	cmd.Flags().StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig for the member cluster. Only required if out-of-cluster.")
+	cmd.Flags().StringVar(&opts.clusterName, "cluster-name", "", "The name of the KubefedCluster of the member cluster.")
+	cmd.Flags().StringVar(&opts.kubefedNamespace, "kubefed-namespace", util.DefaultKubefedSystemNamespace, "The namespace the kubefed control plane is deployed in.")
+	cmd.Flags().DurationVar(&opts.resyncPeriod, "resync-period", 30*time.Second, "How often resources are written again if they have changed in the member cluster and their state is reported.")
+	cmd.Flags().DurationVar(&opts.healthCheckPeriod, "health-check-period", util.DefaultClusterHealthCheckPeriod*time.Second, "How often the health of the member cluster is reported.")
+	cmd.Flags().DurationVar(&opts.healthCheckTimeout, "health-check-timeout", util.DefaultClusterHealthCheckTimeout*time.Second, "The timeout of a health check of the member cluster.")
+
+	return cmd
+}
+
+func runAgent(opts *agentOptions) error {
+	if len(opts.hostKubeconfig) == 0 {
+		return errors.New("--host-kubeconfig is required")
+	}
+	if len(opts.clusterName) == 0 {
+		return errors.New("--cluster-name is required")
+	}
+
+	hostConfig, err := clientcmd.BuildConfigFromFlags("", opts.hostKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load the configuration of the host cluster")
+	}
+	memberConfig, err := clientcmd.BuildConfigFromFlags("", opts.kubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load the configuration of the member cluster")
+	}
+	memberConfig.QPS = util.KubeAPIQPS
+	memberConfig.Burst = util.KubeAPIBurst
+
+	stopChan := setupSignalHandler()
+	err = agent.StartAgent(&agent.Config{
+		ClusterName:        opts.clusterName,
+		KubefedNamespace:   opts.kubefedNamespace,
+		HostConfig:         hostConfig,
+		MemberConfig:       memberConfig,
+		ResyncPeriod:       opts.resyncPeriod,
+		HealthCheckPeriod:  opts.healthCheckPeriod,
+		HealthCheckTimeout: opts.healthCheckTimeout,
+	}, stopChan)
+	if err != nil {
+		return err
+	}
+
+	<-stopChan
+	klog.Infof("Stopping agent for cluster %q", opts.clusterName)
+	return nil
+}
+
+// setupSignalHandler registers for SIGTERM and SIGINT. A stop channel
+// is returned which is closed on one of these signals. If a second
+// signal is caught, the program is terminated with exit code 1.
+func setupSignalHandler() <-chan struct{} {
+	stop := make(chan struct{})
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		close(stop)
+		<-c
+		os.Exit(1) // second signal. Exit directly.
+	}()
+	return stop
+}
@@ -27,15 +27,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	apiextv1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/apiserver/pkg/util/logs"
+	kubeclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
@@ -44,12 +47,14 @@ import (
 	"sigs.k8s.io/kubefed/cmd/controller-manager/app/options"
 	corev1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	"sigs.k8s.io/kubefed/pkg/controller/credentialrotation"
 	"sigs.k8s.io/kubefed/pkg/controller/dnsendpoint"
 	"sigs.k8s.io/kubefed/pkg/controller/federatedtypeconfig"
 	"sigs.k8s.io/kubefed/pkg/controller/ingressdns"
 	"sigs.k8s.io/kubefed/pkg/controller/kubefedcluster"
 	"sigs.k8s.io/kubefed/pkg/controller/schedulingmanager"
 	"sigs.k8s.io/kubefed/pkg/controller/servicedns"
+	"sigs.k8s.io/kubefed/pkg/controller/sharding"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 	"sigs.k8s.io/kubefed/pkg/features"
 	"sigs.k8s.io/kubefed/pkg/version"
@@ -100,8 +105,8 @@ func Run(opts *options.Options) error {
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
-	// TODO: Make healthz endpoint configurable
-	go serveHealthz(":8080")
+	// TODO: Make healthz and metrics endpoint configurable
+	go serveHealthzAndMetrics(":8080")
 
 	stopChan := setupSignalHandler()
 
@@ -125,7 +130,16 @@ func Run(opts *options.Options) error {
 		klog.Info("Federation will target all namespaces")
 	}
 
-	elector, err := leaderelection.NewFederationLeaderElector(opts, startControllers)
+	startLeaderControllers := startControllers
+	if opts.Config.Sharding.Mode != "" {
+		// Controllers that divide their work between replicas run in
+		// every replica, and the remaining controllers run in the
+		// elected leader.
+		startShardedControllers(opts, stopChan)
+		startLeaderControllers = startUnshardedControllers
+	}
+
+	elector, err := leaderelection.NewFederationLeaderElector(opts, startLeaderControllers)
 	if err != nil {
 		panic(err)
 	}
@@ -148,10 +162,47 @@ func Run(opts *options.Options) error {
 }
 
 func startControllers(opts *options.Options, stopChan <-chan struct{}) {
+	startUnshardedControllers(opts, stopChan)
+	startPropagationControllers(opts, stopChan)
+}
+
+// startShardedControllers joins the shard set of the controller
+// manager and starts the controllers whose work is divided between
+// replicas.
+func startShardedControllers(opts *options.Options, stopChan <-chan struct{}) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("Error retrieving hostname: %v", err)
+	}
+	leaseName := string(uuid.NewUUID())
+	identity := hostname + "_" + leaseName
+	client := kubeclient.NewForConfigOrDie(rest.AddUserAgent(rest.CopyConfig(opts.Config.KubeConfig), "kubefed-shard-membership"))
+	membership := sharding.NewMembership(client.CoordinationV1beta1(), opts.Config.KubefedNamespace, identity, leaseName,
+		opts.ShardMembership.LeaseDuration, opts.ShardMembership.RenewPeriod)
+	go membership.Run(stopChan)
+	klog.Infof("Waiting for shard member %q to observe its lease", identity)
+	if !membership.WaitForSync(stopChan) {
+		return
+	}
+
+	opts.Config.Sharding.Owner = membership
+	klog.Infof("Propagation will be sharded by %s as shard member %q", opts.Config.Sharding.Mode, identity)
+	startPropagationControllers(opts, stopChan)
+}
+
+// startUnshardedControllers starts the controllers that must only run
+// in a single replica.
+func startUnshardedControllers(opts *options.Options, stopChan <-chan struct{}) {
 	if err := kubefedcluster.StartClusterController(opts.Config, opts.ClusterHealthCheckConfig, stopChan); err != nil {
 		klog.Fatalf("Error starting cluster controller: %v", err)
 	}
 
+	if opts.CredentialRotation.Enabled {
+		if err := credentialrotation.StartController(opts.Config, opts.CredentialRotation, stopChan); err != nil {
+			klog.Fatalf("Error starting credential rotation controller: %v", err)
+		}
+	}
+
 	if utilfeature.DefaultFeatureGate.Enabled(features.SchedulerPreferences) {
 		if _, err := schedulingmanager.StartSchedulingManager(opts.Config, stopChan); err != nil {
 			klog.Fatalf("Error starting scheduling manager: %v", err)
@@ -177,8 +228,21 @@ func startControllers(opts *options.Options, stopChan <-chan struct{}) {
 			klog.Fatalf("Error starting ingress dns endpoint controller: %v", err)
 		}
 	}
+}
 
+// startPropagationControllers starts the controllers that propagate
+// federated resources, which may divide their work between replicas.
+func startPropagationControllers(opts *options.Options, stopChan <-chan struct{}) {
 	if utilfeature.DefaultFeatureGate.Enabled(features.PushReconciler) {
+		// Policies are read by the sync controllers of every
+		// federated type through a single set of informers.
+		policyInformers, err := util.NewPolicyInformers(opts.Config)
+		if err != nil {
+			klog.Fatalf("Error initializing policy informers: %v", err)
+		}
+		policyInformers.Run(stopChan)
+		opts.Config.PolicyInformers = policyInformers
+
 		if err := federatedtypeconfig.StartController(opts.Config, stopChan); err != nil {
 			klog.Fatalf("Error starting federated type config controller: %v", err)
 		}
@@ -281,6 +345,17 @@ func setDefaultKubefedConfig(fedConfig *corev1a1.KubefedConfig) {
 	setInt(&healthCheck.FailureThreshold, util.DefaultClusterHealthCheckFailureThreshold)
 	setInt(&healthCheck.SuccessThreshold, util.DefaultClusterHealthCheckSuccessThreshold)
 
+	syncController := &spec.SyncController
+	setDuration(&syncController.UpdateTimeout, util.DefaultSyncUpdateTimeout)
+	setDuration(&syncController.ReconcileDelay, util.DefaultSyncReconcileDelay)
+
+	shardingConfig := &spec.Sharding
+	setDuration(&shardingConfig.LeaseDuration, util.DefaultShardLeaseDuration)
+	setDuration(&shardingConfig.RenewPeriod, util.DefaultShardRenewPeriod)
+
+	rotation := &spec.CredentialRotation
+	setDuration(&rotation.Period, util.DefaultCredentialRotationPeriod)
+	setDuration(&rotation.RevocationDelay, util.DefaultCredentialRevocationDelay)
 }
 
 func updateKubefedConfig(config *rest.Config, fedConfig *corev1a1.KubefedConfig) {
@@ -348,8 +423,32 @@ func setOptionsByKubefedConfig(opts *options.Options) {
 	opts.ClusterHealthCheckConfig.TimeoutSeconds = spec.ClusterHealthCheck.TimeoutSeconds
 	opts.ClusterHealthCheckConfig.FailureThreshold = spec.ClusterHealthCheck.FailureThreshold
 	opts.ClusterHealthCheckConfig.SuccessThreshold = spec.ClusterHealthCheck.SuccessThreshold
+	if err := kubefedcluster.ValidateHealthChecks(spec.ClusterHealthCheck.Checks, spec.ClusterHealthCheck.ReadyGates); err != nil {
+		klog.Fatalf("Invalid cluster health checks: %v", err)
+	}
+	opts.ClusterHealthCheckConfig.Checks = spec.ClusterHealthCheck.Checks
+	opts.ClusterHealthCheckConfig.ReadyGates = spec.ClusterHealthCheck.ReadyGates
 
 	opts.Config.SkipAdoptingResources = spec.SyncController.SkipAdoptingResources
+	opts.Config.SyncConfig.UpdateTimeout = spec.SyncController.UpdateTimeout.Duration
+	opts.Config.SyncConfig.ReconcileDelay = spec.SyncController.ReconcileDelay.Duration
+	opts.Config.SyncConfig.MaxConcurrentDispatches = spec.SyncController.MaxConcurrentDispatches
+	opts.Config.ClusterOperationLimiter = util.NewClusterOperationLimiter(spec.SyncController.MaxConcurrentDispatchesPerCluster)
+
+	switch spec.Sharding.Mode {
+	case "", corev1a1.ShardByTypeNamespace, corev1a1.ShardByCluster:
+	default:
+		klog.Fatalf("Invalid sharding mode %q: supported modes are %q and %q", spec.Sharding.Mode, corev1a1.ShardByTypeNamespace, corev1a1.ShardByCluster)
+	}
+	opts.Config.Sharding.Mode = spec.Sharding.Mode
+	opts.ShardMembership.LeaseDuration = spec.Sharding.LeaseDuration.Duration
+	opts.ShardMembership.RenewPeriod = spec.Sharding.RenewPeriod.Duration
+
+	opts.CredentialRotation.Enabled = spec.CredentialRotation.Enabled
+	opts.CredentialRotation.Period = spec.CredentialRotation.Period.Duration
+	opts.CredentialRotation.RevocationDelay = spec.CredentialRotation.RevocationDelay.Duration
+
+	opts.Config.AllowedExecCommands = spec.ClusterCredentials.AllowedExecCommands
 
 	updateKubefedConfig(opts.Config.KubeConfig, fedConfig)
 
@@ -394,11 +493,12 @@ func setupSignalHandler() (stopCh <-chan struct{}) {
 	return stop
 }
 
-func serveHealthz(address string) {
+func serveHealthzAndMetrics(address string) {
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("OK"))
 	})
+	http.Handle("/metrics", promhttp.Handler())
 
 	klog.Fatal(http.ListenAndServe(address, nil))
 }
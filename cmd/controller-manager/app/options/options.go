@@ -32,6 +32,8 @@ type Options struct {
 	Scope                    apiextv1b1.ResourceScope
 	LeaderElection           *util.LeaderElectionConfiguration
 	ClusterHealthCheckConfig util.ClusterHealthCheckConfig
+	ShardMembership          util.ShardMembershipConfig
+	CredentialRotation       util.CredentialRotationConfig
 }
 
 // AddFlags adds flags to fs and binds them to options.
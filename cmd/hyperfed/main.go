@@ -36,6 +36,7 @@ import (
 	"k8s.io/apiserver/pkg/util/logs"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Load all client auth plugins for GCP, Azure, Openstack, etc
 
+	agent "sigs.k8s.io/kubefed/cmd/agent/app"
 	"sigs.k8s.io/kubefed/cmd/controller-manager/app"
 	"sigs.k8s.io/kubefed/pkg/kubefedctl"
 )
@@ -78,10 +79,12 @@ func commandFor(basename string, defaultCommand *cobra.Command, commands []func(
 func NewHyperFedCommand() (*cobra.Command, []func() *cobra.Command) {
 	controller := func() *cobra.Command { return app.NewControllerManagerCommand() }
 	kubefedctlCmd := func() *cobra.Command { return kubefedctl.NewKubeFedCtlCommand(os.Stdout) }
+	agentCmd := func() *cobra.Command { return agent.NewAgentCommand() }
 
 	commandFns := []func() *cobra.Command{
 		controller,
 		kubefedctlCmd,
+		agentCmd,
 	}
 
 	makeSymlinksFlag := false
@@ -18,6 +18,8 @@ package typeconfig
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 )
 
 // Interface defines how to interact with a FederatedTypeConfig
@@ -26,6 +28,13 @@ type Interface interface {
 	GetTarget() metav1.APIResource
 	GetNamespaced() bool
 	GetPropagationEnabled() bool
+	GetRetainFields() []fedv1a1.RetainField
+	GetServerSideApply() *fedv1a1.ServerSideApply
+	GetDeletionPolicy() fedv1a1.DeletionPolicy
+	GetDeletionGracePeriodSeconds() *int64
+	GetAdoptionPolicy() fedv1a1.AdoptionPolicy
+	GetStatusAggregation() []fedv1a1.StatusAggregationRule
+	GetHealthCheck() *fedv1a1.HealthCheck
 	GetFederatedType() metav1.APIResource
 	GetStatus() *metav1.APIResource
 	GetEnableStatus() bool
@@ -51,6 +51,179 @@ type FederatedTypeConfigSpec struct {
 	// Whether or not Status object should be populated.
 	// +optional
 	EnableStatus bool `json:"enableStatus,omitempty"`
+	// Fields of the target resource whose values in member clusters
+	// are retained when the sync controller updates the resource.
+	// This avoids contention with controllers in member clusters
+	// that set these fields.  The rules are applied in addition to
+	// the default rules for the fields known to be set by kubernetes
+	// for the target type, and replace a default rule for the same
+	// path.
+	// +optional
+	RetainFields []RetainField `json:"retainFields,omitempty"`
+	// Whether the default rules for retaining the fields known to be
+	// set by kubernetes for the target type are not applied.
+	// +optional
+	DisableDefaultRetainFields bool `json:"disableDefaultRetainFields,omitempty"`
+	// Configures the sync controller to write resources to member
+	// clusters with server-side apply, so that it owns only the
+	// fields it renders and leaves fields set by other actors
+	// intact.  If not provided, resources are written with update.
+	// +optional
+	ServerSideApply *ServerSideApply `json:"serverSideApply,omitempty"`
+	// The default deletion policy of federated resources of the type
+	// that do not specify one.  If not provided, defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete,Orphan,DelayedDelete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// The default grace period in seconds of the DelayedDelete
+	// deletion policy for federated resources of the type that do
+	// not specify one.  If not provided, defaults to 300.
+	// +optional
+	DeletionGracePeriodSeconds *int64 `json:"deletionGracePeriodSeconds,omitempty"`
+	// Whether resources that already exist in member clusters are
+	// adopted by the federated resources of the type.  Federated
+	// resources may override the policy with the
+	// `kubefed.k8s.io/adoption-policy` annotation.  If not provided,
+	// resources are adopted unless adoption is disabled by the
+	// `skip-adopting-resources` option of the sync controller.
+	// +kubebuilder:validation:Enum=Always,Never,IfIdentical,IfLabelled
+	// +optional
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+	// Rules for summarizing the status of the target resources in
+	// member clusters in the status of federated resources of the
+	// type.  If not provided, defaults to the rules known for the
+	// target type.
+	// +optional
+	StatusAggregation []StatusAggregationRule `json:"statusAggregation,omitempty"`
+	// A rule for assessing the health of the target resources in
+	// member clusters.  If not provided, the health of Deployment,
+	// Job and Service resources is assessed by built-in checks and
+	// the health of resources of other types is not assessed.
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+}
+
+// HealthCheck assesses the health of a target resource from the
+// result of a JSONPath expression.
+type HealthCheck struct {
+	// JSONPath expression evaluated against the target resource
+	// (e.g. `{.status.conditions[?(@.type=="Ready")].status}`).
+	JSONPath string `json:"jsonPath"`
+	// Values of the expression that indicate the resource is
+	// healthy.  The resource is unhealthy if no value of the
+	// expression is one of these values.
+	HealthyValues []string `json:"healthyValues"`
+}
+
+// StatusAggregationRule computes a field of the aggregated status of a
+// federated resource from the values of a field of the target
+// resources in its selected member clusters.
+type StatusAggregationRule struct {
+	// Name of the field of the aggregated status.
+	Field string `json:"field"`
+	// Path of the field of the target resources as a JSON pointer
+	// (e.g. `/status/readyReplicas`).
+	Path string `json:"path"`
+	// How the values of the target resources are combined.
+	// +kubebuilder:validation:Enum=Sum,Min,Max,All,Any,Append,MergeConditions
+	Operation StatusAggregationOperation `json:"operation"`
+}
+
+// StatusAggregationOperation determines how the values of a field of
+// target resources in member clusters are combined.
+type StatusAggregationOperation string
+
+const (
+	// StatusAggregationSum sums numeric values.
+	StatusAggregationSum StatusAggregationOperation = "Sum"
+	// StatusAggregationMin determines the smallest numeric value.
+	StatusAggregationMin StatusAggregationOperation = "Min"
+	// StatusAggregationMax determines the largest numeric value.
+	StatusAggregationMax StatusAggregationOperation = "Max"
+	// StatusAggregationAll indicates whether the boolean value is
+	// true in every cluster.
+	StatusAggregationAll StatusAggregationOperation = "All"
+	// StatusAggregationAny indicates whether the boolean value is
+	// true in at least one cluster.
+	StatusAggregationAny StatusAggregationOperation = "Any"
+	// StatusAggregationAppend concatenates list values.
+	StatusAggregationAppend StatusAggregationOperation = "Append"
+	// StatusAggregationMergeConditions merges lists of conditions
+	// by type.
+	StatusAggregationMergeConditions StatusAggregationOperation = "MergeConditions"
+)
+
+// AdoptionPolicy determines whether a resource that already exists
+// in a member cluster is adopted by the federated resource that
+// would otherwise create it.
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyAlways indicates that existing resources are
+	// adopted.
+	AdoptionPolicyAlways AdoptionPolicy = "Always"
+	// AdoptionPolicyNever indicates that existing resources are not
+	// adopted.
+	AdoptionPolicyNever AdoptionPolicy = "Never"
+	// AdoptionPolicyIfIdentical indicates that an existing resource
+	// is adopted only if it does not differ from the resource that
+	// would be propagated.
+	AdoptionPolicyIfIdentical AdoptionPolicy = "IfIdentical"
+	// AdoptionPolicyIfLabelled indicates that an existing resource
+	// is adopted only if it has the `kubefed.k8s.io/adoptable: "true"`
+	// label.
+	AdoptionPolicyIfLabelled AdoptionPolicy = "IfLabelled"
+)
+
+// DeletionPolicy determines what happens to a resource in a member
+// cluster when the cluster is no longer selected by the placement of
+// the federated resource, or when the federated resource is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete indicates that the resource is deleted.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan indicates that the managed label is
+	// removed from the resource and the resource is left in place.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyDelayedDelete indicates that a resource in a
+	// cluster that is no longer selected is deleted once a grace
+	// period has elapsed.  When the federated resource is deleted,
+	// resources are deleted immediately.
+	DeletionPolicyDelayedDelete DeletionPolicy = "DelayedDelete"
+)
+
+// ServerSideApply configures the use of server-side apply to write
+// resources to member clusters.
+type ServerSideApply struct {
+	// Whether to take ownership of fields managed by other actors
+	// whose values differ from the desired values.  By default an
+	// apply that conflicts with another actor fails and the conflict
+	// is reported in the status of the federated resource.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+// RetainField identifies a field of a target resource whose value in
+// member clusters is retained.
+type RetainField struct {
+	// Path of the field as a JSON pointer (e.g. `/spec/clusterIP`).
+	// A path segment of `*` matches each element of a list
+	// (e.g. `/spec/ports/*/nodePort`).
+	Path string `json:"path"`
+	// Whether to retain the value only if the field is absent from
+	// the desired resource (i.e. the template with overrides
+	// applied).  By default the value in the member cluster replaces
+	// any desired value.
+	// +optional
+	OnlyIfAbsent bool `json:"onlyIfAbsent,omitempty"`
+	// Fields identifying the elements of lists matched by `*` in the
+	// path.  The value of a field is retained from the list element
+	// in the member cluster whose values for these fields equal
+	// those of the desired list element.  If not provided, list
+	// elements are matched by index.
+	// +optional
+	MatchKeys []string `json:"matchKeys,omitempty"`
 }
 
 // APIResource defines how to configure the dynamic client for an API resource.
@@ -145,6 +318,76 @@ func SetFederatedTypeConfigDefaults(obj *FederatedTypeConfig) {
 		setStringDefault(&obj.Spec.Status.Group, obj.Spec.FederatedType.Group)
 		setStringDefault(&obj.Spec.Status.Version, obj.Spec.FederatedType.Version)
 	}
+	if obj.Spec.StatusAggregation == nil {
+		obj.Spec.StatusAggregation = DefaultStatusAggregation(obj.Spec.Target)
+	}
+}
+
+// DefaultRetainFields returns the fields of the given target type
+// that are set by kubernetes in member clusters and must be retained
+// to avoid contention with the controllers setting them.
+func DefaultRetainFields(target APIResource) []RetainField {
+	if target.Group != "" {
+		return nil
+	}
+	switch target.Kind {
+	case "Service":
+		// The cluster IP and node ports of a service are allocated
+		// in the member cluster.
+		return []RetainField{
+			{Path: "/spec/clusterIP"},
+			{Path: "/spec/ports/*/nodePort", MatchKeys: []string{"name", "protocol", "port"}},
+		}
+	case "ServiceAccount":
+		// Clearing the generated secrets of a service account would
+		// prompt continual regeneration by the service account
+		// controller in the member cluster.
+		return []RetainField{
+			{Path: "/secrets", OnlyIfAbsent: true},
+		}
+	}
+	return nil
+}
+
+// DefaultStatusAggregation returns the rules for summarizing the
+// status of the given target type across member clusters.
+func DefaultStatusAggregation(target APIResource) []StatusAggregationRule {
+	sum := func(field string) StatusAggregationRule {
+		return StatusAggregationRule{Field: field, Path: "/status/" + field, Operation: StatusAggregationSum}
+	}
+	conditions := StatusAggregationRule{Field: "conditions", Path: "/status/conditions", Operation: StatusAggregationMergeConditions}
+	switch {
+	case target.Kind == "Deployment" && (target.Group == "apps" || target.Group == "extensions"):
+		return []StatusAggregationRule{
+			sum("replicas"),
+			sum("updatedReplicas"),
+			sum("readyReplicas"),
+			sum("availableReplicas"),
+			sum("unavailableReplicas"),
+			conditions,
+		}
+	case target.Kind == "ReplicaSet" && (target.Group == "apps" || target.Group == "extensions"):
+		return []StatusAggregationRule{
+			sum("replicas"),
+			sum("fullyLabeledReplicas"),
+			sum("readyReplicas"),
+			sum("availableReplicas"),
+			conditions,
+		}
+	case target.Kind == "Job" && target.Group == "batch":
+		return []StatusAggregationRule{
+			sum("active"),
+			sum("succeeded"),
+			sum("failed"),
+			conditions,
+		}
+	case target.Kind == "Service" && target.Group == "":
+		// The load balancer ingress points of all clusters.
+		return []StatusAggregationRule{
+			{Field: "loadBalancerIngress", Path: "/status/loadBalancer/ingress", Operation: StatusAggregationAppend},
+		}
+	}
+	return nil
 }
 
 // GetDefaultedString returns the value if provided, and otherwise
@@ -188,6 +431,49 @@ func (f *FederatedTypeConfig) GetPropagationEnabled() bool {
 	return f.Spec.PropagationEnabled
 }
 
+// GetRetainFields returns the retention rules of the type, including
+// the default rules for the target type unless they are disabled.
+func (f *FederatedTypeConfig) GetRetainFields() []RetainField {
+	if f.Spec.DisableDefaultRetainFields {
+		return f.Spec.RetainFields
+	}
+	paths := make(map[string]bool)
+	for _, field := range f.Spec.RetainFields {
+		paths[field.Path] = true
+	}
+	retainFields := []RetainField{}
+	for _, field := range DefaultRetainFields(f.Spec.Target) {
+		if !paths[field.Path] {
+			retainFields = append(retainFields, field)
+		}
+	}
+	return append(retainFields, f.Spec.RetainFields...)
+}
+
+func (f *FederatedTypeConfig) GetServerSideApply() *ServerSideApply {
+	return f.Spec.ServerSideApply
+}
+
+func (f *FederatedTypeConfig) GetDeletionPolicy() DeletionPolicy {
+	return f.Spec.DeletionPolicy
+}
+
+func (f *FederatedTypeConfig) GetDeletionGracePeriodSeconds() *int64 {
+	return f.Spec.DeletionGracePeriodSeconds
+}
+
+func (f *FederatedTypeConfig) GetAdoptionPolicy() AdoptionPolicy {
+	return f.Spec.AdoptionPolicy
+}
+
+func (f *FederatedTypeConfig) GetStatusAggregation() []StatusAggregationRule {
+	return f.Spec.StatusAggregation
+}
+
+func (f *FederatedTypeConfig) GetHealthCheck() *HealthCheck {
+	return f.Spec.HealthCheck
+}
+
 func (f *FederatedTypeConfig) GetFederatedType() metav1.APIResource {
 	return apiResourceToMeta(f.Spec.FederatedType, f.GetFederatedNamespaced())
 }
@@ -23,18 +23,80 @@ import (
 	"sigs.k8s.io/kubefed/pkg/apis/core/common"
 )
 
+// ConnectionMode determines how the control plane communicates with a
+// member cluster.
+type ConnectionMode string
+
+const (
+	// The control plane connects to the API endpoint of the member
+	// cluster.
+	ConnectionModePush ConnectionMode = "Push"
+	// An agent running in the member cluster connects to the control
+	// plane, applies the resources destined for the cluster and
+	// reports their state and the health of the cluster.
+	ConnectionModePull ConnectionMode = "Pull"
+)
+
 // KubefedClusterSpec defines the desired state of KubefedCluster
 type KubefedClusterSpec struct {
 	// The API endpoint of the member cluster. This can be a hostname,
-	// hostname:port, IP or IP:port.
-	APIEndpoint string `json:"apiEndpoint"`
+	// hostname:port, IP or IP:port.  Required unless the connection
+	// mode is Pull.
+	// +optional
+	APIEndpoint string `json:"apiEndpoint,omitempty"`
 
-	// Name of the secret containing a token and ca bundle required to
-	// access the member cluster.
+	// Name of the secret containing the credentials and ca bundle
+	// required to access the member cluster.  Required unless the
+	// connection mode is Pull.
 	//
 	// The secret needs to exist in the same namespace as the control
-	// plane and should have keys for "token" and "ca.crt".
-	SecretRef LocalSecretReference `json:"secretRef"`
+	// plane.  It should have a key for "token", or keys for
+	// "tls.crt" and "tls.key" to authenticate with a client
+	// certificate, unless credentials are provided by an exec
+	// plugin.  The ca bundle is read from "ca.crt" and the system
+	// roots are used if it is not present.
+	// +optional
+	SecretRef LocalSecretReference `json:"secretRef,omitempty"`
+
+	// A credential plugin that is executed by the control plane to
+	// obtain credentials for the member cluster.  Takes precedence
+	// over the token and client certificate of the secret.
+	// +optional
+	Exec *ExecCredentialConfig `json:"exec,omitempty"`
+
+	// The URL of the proxy used for requests to the member cluster.
+	// The scheme must be one of http, https or socks5.
+	// +optional
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// The server name used to verify the serving certificate of the
+	// member cluster.  Defaults to the host of the API endpoint.
+	// +optional
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// Whether the serving certificate of the member cluster is
+	// accepted without verification.  This is insecure and intended
+	// for testing only.
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// The maximum number of queries per second the control plane
+	// sends to the member cluster.  Defaults to 20.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	QPS *int32 `json:"qps,omitempty"`
+
+	// The maximum burst of queries the control plane sends to the
+	// member cluster.  Defaults to 30.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+
+	// How the control plane communicates with the member cluster.
+	// Defaults to Push.
+	// +kubebuilder:validation:Enum=Push,Pull
+	// +optional
+	ConnectionMode ConnectionMode `json:"connectionMode,omitempty"`
 }
 
 // LocalSecretReference is a reference to a secret within the enclosing
@@ -45,6 +107,30 @@ type LocalSecretReference struct {
 	Name string `json:"name"`
 }
 
+// ExecCredentialConfig configures a command that provides credentials
+// for a member cluster in the format of a client.authentication.k8s.io
+// ExecCredential.
+type ExecCredentialConfig struct {
+	// The command to execute.  It must be available to the control
+	// plane.
+	Command string `json:"command"`
+	// Arguments to pass to the command.
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// Additional environment variables to expose to the command.
+	// +optional
+	Env []ExecEnvVar `json:"env,omitempty"`
+	// The API version of the ExecCredential exchanged with the
+	// command (e.g. client.authentication.k8s.io/v1beta1).
+	APIVersion string `json:"apiVersion"`
+}
+
+// ExecEnvVar is an environment variable of an exec credential plugin.
+type ExecEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // KubefedClusterStatus contains information about the current status of a
 // cluster updated periodically by cluster controller.
 type KubefedClusterStatus struct {
@@ -57,6 +143,35 @@ type KubefedClusterStatus struct {
 	// Region is the name of the region in which all of the nodes in the cluster exist.  e.g. 'us-east1'.
 	// +optional
 	Region string `json:"region,omitempty"`
+	// CredentialRotation describes the rotation of the service
+	// account token the control plane uses to access the cluster.
+	// +optional
+	CredentialRotation *CredentialRotationStatus `json:"credentialRotation,omitempty"`
+}
+
+// CredentialRotationStatus describes the rotation of the service account
+// token of a member cluster by the credential rotation controller.
+type CredentialRotationStatus struct {
+	// The time the token was last rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// The time the token is next due to be rotated.
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// The name of the secret holding the token replaced by the last
+	// rotation.  The token is revoked and the secret is deleted once
+	// the revocation delay has passed.
+	// +optional
+	PreviousSecretName string `json:"previousSecretName,omitempty"`
+	// The time of the last attempt to rotate or revoke the token that
+	// failed.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// Human readable message indicating why the last attempt failed or
+	// the token cannot be rotated.  Empty if the last attempt
+	// succeeded.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
@@ -99,6 +214,12 @@ type ClusterCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// IsPullMode indicates whether resources are propagated to the cluster
+// by an agent running in the cluster.
+func (c *KubefedCluster) IsPullMode() bool {
+	return c.Spec.ConnectionMode == ConnectionModePull
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // KubefedClusterList contains a list of KubefedCluster
@@ -31,6 +31,9 @@ type KubefedConfigSpec struct {
 	FeatureGates       []FeatureGatesConfig     `json:"feature-gates,omitempty"`
 	ClusterHealthCheck ClusterHealthCheckConfig `json:"cluster-health-check,omitempty"`
 	SyncController     SyncControllerConfig     `json:"sync-controller,omitempty"`
+	Sharding           ShardingConfig           `json:"sharding,omitempty"`
+	CredentialRotation CredentialRotationConfig `json:"credential-rotation,omitempty"`
+	ClusterCredentials ClusterCredentialsConfig `json:"cluster-credentials,omitempty"`
 }
 
 type DurationConfig struct {
@@ -71,13 +74,139 @@ type ClusterHealthCheckConfig struct {
 	SuccessThreshold int `json:"success-threshold,omitempty"`
 	// Number of seconds after which the cluster health check times out.
 	TimeoutSeconds int `json:"timeout-seconds,omitempty"`
+	// Health checks that are run in addition to requesting /healthz,
+	// which determines whether a cluster is offline.  Each check
+	// reports a condition named after the check in the status of a
+	// cluster.
+	Checks []ClusterHealthCheck `json:"checks,omitempty"`
+	// The names of the checks that must pass for a cluster to be
+	// considered ready.  Checks that are not listed only report their
+	// condition.
+	ReadyGates []string `json:"ready-gates,omitempty"`
+}
+
+type ClusterHealthCheckType string
+
+const (
+	// ReadyzHealthCheck requests /readyz of the cluster.
+	ReadyzHealthCheck ClusterHealthCheckType = "Readyz"
+	// LatencyHealthCheck compares the round-trip time of the request
+	// to /healthz of the cluster to a maximum latency.
+	LatencyHealthCheck ClusterHealthCheckType = "Latency"
+	// NodeReadinessHealthCheck compares the percentage of the nodes
+	// of the cluster that are ready to a minimum.
+	NodeReadinessHealthCheck ClusterHealthCheckType = "NodeReadiness"
+	// ProbeHealthCheck requests a named resource from the cluster.
+	ProbeHealthCheck ClusterHealthCheckType = "Probe"
+)
+
+// ClusterHealthCheck configures a check of the health of member
+// clusters.
+type ClusterHealthCheck struct {
+	// The name of the check, which is the type of the condition the
+	// check reports.  Must not be `Ready` or `Offline`.
+	Name string `json:"name"`
+	// The type of the check.  Supported types are `Readyz`, `Latency`,
+	// `NodeReadiness` and `Probe`.
+	Type ClusterHealthCheckType `json:"type"`
+	// The maximum round-trip time of a request for a `Latency` check.
+	// Defaults to 1s.
+	MaxLatency metav1.Duration `json:"max-latency,omitempty"`
+	// The minimum percentage of nodes that are ready for a
+	// `NodeReadiness` check.  Defaults to 100.
+	MinReadyNodesPercent int `json:"min-ready-nodes-percent,omitempty"`
+	// The resource requested by a `Probe` check.
+	Probe *ClusterHealthProbe `json:"probe,omitempty"`
+}
+
+// ClusterHealthProbe identifies a resource whose retrieval indicates
+// that a cluster is healthy.
+type ClusterHealthProbe struct {
+	// The API version of the resource (e.g. `v1` or `apps/v1`).
+	APIVersion string `json:"api-version"`
+	// The plural name of the resource (e.g. `configmaps`).
+	Resource string `json:"resource"`
+	// The namespace of the resource.  Empty for a cluster-scoped
+	// resource.
+	Namespace string `json:"namespace,omitempty"`
+	// The name of the resource.
+	Name string `json:"name"`
 }
 
 type SyncControllerConfig struct {
-	// Whether to skip adopting pre-existing resource in member clusters. Defaults to false
+	// Whether to skip adopting pre-existing resource in member clusters
+	// for types that do not specify an adoption policy. Defaults to false
 	SkipAdoptingResources bool `json:"skip-adopting-resources,omitempty"`
+	// Time to wait for the operations dispatched to member clusters
+	// when reconciling a federated resource to complete, including
+	// time spent waiting for the concurrency limits to permit them to
+	// run.  Operations still in progress are cancelled.  Defaults to
+	// 30s.
+	UpdateTimeout metav1.Duration `json:"update-timeout,omitempty"`
+	// Time to wait before reconciling each federated resource after
+	// the set of available member clusters changes.  Defaults to 3s.
+	ReconcileDelay metav1.Duration `json:"reconcile-delay,omitempty"`
+	// Maximum number of operations dispatched concurrently to member
+	// clusters when reconciling a federated resource.  Defaults to 0
+	// (no limit).
+	MaxConcurrentDispatches int `json:"max-concurrent-dispatches,omitempty"`
+	// Maximum number of operations dispatched concurrently to a
+	// single member cluster by the sync controllers of all federated
+	// types.  Defaults to 0 (no limit).
+	MaxConcurrentDispatchesPerCluster int `json:"max-concurrent-dispatches-per-cluster,omitempty"`
 }
 
+type ShardingConfig struct {
+	// How the propagation of federated resources is divided between
+	// replicas of the controller manager.  Supported options are
+	// `TypeNamespace` and `Cluster`.  If not provided, the work is not
+	// divided and is performed by the elected leader.
+	Mode ShardingMode `json:"mode,omitempty"`
+	// The duration after which a replica that has not renewed its
+	// shard lease is no longer considered a member of the shard set.
+	// Defaults to 15s.
+	LeaseDuration metav1.Duration `json:"lease-duration,omitempty"`
+	// The interval at which replicas renew their shard lease and
+	// observe the leases of other replicas.  Defaults to 5s.
+	RenewPeriod metav1.Duration `json:"renew-period,omitempty"`
+}
+
+type CredentialRotationConfig struct {
+	// Whether the service account tokens that the control plane uses
+	// to access member clusters are rotated.  Defaults to false.
+	Enabled bool `json:"enabled,omitempty"`
+	// How often the token of each member cluster is rotated.
+	// Defaults to 720h (30 days).
+	Period metav1.Duration `json:"period,omitempty"`
+	// Time to wait after a token has been replaced before it is
+	// revoked, so that controllers still using the token can switch
+	// to its replacement.  Defaults to 5m.
+	RevocationDelay metav1.Duration `json:"revocation-delay,omitempty"`
+}
+
+type ClusterCredentialsConfig struct {
+	// The commands that the exec plugins of member clusters are
+	// allowed to run.  An exec plugin runs in the controller manager
+	// with its service account and filesystem, so anyone able to
+	// create or update a KubefedCluster could otherwise run arbitrary
+	// commands in the control plane.  Exec plugins are disabled if no
+	// commands are listed.
+	AllowedExecCommands []string `json:"allowed-exec-commands,omitempty"`
+}
+
+// ShardingMode determines how the propagation of federated resources
+// is divided between replicas of the controller manager.
+type ShardingMode string
+
+const (
+	// ShardByTypeNamespace indicates that each replica propagates the
+	// federated resources of the types and namespaces it owns.
+	ShardByTypeNamespace ShardingMode = "TypeNamespace"
+	// ShardByCluster indicates that each replica propagates federated
+	// resources to the member clusters it owns.
+	ShardByCluster ShardingMode = "Cluster"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
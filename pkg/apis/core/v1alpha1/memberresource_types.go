@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// MemberResourceSpec defines the desired state of MemberResource
+type MemberResourceSpec struct {
+	// The name of the pull-mode cluster the resource is destined for.
+	ClusterName string `json:"clusterName"`
+	// The plural name of the API resource of the object
+	// (e.g. deployments).
+	Resource string `json:"resource"`
+	// The object to write to the cluster, as rendered for the
+	// cluster by the sync controller.  The data of a Secret is not
+	// included.
+	Object runtime.RawExtension `json:"object"`
+	// The name of the secret in the namespace of the MemberResource
+	// that holds the data of a Secret object, so that the data is
+	// only stored in a Secret in the host cluster.
+	// +optional
+	DataSecretName string `json:"dataSecretName,omitempty"`
+	// The resource version of the data secret the object was
+	// rendered with.  A change to the data of the object changes the
+	// version and thereby the generation of the MemberResource.
+	// +optional
+	DataSecretVersion string `json:"dataSecretVersion,omitempty"`
+	// If set, the object is written with server-side apply as the
+	// given field manager.  Otherwise the object is created or
+	// updated.
+	// +optional
+	FieldManager string `json:"fieldManager,omitempty"`
+	// Whether fields managed by other field managers are taken over
+	// by server-side apply instead of conflicting.
+	// +optional
+	Force bool `json:"force,omitempty"`
+	// Whether the object is left in the cluster when the
+	// MemberResource is deleted.  An orphaned object is no longer
+	// labeled as managed.
+	// +optional
+	Orphan bool `json:"orphan,omitempty"`
+}
+
+// MemberResourceStatus defines the observed state of MemberResource
+type MemberResourceStatus struct {
+	// The generation of the MemberResource last applied by the agent.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The version of the object produced by the last write of the
+	// agent.  The agent writes the object again if its version in the
+	// cluster differs.
+	// +optional
+	AppliedVersion string `json:"appliedVersion,omitempty"`
+	// The error that prevented the agent from writing the object for
+	// the observed generation.
+	// +optional
+	Error string `json:"error,omitempty"`
+	// The object as last observed in the cluster by the agent.  The
+	// data of a Secret is not reported.
+	// +optional
+	Object *runtime.RawExtension `json:"object,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MemberResource holds an object destined for a cluster whose
+// connection mode is Pull.  MemberResources are written by the sync
+// controller in place of the object in the cluster, and the agent
+// running in the cluster writes the object and reports its state.
+// The MemberResources of a cluster are held in a namespace of the host
+// cluster dedicated to the cluster.
+//
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=memberresources
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name=cluster,type=string,JSONPath=.spec.clusterName
+// +kubebuilder:printcolumn:name=resource,type=string,JSONPath=.spec.resource
+// +kubebuilder:printcolumn:name=age,type=date,JSONPath=.metadata.creationTimestamp
+type MemberResource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MemberResourceSpec   `json:"spec,omitempty"`
+	Status MemberResourceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MemberResourceList contains a list of MemberResource
+type MemberResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MemberResource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MemberResource{}, &MemberResourceList{})
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OverridePolicySpec defines the desired state of OverridePolicy
+type OverridePolicySpec struct {
+	// ResourceSelector determines the federated resources the policy
+	// applies to.
+	ResourceSelector PolicyResourceSelector `json:"resourceSelector"`
+
+	// Priority determines which policy will be used when more than
+	// one policy of the same scope selects a federated resource.  The
+	// policy with the highest priority will be used.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Overrides have the same semantics as the overrides field of a
+	// federated resource.  Overrides defined by a federated resource
+	// take precedence over overrides for the same path defined by a
+	// policy.
+	Overrides []PolicyOverride `json:"overrides,omitempty"`
+}
+
+// PolicyOverride defines the overrides for either a single named
+// cluster or the clusters matching a label selector.
+type PolicyOverride struct {
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Each override is a JSON Patch operation with op, path and value
+	// fields as per the overrides of a federated resource.  The value
+	// of an override may be of any type, which precludes up-front
+	// validation.
+	// +optional
+	ClusterOverrides []runtime.RawExtension `json:"clusterOverrides,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OverridePolicy determines overrides for the federated resources it
+// selects in its namespace.
+//
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=overridepolicies
+type OverridePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OverridePolicySpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OverridePolicyList contains a list of OverridePolicy
+type OverridePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OverridePolicy `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ClusterOverridePolicy determines overrides for the federated
+// resources it selects in any namespace.  An OverridePolicy selecting
+// a federated resource takes precedence over a ClusterOverridePolicy.
+//
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=clusteroverridepolicies
+type ClusterOverridePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OverridePolicySpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ClusterOverridePolicyList contains a list of ClusterOverridePolicy
+type ClusterOverridePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterOverridePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OverridePolicy{}, &OverridePolicyList{})
+	SchemeBuilder.Register(&ClusterOverridePolicy{}, &ClusterOverridePolicyList{})
+}
@@ -28,7 +28,10 @@ type PropagatedVersionSpec struct {
 type PropagatedVersionStatus struct {
 	// The observed version of the template for this resource.
 	TemplateVersion string `json:"templateVersion,omitempty"`
-	// The observed version of the overrides for this resource.
+	// The observed version of the overrides for all clusters.  Only
+	// recorded by earlier versions of the sync controller, which did
+	// not track the version of overrides for each cluster.
+	// +optional
 	OverrideVersion string `json:"overridesVersion,omitempty"`
 	// The last versions produced in each cluster for this resource.
 	ClusterVersions []ClusterObjectVersion `json:"clusterVersions,omitempty"`
@@ -40,6 +43,10 @@ type ClusterObjectVersion struct {
 	// The last version produced for the resource by a federation
 	// operation.
 	Version string `json:"version,omitempty"`
+	// The version of the overrides for the cluster that the version
+	// was produced for.  Empty if the cluster had no overrides.
+	// +optional
+	OverrideVersion string `json:"overridesVersion,omitempty"`
 }
 
 // +genclient
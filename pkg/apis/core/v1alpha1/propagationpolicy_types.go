@@ -0,0 +1,207 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PropagationPolicySpec defines the desired state of PropagationPolicy
+type PropagationPolicySpec struct {
+	// ResourceSelector determines the federated resources the policy
+	// applies to.
+	ResourceSelector PolicyResourceSelector `json:"resourceSelector"`
+
+	// Priority determines which policy will be used when more than
+	// one policy of the same scope selects a federated resource.  The
+	// policy with the highest priority will be used.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Placement determines the member clusters that selected
+	// federated resources will be propagated to.  Placement defined
+	// by a federated resource takes precedence over placement defined
+	// by a policy.
+	Placement PolicyPlacement `json:"placement"`
+}
+
+// PolicyResourceSelector determines the federated resources that a
+// policy applies to.  A federated resource is selected if it matches
+// all of the provided criteria.
+type PolicyResourceSelector struct {
+	// Kinds of the federated resources to select
+	// (e.g. FederatedDeployment).  If empty, federated resources of
+	// all kinds will be selected.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+
+	// LabelSelector selects federated resources by label.  If not
+	// provided, federated resources will be selected regardless of
+	// their labels.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// NamespaceSelector selects federated resources by the labels of
+	// their containing namespace.  It is only honored by
+	// cluster-scoped policies.  If not provided, federated resources
+	// in all namespaces will be selected.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// PolicyPlacement has the same semantics as the placement field of a
+// federated resource.  If one or more clusters is provided, the
+// clusterSelector field will be ignored.
+type PolicyPlacement struct {
+	// +optional
+	Clusters []ClusterReference `json:"clusters,omitempty"`
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// +optional
+	SpreadConstraints *SpreadConstraints `json:"spreadConstraints,omitempty"`
+	// +optional
+	Failover *FailoverPlacement `json:"failover,omitempty"`
+}
+
+type SpreadBy string
+
+const (
+	// Spread selected clusters across the regions reported in
+	// KubefedClusterStatus.
+	SpreadByRegion SpreadBy = "Region"
+	// Spread selected clusters across the zones reported in
+	// KubefedClusterStatus.  Clusters spanning more than one zone
+	// are grouped by their full set of zones.
+	SpreadByZone SpreadBy = "Zone"
+	// Spread selected clusters across the values of a KubefedCluster
+	// label.
+	SpreadByLabel SpreadBy = "Label"
+)
+
+// SpreadConstraints limit the clusters selected by the clusters or
+// clusterSelector fields of placement.  Selection is deterministic,
+// and clusters that were previously selected are preferred to avoid
+// placement changing when a resource is reconciled again.
+type SpreadConstraints struct {
+	// SpreadBy determines the topology that selected clusters are
+	// spread across.  If provided, at most one cluster will be
+	// selected for each distinct region, zone or label value, and
+	// clusters without a value will not be selected.
+	// +optional
+	SpreadBy SpreadBy `json:"spreadBy,omitempty"`
+
+	// LabelKey is the KubefedCluster label to spread by when SpreadBy
+	// is Label.
+	// +optional
+	LabelKey string `json:"labelKey,omitempty"`
+
+	// MaxClusters is the maximum number of clusters to select.  If
+	// not provided, the number of selected clusters is not limited.
+	// +optional
+	MaxClusters int32 `json:"maxClusters,omitempty"`
+
+	// MinClusters is the minimum number of clusters to select.  If
+	// fewer clusters can be selected, placement will fail.
+	// +optional
+	MinClusters int32 `json:"minClusters,omitempty"`
+}
+
+// FailoverPlacement selects clusters from an ordered list of
+// preferred clusters, replacing selected clusters that become
+// unavailable with the next available preferred cluster.  If
+// provided, the clusters and clusterSelector fields of placement will
+// be ignored.
+type FailoverPlacement struct {
+	// PreferredClusters lists candidate clusters in order of
+	// preference.
+	PreferredClusters []ClusterReference `json:"preferredClusters"`
+
+	// ClusterCount is the number of clusters to select.  Defaults to
+	// 1.
+	// +optional
+	ClusterCount int32 `json:"clusterCount,omitempty"`
+
+	// ReplaceUnavailableAfter is how long a selected cluster may be
+	// unavailable (i.e. its Ready condition is not true) before it is
+	// replaced.  If not provided, an unavailable cluster is replaced
+	// immediately.  A more preferred cluster is selected again as
+	// soon as it is available.
+	// +optional
+	ReplaceUnavailableAfter *metav1.Duration `json:"replaceUnavailableAfter,omitempty"`
+}
+
+// ClusterReference is a reference to a member cluster.
+type ClusterReference struct {
+	Name string `json:"name"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PropagationPolicy determines placement for the federated resources
+// it selects in its namespace.
+//
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=propagationpolicies
+type PropagationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PropagationPolicySpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PropagationPolicyList contains a list of PropagationPolicy
+type PropagationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PropagationPolicy `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ClusterPropagationPolicy determines placement for the federated
+// resources it selects in any namespace.  A PropagationPolicy
+// selecting a federated resource takes precedence over a
+// ClusterPropagationPolicy.
+//
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=clusterpropagationpolicies
+type ClusterPropagationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PropagationPolicySpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ClusterPropagationPolicyList contains a list of ClusterPropagationPolicy
+type ClusterPropagationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPropagationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PropagationPolicy{}, &PropagationPolicyList{})
+	SchemeBuilder.Register(&ClusterPropagationPolicy{}, &ClusterPropagationPolicyList{})
+}
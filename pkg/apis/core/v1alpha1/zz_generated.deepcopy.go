@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -58,9 +59,64 @@ func (in *ClusterCondition) DeepCopy() *ClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCredentialsConfig) DeepCopyInto(out *ClusterCredentialsConfig) {
+	*out = *in
+	if in.AllowedExecCommands != nil {
+		in, out := &in.AllowedExecCommands, &out.AllowedExecCommands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCredentialsConfig.
+func (in *ClusterCredentialsConfig) DeepCopy() *ClusterCredentialsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCredentialsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHealthCheck) DeepCopyInto(out *ClusterHealthCheck) {
+	*out = *in
+	out.MaxLatency = in.MaxLatency
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(ClusterHealthProbe)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHealthCheck.
+func (in *ClusterHealthCheck) DeepCopy() *ClusterHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterHealthCheckConfig) DeepCopyInto(out *ClusterHealthCheckConfig) {
 	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]ClusterHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReadyGates != nil {
+		in, out := &in.ReadyGates, &out.ReadyGates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -74,6 +130,22 @@ func (in *ClusterHealthCheckConfig) DeepCopy() *ClusterHealthCheckConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHealthProbe) DeepCopyInto(out *ClusterHealthProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHealthProbe.
+func (in *ClusterHealthProbe) DeepCopy() *ClusterHealthProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHealthProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterObjectVersion) DeepCopyInto(out *ClusterObjectVersion) {
 	*out = *in
@@ -90,6 +162,66 @@ func (in *ClusterObjectVersion) DeepCopy() *ClusterObjectVersion {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOverridePolicy) DeepCopyInto(out *ClusterOverridePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOverridePolicy.
+func (in *ClusterOverridePolicy) DeepCopy() *ClusterOverridePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOverridePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterOverridePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOverridePolicyList) DeepCopyInto(out *ClusterOverridePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterOverridePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOverridePolicyList.
+func (in *ClusterOverridePolicyList) DeepCopy() *ClusterOverridePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOverridePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterOverridePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPropagatedVersion) DeepCopyInto(out *ClusterPropagatedVersion) {
 	*out = *in
@@ -167,6 +299,128 @@ func (in *ClusterPropagatedVersionSpec) DeepCopy() *ClusterPropagatedVersionSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPropagationPolicy) DeepCopyInto(out *ClusterPropagationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPropagationPolicy.
+func (in *ClusterPropagationPolicy) DeepCopy() *ClusterPropagationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPropagationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterPropagationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPropagationPolicyList) DeepCopyInto(out *ClusterPropagationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterPropagationPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPropagationPolicyList.
+func (in *ClusterPropagationPolicyList) DeepCopy() *ClusterPropagationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPropagationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterPropagationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReference) DeepCopyInto(out *ClusterReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReference.
+func (in *ClusterReference) DeepCopy() *ClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialRotationConfig) DeepCopyInto(out *CredentialRotationConfig) {
+	*out = *in
+	out.Period = in.Period
+	out.RevocationDelay = in.RevocationDelay
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialRotationConfig.
+func (in *CredentialRotationConfig) DeepCopy() *CredentialRotationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialRotationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialRotationStatus) DeepCopyInto(out *CredentialRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialRotationStatus.
+func (in *CredentialRotationStatus) DeepCopy() *CredentialRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DurationConfig) DeepCopyInto(out *DurationConfig) {
 	*out = *in
@@ -185,6 +439,74 @@ func (in *DurationConfig) DeepCopy() *DurationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecCredentialConfig) DeepCopyInto(out *ExecCredentialConfig) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]ExecEnvVar, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecCredentialConfig.
+func (in *ExecCredentialConfig) DeepCopy() *ExecCredentialConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecCredentialConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecEnvVar) DeepCopyInto(out *ExecEnvVar) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecEnvVar.
+func (in *ExecEnvVar) DeepCopy() *ExecEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecEnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverPlacement) DeepCopyInto(out *FailoverPlacement) {
+	*out = *in
+	if in.PreferredClusters != nil {
+		in, out := &in.PreferredClusters, &out.PreferredClusters
+		*out = make([]ClusterReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReplaceUnavailableAfter != nil {
+		in, out := &in.ReplaceUnavailableAfter, &out.ReplaceUnavailableAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverPlacement.
+func (in *FailoverPlacement) DeepCopy() *FailoverPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FeatureGatesConfig) DeepCopyInto(out *FeatureGatesConfig) {
 	*out = *in
@@ -355,6 +677,33 @@ func (in *FederatedTypeConfigSpec) DeepCopyInto(out *FederatedTypeConfigSpec) {
 		*out = new(APIResource)
 		**out = **in
 	}
+	if in.RetainFields != nil {
+		in, out := &in.RetainFields, &out.RetainFields
+		*out = make([]RetainField, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerSideApply != nil {
+		in, out := &in.ServerSideApply, &out.ServerSideApply
+		*out = new(ServerSideApply)
+		**out = **in
+	}
+	if in.DeletionGracePeriodSeconds != nil {
+		in, out := &in.DeletionGracePeriodSeconds, &out.DeletionGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.StatusAggregation != nil {
+		in, out := &in.StatusAggregation, &out.StatusAggregation
+		*out = make([]StatusAggregationRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -385,29 +734,50 @@ func (in *FederatedTypeConfigStatus) DeepCopy() *FederatedTypeConfigStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubefedCluster) DeepCopyInto(out *KubefedCluster) {
+func (in *HealthCheck) DeepCopyInto(out *HealthCheck) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
+	if in.HealthyValues != nil {
+		in, out := &in.HealthyValues, &out.HealthyValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubefedCluster.
-func (in *KubefedCluster) DeepCopy() *KubefedCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheck.
+func (in *HealthCheck) DeepCopy() *HealthCheck {
 	if in == nil {
 		return nil
 	}
-	out := new(KubefedCluster)
+	out := new(HealthCheck)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KubefedCluster) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubefedCluster) DeepCopyInto(out *KubefedCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubefedCluster.
+func (in *KubefedCluster) DeepCopy() *KubefedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(KubefedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubefedCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
 	return nil
 }
@@ -449,6 +819,21 @@ func (in *KubefedClusterList) DeepCopyObject() runtime.Object {
 func (in *KubefedClusterSpec) DeepCopyInto(out *KubefedClusterSpec) {
 	*out = *in
 	out.SecretRef = in.SecretRef
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecCredentialConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QPS != nil {
+		in, out := &in.QPS, &out.QPS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -477,6 +862,11 @@ func (in *KubefedClusterStatus) DeepCopyInto(out *KubefedClusterStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CredentialRotation != nil {
+		in, out := &in.CredentialRotation, &out.CredentialRotation
+		*out = new(CredentialRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -560,8 +950,11 @@ func (in *KubefedConfigSpec) DeepCopyInto(out *KubefedConfigSpec) {
 		*out = make([]FeatureGatesConfig, len(*in))
 		copy(*out, *in)
 	}
-	out.ClusterHealthCheck = in.ClusterHealthCheck
+	in.ClusterHealthCheck.DeepCopyInto(&out.ClusterHealthCheck)
 	out.SyncController = in.SyncController
+	out.Sharding = in.Sharding
+	out.CredentialRotation = in.CredentialRotation
+	in.ClusterCredentials.DeepCopyInto(&out.ClusterCredentials)
 	return
 }
 
@@ -610,6 +1003,284 @@ func (in *LocalSecretReference) DeepCopy() *LocalSecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberResource) DeepCopyInto(out *MemberResource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberResource.
+func (in *MemberResource) DeepCopy() *MemberResource {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MemberResource) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberResourceList) DeepCopyInto(out *MemberResourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MemberResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberResourceList.
+func (in *MemberResourceList) DeepCopy() *MemberResourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberResourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MemberResourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberResourceSpec) DeepCopyInto(out *MemberResourceSpec) {
+	*out = *in
+	in.Object.DeepCopyInto(&out.Object)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberResourceSpec.
+func (in *MemberResourceSpec) DeepCopy() *MemberResourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberResourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberResourceStatus) DeepCopyInto(out *MemberResourceStatus) {
+	*out = *in
+	if in.Object != nil {
+		in, out := &in.Object, &out.Object
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberResourceStatus.
+func (in *MemberResourceStatus) DeepCopy() *MemberResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverridePolicy) DeepCopyInto(out *OverridePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverridePolicy.
+func (in *OverridePolicy) DeepCopy() *OverridePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OverridePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OverridePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverridePolicyList) DeepCopyInto(out *OverridePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OverridePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverridePolicyList.
+func (in *OverridePolicyList) DeepCopy() *OverridePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(OverridePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OverridePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverridePolicySpec) DeepCopyInto(out *OverridePolicySpec) {
+	*out = *in
+	in.ResourceSelector.DeepCopyInto(&out.ResourceSelector)
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]PolicyOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverridePolicySpec.
+func (in *OverridePolicySpec) DeepCopy() *OverridePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OverridePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyOverride) DeepCopyInto(out *PolicyOverride) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterOverrides != nil {
+		in, out := &in.ClusterOverrides, &out.ClusterOverrides
+		*out = make([]runtime.RawExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyOverride.
+func (in *PolicyOverride) DeepCopy() *PolicyOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyPlacement) DeepCopyInto(out *PolicyPlacement) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpreadConstraints != nil {
+		in, out := &in.SpreadConstraints, &out.SpreadConstraints
+		*out = new(SpreadConstraints)
+		**out = **in
+	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(FailoverPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyPlacement.
+func (in *PolicyPlacement) DeepCopy() *PolicyPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyResourceSelector) DeepCopyInto(out *PolicyResourceSelector) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyResourceSelector.
+func (in *PolicyResourceSelector) DeepCopy() *PolicyResourceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyResourceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PropagatedVersion) DeepCopyInto(out *PropagatedVersion) {
 	*out = *in
@@ -708,9 +1379,176 @@ func (in *PropagatedVersionStatus) DeepCopy() *PropagatedVersionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationPolicy) DeepCopyInto(out *PropagationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationPolicy.
+func (in *PropagationPolicy) DeepCopy() *PropagationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PropagationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationPolicyList) DeepCopyInto(out *PropagationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PropagationPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationPolicyList.
+func (in *PropagationPolicyList) DeepCopy() *PropagationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PropagationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationPolicySpec) DeepCopyInto(out *PropagationPolicySpec) {
+	*out = *in
+	in.ResourceSelector.DeepCopyInto(&out.ResourceSelector)
+	in.Placement.DeepCopyInto(&out.Placement)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationPolicySpec.
+func (in *PropagationPolicySpec) DeepCopy() *PropagationPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainField) DeepCopyInto(out *RetainField) {
+	*out = *in
+	if in.MatchKeys != nil {
+		in, out := &in.MatchKeys, &out.MatchKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainField.
+func (in *RetainField) DeepCopy() *RetainField {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainField)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerSideApply) DeepCopyInto(out *ServerSideApply) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerSideApply.
+func (in *ServerSideApply) DeepCopy() *ServerSideApply {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerSideApply)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardingConfig) DeepCopyInto(out *ShardingConfig) {
+	*out = *in
+	out.LeaseDuration = in.LeaseDuration
+	out.RenewPeriod = in.RenewPeriod
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardingConfig.
+func (in *ShardingConfig) DeepCopy() *ShardingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpreadConstraints) DeepCopyInto(out *SpreadConstraints) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpreadConstraints.
+func (in *SpreadConstraints) DeepCopy() *SpreadConstraints {
+	if in == nil {
+		return nil
+	}
+	out := new(SpreadConstraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusAggregationRule) DeepCopyInto(out *StatusAggregationRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusAggregationRule.
+func (in *StatusAggregationRule) DeepCopy() *StatusAggregationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusAggregationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncControllerConfig) DeepCopyInto(out *SyncControllerConfig) {
 	*out = *in
+	out.UpdateTimeout = in.UpdateTimeout
+	out.ReconcileDelay = in.ReconcileDelay
 	return
 }
 
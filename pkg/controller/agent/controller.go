@@ -0,0 +1,448 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	"sigs.k8s.io/kubefed/pkg/controller/kubefedcluster"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+	finalizersutil "sigs.k8s.io/kubefed/pkg/controller/util/finalizers"
+)
+
+const (
+	userAgentName = "kubefed-agent"
+
+	// FinalizerAgent ensures that the object held by a
+	// MemberResource is removed from the cluster before the
+	// MemberResource is deleted.
+	FinalizerAgent = "kubefed.k8s.io/agent"
+)
+
+// Config configures the agent of a pull-mode cluster.
+type Config struct {
+	// The name of the KubefedCluster of the cluster the agent runs in.
+	ClusterName string
+	// The namespace of the kubefed control plane in the host cluster.
+	KubefedNamespace string
+	// Configuration for accessing the host cluster.
+	HostConfig *rest.Config
+	// Configuration for accessing the cluster the agent runs in.
+	MemberConfig *rest.Config
+	// How often every MemberResource is reconciled, which corrects
+	// drift and reports the state of objects in the cluster.
+	ResyncPeriod time.Duration
+	// How often the health of the cluster is reported.
+	HealthCheckPeriod time.Duration
+	// The timeout of a health check.
+	HealthCheckTimeout time.Duration
+}
+
+// Agent writes the objects destined for a pull-mode cluster to the
+// cluster and reports their state and the health of the cluster to
+// the host cluster.
+type Agent struct {
+	config *Config
+
+	// Client for the MemberResources in the host cluster
+	memberResourceClient dynamic.ResourceInterface
+	// Client for the secrets holding the data of Secret objects in
+	// the host cluster
+	secretClient dynamic.ResourceInterface
+	// Client for the KubefedCluster in the host cluster
+	hostClient genericclient.Client
+
+	// Store for the MemberResources of the cluster
+	memberResourceStore cache.Store
+	// Informer for the MemberResources of the cluster
+	memberResourceController cache.Controller
+
+	worker util.ReconcileWorker
+
+	clusterClient *kubefedcluster.ClusterClient
+
+	clientsLock sync.Mutex
+	// Clients for the API resources of the cluster
+	clients map[schema.GroupVersionResource]util.ResourceClient
+}
+
+// StartAgent starts the agent of a pull-mode cluster.
+func StartAgent(config *Config, stopChan <-chan struct{}) error {
+	agent, err := newAgent(config)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Starting agent for cluster %q", config.ClusterName)
+	agent.Run(stopChan)
+	return nil
+}
+
+func newAgent(config *Config) (*Agent, error) {
+	hostConfig := rest.AddUserAgent(rest.CopyConfig(config.HostConfig), userAgentName)
+	dynamicClient, err := dynamic.NewForConfig(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	hostClient, err := genericclient.New(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	clusterClient, err := kubefedcluster.NewClusterClientForConfig(config.ClusterName, config.MemberConfig, config.HealthCheckTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := util.PullClusterNamespace(config.KubefedNamespace, config.ClusterName)
+	a := &Agent{
+		config:               config,
+		memberResourceClient: dynamicClient.Resource(util.MemberResourceGroupVersionResource).Namespace(namespace),
+		secretClient:         dynamicClient.Resource(util.SecretGroupVersionResource).Namespace(namespace),
+		hostClient:           hostClient,
+		clusterClient:        clusterClient,
+		clients:              make(map[schema.GroupVersionResource]util.ResourceClient),
+	}
+
+	a.worker = util.NewReconcileWorker(userAgentName, a.reconcile, util.WorkerTiming{})
+
+	selector := labels.SelectorFromSet(labels.Set{util.MemberResourceClusterLabel: config.ClusterName}).String()
+	a.memberResourceStore, a.memberResourceController = cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (pkgruntime.Object, error) {
+				options.LabelSelector = selector
+				return a.memberResourceClient.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				return a.memberResourceClient.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		util.NoResyncPeriod,
+		util.NewTriggerOnAllChanges(a.worker.EnqueueObject),
+	)
+
+	return a, nil
+}
+
+// Run runs the agent until the given channel is closed.
+func (a *Agent) Run(stopChan <-chan struct{}) {
+	go a.memberResourceController.Run(stopChan)
+	a.worker.Run(stopChan)
+
+	go wait.Until(a.resync, a.config.ResyncPeriod, stopChan)
+	go wait.Until(a.reportClusterHealth, a.config.HealthCheckPeriod, stopChan)
+}
+
+// resync enqueues every MemberResource of the cluster for
+// reconciliation.
+func (a *Agent) resync() {
+	if !a.memberResourceController.HasSynced() {
+		return
+	}
+	for _, obj := range a.memberResourceStore.List() {
+		a.worker.EnqueueObject(obj.(pkgruntime.Object))
+	}
+}
+
+func (a *Agent) reconcile(qualifiedName util.QualifiedName) util.ReconciliationStatus {
+	if !a.memberResourceController.HasSynced() {
+		return util.StatusNotSynced
+	}
+
+	key := qualifiedName.String()
+	cachedObj, exists, err := a.memberResourceStore.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to query store for MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	if !exists {
+		return util.StatusAllOK
+	}
+	memberResource := &fedv1a1.MemberResource{}
+	if err := util.UnstructuredToInterface(cachedObj.(*unstructured.Unstructured), memberResource); err != nil {
+		klog.Errorf("Failed to decode MemberResource %q: %v", key, err)
+		return util.StatusAllOK
+	}
+	if memberResource.Spec.ClusterName != a.config.ClusterName {
+		klog.Warningf("MemberResource %q is labeled for cluster %q but destined for cluster %q", key, a.config.ClusterName, memberResource.Spec.ClusterName)
+		return util.StatusAllOK
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(memberResource.Spec.Object.Raw); err != nil {
+		klog.Errorf("Failed to decode the object of MemberResource %q: %v", key, err)
+		return util.StatusAllOK
+	}
+	client, err := a.clientFor(memberResource.Spec.Resource, obj)
+	if err != nil {
+		klog.Errorf("Failed to create a client for MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+
+	if memberResource.DeletionTimestamp != nil {
+		return a.remove(memberResource, client, obj)
+	}
+
+	if len(memberResource.Spec.DataSecretName) > 0 {
+		if err := a.setSecretData(memberResource, obj); err != nil {
+			klog.Errorf("Failed to read the data of MemberResource %q: %v", key, err)
+			return util.StatusError
+		}
+	}
+
+	added, err := finalizersutil.AddFinalizers(memberResource, sets.NewString(FinalizerAgent))
+	if err != nil {
+		klog.Errorf("Failed to add finalizer to MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	if added {
+		memberResource, err = a.updateMemberResource(memberResource, false)
+		if err != nil {
+			klog.Errorf("Failed to add finalizer to MemberResource %q: %v", key, err)
+			return util.StatusError
+		}
+	}
+
+	status := a.write(memberResource, client, obj)
+	if reflect.DeepEqual(status, &memberResource.Status) {
+		return util.StatusAllOK
+	}
+	memberResource.Status = *status
+	if _, err := a.updateMemberResource(memberResource, true); err != nil {
+		klog.Errorf("Failed to update the status of MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	if len(status.Error) > 0 {
+		return util.StatusNeedsRecheck
+	}
+	return util.StatusAllOK
+}
+
+// write writes the object held by the given MemberResource to the
+// cluster if the MemberResource has changed, the object is missing,
+// or the object has changed since the agent last wrote it.  The
+// resulting status of the MemberResource is returned.
+func (a *Agent) write(memberResource *fedv1a1.MemberResource, client util.ResourceClient, obj *unstructured.Unstructured) *fedv1a1.MemberResourceStatus {
+	status := memberResource.Status.DeepCopy()
+	status.ObservedGeneration = memberResource.Generation
+	status.Error = ""
+
+	clusterObj, err := client.Resources(obj.GetNamespace()).Get(obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		clusterObj = nil
+	} else if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	if clusterObj != nil {
+		if !util.HasManagedLabel(clusterObj) && len(status.AppliedVersion) == 0 {
+			status.Error = "The resource already exists in the cluster and is not managed by kubefed.  Resources are not adopted in pull-mode clusters."
+			return status
+		}
+		upToDate := memberResource.Generation == memberResource.Status.ObservedGeneration &&
+			len(memberResource.Status.Error) == 0 &&
+			util.ObjectVersion(clusterObj) == memberResource.Status.AppliedVersion
+		if upToDate {
+			setStatusObject(status, clusterObj)
+			return status
+		}
+	}
+
+	var writtenObj *unstructured.Unstructured
+	switch {
+	case len(memberResource.Spec.FieldManager) > 0:
+		writtenObj, err = client.Apply(obj, memberResource.Spec.FieldManager, memberResource.Spec.Force)
+	case clusterObj == nil:
+		writtenObj, err = client.Resources(obj.GetNamespace()).Create(obj, metav1.CreateOptions{})
+	default:
+		obj.SetResourceVersion(clusterObj.GetResourceVersion())
+		writtenObj, err = client.Resources(obj.GetNamespace()).Update(obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		status.Error = err.Error()
+		if clusterObj != nil {
+			setStatusObject(status, clusterObj)
+		}
+		return status
+	}
+	status.AppliedVersion = util.ObjectVersion(writtenObj)
+	setStatusObject(status, writtenObj)
+	return status
+}
+
+// remove removes the object held by the given MemberResource from the
+// cluster, or only removes its managed label if the MemberResource
+// indicates that the object should be orphaned, and then removes the
+// finalizer of the agent.
+func (a *Agent) remove(memberResource *fedv1a1.MemberResource, client util.ResourceClient, obj *unstructured.Unstructured) util.ReconciliationStatus {
+	key := util.NewQualifiedName(memberResource).String()
+	hasFinalizer, err := finalizersutil.HasFinalizer(memberResource, FinalizerAgent)
+	if err != nil || !hasFinalizer {
+		return util.StatusAllOK
+	}
+
+	resources := client.Resources(obj.GetNamespace())
+	clusterObj, err := resources.Get(obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Failed to retrieve the object of MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	// Only objects managed by kubefed are removed.
+	if err == nil && util.HasManagedLabel(clusterObj) {
+		if memberResource.Spec.Orphan {
+			util.RemoveManagedLabel(clusterObj)
+			_, err = resources.Update(clusterObj, metav1.UpdateOptions{})
+		} else {
+			err = resources.Delete(obj.GetName(), &metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to remove the object of MemberResource %q: %v", key, err)
+			return util.StatusError
+		}
+	}
+
+	if _, err := finalizersutil.RemoveFinalizers(memberResource, sets.NewString(FinalizerAgent)); err != nil {
+		klog.Errorf("Failed to remove finalizer from MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	if _, err := a.updateMemberResource(memberResource, false); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Failed to remove finalizer from MemberResource %q: %v", key, err)
+		return util.StatusError
+	}
+	return util.StatusAllOK
+}
+
+// setSecretData sets the data of the given Secret object from the data
+// secret of the given MemberResource.
+func (a *Agent) setSecretData(memberResource *fedv1a1.MemberResource, obj *unstructured.Unstructured) error {
+	dataSecret, err := a.secretClient.Get(memberResource.Spec.DataSecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	util.RemoveSecretData(obj)
+	// The API server merges stringData into data.
+	if data, ok := dataSecret.Object["data"]; ok {
+		obj.Object["data"] = data
+	}
+	return nil
+}
+
+func (a *Agent) updateMemberResource(memberResource *fedv1a1.MemberResource, status bool) (*fedv1a1.MemberResource, error) {
+	obj, err := util.GetUnstructured(memberResource)
+	if err != nil {
+		return nil, err
+	}
+	if status {
+		obj, err = a.memberResourceClient.UpdateStatus(obj, metav1.UpdateOptions{})
+	} else {
+		obj, err = a.memberResourceClient.Update(obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	updated := &fedv1a1.MemberResource{}
+	if err := util.UnstructuredToInterface(obj, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// clientFor returns the client for the API resource of the given
+// object in the cluster.
+func (a *Agent) clientFor(resource string, obj *unstructured.Unstructured) (util.ResourceClient, error) {
+	gvk := obj.GroupVersionKind()
+	gvr := gvk.GroupVersion().WithResource(resource)
+	a.clientsLock.Lock()
+	defer a.clientsLock.Unlock()
+	if client, ok := a.clients[gvr]; ok {
+		return client, nil
+	}
+	apiResource := &metav1.APIResource{
+		Group:      gvk.Group,
+		Version:    gvk.Version,
+		Kind:       gvk.Kind,
+		Name:       resource,
+		Namespaced: len(obj.GetNamespace()) > 0,
+	}
+	client, err := util.NewResourceClient(rest.AddUserAgent(rest.CopyConfig(a.config.MemberConfig), userAgentName), apiResource)
+	if err != nil {
+		return nil, err
+	}
+	a.clients[gvr] = client
+	return client, nil
+}
+
+// reportClusterHealth checks the health of the cluster and records it
+// in the status of the KubefedCluster of the cluster.
+func (a *Agent) reportClusterHealth() {
+	cluster := &fedv1a1.KubefedCluster{}
+	err := a.hostClient.Get(context.TODO(), cluster, a.config.KubefedNamespace, a.config.ClusterName)
+	if err != nil {
+		klog.Errorf("Failed to retrieve KubefedCluster %q: %v", a.config.ClusterName, err)
+		return
+	}
+	if !cluster.IsPullMode() {
+		klog.Warningf("KubefedCluster %q is not in pull mode; its health is not reported by the agent", cluster.Name)
+		return
+	}
+
+	clusterStatus := a.clusterClient.GetClusterHealthStatus(nil, nil)
+	kubefedcluster.PreserveTransitionTimes(clusterStatus, &cluster.Status)
+	clusterStatus.Zones = cluster.Status.Zones
+	clusterStatus.Region = cluster.Status.Region
+	cluster.Status = *clusterStatus
+	if err := a.hostClient.UpdateStatus(context.TODO(), cluster); err != nil {
+		klog.Errorf("Failed to update the status of KubefedCluster %q: %v", cluster.Name, err)
+	}
+}
+
+func setStatusObject(status *fedv1a1.MemberResourceStatus, clusterObj *unstructured.Unstructured) {
+	if clusterObj.GetAPIVersion() == "v1" && clusterObj.GetKind() == "Secret" {
+		// The data of a Secret is not reported
+		clusterObj = clusterObj.DeepCopy()
+		util.RemoveSecretData(clusterObj)
+	}
+	raw, err := clusterObj.MarshalJSON()
+	if err != nil {
+		klog.Errorf("Failed to encode %s %q: %v", clusterObj.GetKind(), util.NewQualifiedName(clusterObj), err)
+		return
+	}
+	// The encoding of an object is terminated by a newline that is
+	// not retained by the API.
+	status.Object = &pkgruntime.RawExtension{Raw: bytes.TrimSpace(raw)}
+}
@@ -0,0 +1,480 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialrotation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeclient "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+const (
+	userAgentName = "credential-rotation-controller"
+
+	// RotatedFromAnnotation records on a secret created by rotation
+	// the name of the secret holding the token it replaced.
+	RotatedFromAnnotation = "kubefed.k8s.io/rotated-from"
+
+	// Time to wait for the token controller of a member cluster to
+	// populate a new service account token secret.
+	tokenSecretTimeout = 30 * time.Second
+
+	serviceAccountIssuer = "kubernetes/serviceaccount"
+)
+
+// serviceAccountClaims are the claims of a service account token that
+// is backed by a secret.
+type serviceAccountClaims struct {
+	Issuer             string `json:"iss"`
+	Namespace          string `json:"kubernetes.io/serviceaccount/namespace"`
+	SecretName         string `json:"kubernetes.io/serviceaccount/secret.name"`
+	ServiceAccountName string `json:"kubernetes.io/serviceaccount/service-account.name"`
+	ServiceAccountUID  string `json:"kubernetes.io/serviceaccount/service-account.uid"`
+}
+
+// Controller periodically replaces the service account token that the
+// control plane uses to access each member cluster.  A new token is
+// minted for the service account in the member cluster with the
+// current token, verified and stored in a new secret in the host
+// cluster that the KubefedCluster is switched to.  The replaced token
+// is revoked once the revocation delay has passed.
+type Controller struct {
+	client genericclient.Client
+
+	config util.CredentialRotationConfig
+
+	fedNamespace string
+
+	allowedExecCommands []string
+
+	clusterStore      cache.Store
+	clusterController cache.Controller
+
+	worker util.ReconcileWorker
+}
+
+// StartController starts the credential rotation controller.
+func StartController(config *util.ControllerConfig, rotationConfig util.CredentialRotationConfig, stopChan <-chan struct{}) error {
+	controller, err := newController(config, rotationConfig)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Starting credential rotation controller")
+	controller.Run(stopChan)
+	return nil
+}
+
+func newController(config *util.ControllerConfig, rotationConfig util.CredentialRotationConfig) (*Controller, error) {
+	client := genericclient.NewForConfigOrDieWithUserAgent(config.KubeConfig, userAgentName)
+
+	c := &Controller{
+		client:              client,
+		config:              rotationConfig,
+		fedNamespace:        config.KubefedNamespace,
+		allowedExecCommands: config.AllowedExecCommands,
+	}
+
+	// Failures are likely to persist, so back off for longer than
+	// controllers reacting to changes.
+	c.worker = util.NewReconcileWorker(userAgentName, c.reconcile, util.WorkerTiming{
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+	})
+
+	var err error
+	c.clusterStore, c.clusterController, err = util.NewGenericInformerWithEventHandler(
+		config.KubeConfig,
+		config.KubefedNamespace,
+		&fedv1a1.KubefedCluster{},
+		util.NoResyncPeriod,
+		&cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.worker.EnqueueObject(obj.(*fedv1a1.KubefedCluster))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// Status updates are frequent and do not affect
+				// rotation.
+				oldCluster := oldObj.(*fedv1a1.KubefedCluster)
+				newCluster := newObj.(*fedv1a1.KubefedCluster)
+				if !reflect.DeepEqual(oldCluster.Spec, newCluster.Spec) {
+					c.worker.EnqueueObject(newCluster)
+				}
+			},
+		},
+	)
+	return c, err
+}
+
+// Run runs the controller until the given channel is closed.
+func (c *Controller) Run(stopChan <-chan struct{}) {
+	go c.clusterController.Run(stopChan)
+	c.worker.Run(stopChan)
+}
+
+func (c *Controller) reconcile(qualifiedName util.QualifiedName) util.ReconciliationStatus {
+	if !c.clusterController.HasSynced() {
+		return util.StatusNotSynced
+	}
+
+	key := qualifiedName.String()
+	cachedObj, exists, err := c.clusterStore.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to query store for KubefedCluster %q: %v", key, err)
+		return util.StatusError
+	}
+	if !exists {
+		return util.StatusAllOK
+	}
+	cluster := cachedObj.(*fedv1a1.KubefedCluster).DeepCopy()
+	if cluster.IsPullMode() {
+		return util.StatusAllOK
+	}
+
+	klog.V(4).Infof("Reconciling credentials of cluster %q", cluster.Name)
+
+	now := time.Now()
+	status := &fedv1a1.CredentialRotationStatus{}
+	if cluster.Status.CredentialRotation != nil {
+		status = cluster.Status.CredentialRotation.DeepCopy()
+	}
+
+	secret := &corev1.Secret{}
+	err = c.client.Get(context.TODO(), secret, c.fedNamespace, cluster.Spec.SecretRef.Name)
+	if err != nil {
+		return c.failed(cluster, status, now, errors.Wrapf(err, "Failed to retrieve secret %q", cluster.Spec.SecretRef.Name))
+	}
+
+	status.LastRotationTime = nil
+	status.PreviousSecretName = ""
+	previousSecretName, rotated := secret.Annotations[RotatedFromAnnotation]
+	if rotated {
+		status.LastRotationTime = &secret.CreationTimestamp
+		revocationTime := secret.CreationTimestamp.Add(c.config.RevocationDelay)
+		revoked, err := c.revokeIfDue(cluster, previousSecretName, revocationTime, now)
+		if err != nil {
+			status.PreviousSecretName = previousSecretName
+			return c.failed(cluster, status, now, err)
+		}
+		if !revoked {
+			status.PreviousSecretName = previousSecretName
+			c.worker.EnqueueWithDelay(qualifiedName, revocationTime.Sub(now))
+		}
+	}
+
+	claims, err := serviceAccountTokenClaims(secret.Data[util.TokenSecretKey])
+	if err != nil {
+		// Credentials that are not a service account token are not
+		// rotated until the cluster changes.
+		status.NextRotationTime = nil
+		status.LastFailureTime = nil
+		status.Message = errors.Wrapf(err, "The credentials of secret %q cannot be rotated", secret.Name).Error()
+		return c.updateStatus(cluster, status, util.StatusAllOK)
+	}
+
+	rotationTime := nextRotationTime(secret.CreationTimestamp, c.config.Period)
+	// A token is only rotated once the token it replaced has been
+	// revoked.
+	if now.Before(rotationTime.Time) || status.PreviousSecretName != "" {
+		if status.PreviousSecretName == "" {
+			c.worker.EnqueueWithDelay(qualifiedName, rotationTime.Sub(now))
+		}
+		status.NextRotationTime = &rotationTime
+		status.LastFailureTime = nil
+		status.Message = ""
+		return c.updateStatus(cluster, status, util.StatusAllOK)
+	}
+
+	klog.V(2).Infof("Rotating the credentials of cluster %q", cluster.Name)
+	newSecret, err := c.rotate(cluster, secret, claims)
+	if err != nil {
+		return c.failed(cluster, status, now, err)
+	}
+	klog.V(2).Infof("Replaced secret %q of cluster %q with %q", secret.Name, cluster.Name, newSecret.Name)
+
+	nextTime := nextRotationTime(newSecret.CreationTimestamp, c.config.Period)
+	status.LastRotationTime = &newSecret.CreationTimestamp
+	status.NextRotationTime = &nextTime
+	status.PreviousSecretName = secret.Name
+	status.LastFailureTime = nil
+	status.Message = ""
+	c.worker.EnqueueWithDelay(qualifiedName, c.config.RevocationDelay)
+	return c.updateStatus(cluster, status, util.StatusAllOK)
+}
+
+// revokeIfDue revokes the token held by the named secret and deletes
+// the secret if the given revocation time has passed.  It returns
+// whether the token has been revoked.
+func (c *Controller) revokeIfDue(cluster *fedv1a1.KubefedCluster, secretName string, revocationTime, now time.Time) (bool, error) {
+	secret := &corev1.Secret{}
+	err := c.client.Get(context.TODO(), secret, c.fedNamespace, secretName)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to retrieve previous secret %q", secretName)
+	}
+	if now.Before(revocationTime) {
+		return false, nil
+	}
+
+	klog.V(2).Infof("Revoking the previous credentials of cluster %q", cluster.Name)
+
+	// A token backed by a secret is revoked by deleting the secret in
+	// the member cluster.
+	claims, err := serviceAccountTokenClaims(secret.Data[util.TokenSecretKey])
+	if err == nil {
+		clusterClient, _, err := c.clusterClient(cluster)
+		if err != nil {
+			return false, err
+		}
+		err = clusterClient.CoreV1().Secrets(claims.Namespace).Delete(claims.SecretName, &metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "Failed to delete token secret %q in the cluster", claims.SecretName)
+		}
+	}
+
+	err = c.client.Delete(context.TODO(), secret, secret.Namespace, secret.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "Failed to delete previous secret %q", secretName)
+	}
+	return true, nil
+}
+
+// rotate mints a new token for the service account of the given claims
+// in the member cluster, verifies it and switches the cluster to a new
+// secret holding it.  The new secret is returned.
+func (c *Controller) rotate(cluster *fedv1a1.KubefedCluster, secret *corev1.Secret, claims *serviceAccountClaims) (*corev1.Secret, error) {
+	clusterClient, clusterConfig, err := c.clusterClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSecret, err := createTokenSecret(clusterClient, claims)
+	if err != nil {
+		return nil, err
+	}
+	newSecret, err := c.switchToToken(cluster, secret, clusterConfig, tokenSecret, claims)
+	if err != nil {
+		// Revoke the unused token.
+		deleteErr := clusterClient.CoreV1().Secrets(claims.Namespace).Delete(tokenSecret.Name, &metav1.DeleteOptions{})
+		if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			klog.Errorf("Failed to delete unused token secret %q in cluster %q: %v", tokenSecret.Name, cluster.Name, deleteErr)
+		}
+		return nil, err
+	}
+	return newSecret, nil
+}
+
+// switchToToken verifies the token of the given token secret and
+// switches the cluster to a new secret holding it.
+func (c *Controller) switchToToken(cluster *fedv1a1.KubefedCluster, secret *corev1.Secret, clusterConfig *restclient.Config,
+	tokenSecret *corev1.Secret, claims *serviceAccountClaims) (*corev1.Secret, error) {
+	token := tokenSecret.Data[util.TokenSecretKey]
+	err := verifyToken(clusterConfig, string(token), claims)
+	if err != nil {
+		return nil, err
+	}
+
+	newSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    c.fedNamespace,
+			GenerateName: cluster.Name + "-",
+			Labels:       secret.Labels,
+			Annotations: map[string]string{
+				RotatedFromAnnotation: secret.Name,
+			},
+		},
+		Type: secret.Type,
+		Data: map[string][]byte{},
+	}
+	for key, value := range secret.Data {
+		newSecret.Data[key] = value
+	}
+	newSecret.Data[util.TokenSecretKey] = token
+	err = c.client.Create(context.TODO(), newSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create secret for the new token")
+	}
+
+	// Switching the secret of the cluster in a single update ensures
+	// that every controller observes either the previous or the new
+	// token.
+	cluster.Spec.SecretRef.Name = newSecret.Name
+	err = c.client.Update(context.TODO(), cluster)
+	if err != nil {
+		deleteErr := c.client.Delete(context.TODO(), newSecret, newSecret.Namespace, newSecret.Name)
+		if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			klog.Errorf("Failed to delete unused secret %q: %v", newSecret.Name, deleteErr)
+		}
+		return nil, errors.Wrapf(err, "Failed to switch to secret %q", newSecret.Name)
+	}
+	return newSecret, nil
+}
+
+func (c *Controller) clusterClient(cluster *fedv1a1.KubefedCluster) (kubeclient.Interface, *restclient.Config, error) {
+	clusterConfig, err := util.BuildClusterConfig(cluster, c.client, c.fedNamespace, c.allowedExecCommands)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to build the configuration of the cluster")
+	}
+	clusterConfig = restclient.AddUserAgent(clusterConfig, userAgentName)
+	clusterClient, err := kubeclient.NewForConfig(clusterConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clusterClient, clusterConfig, nil
+}
+
+// createTokenSecret creates a secret for a new token of the service
+// account of the given claims and waits for the token to be populated.
+func createTokenSecret(clusterClient kubeclient.Interface, claims *serviceAccountClaims) (*corev1.Secret, error) {
+	secrets := clusterClient.CoreV1().Secrets(claims.Namespace)
+	tokenSecret, err := secrets.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: claims.ServiceAccountName + "-token-",
+			Annotations: map[string]string{
+				corev1.ServiceAccountNameKey: claims.ServiceAccountName,
+				corev1.ServiceAccountUIDKey:  claims.ServiceAccountUID,
+			},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create a token secret for service account %q in the cluster", claims.ServiceAccountName)
+	}
+
+	// Referencing the secret from the service account prevents the
+	// token controller from creating another token when the previous
+	// token is revoked.
+	serviceAccounts := clusterClient.CoreV1().ServiceAccounts(claims.Namespace)
+	serviceAccount, err := serviceAccounts.Get(claims.ServiceAccountName, metav1.GetOptions{})
+	if err == nil {
+		serviceAccount.Secrets = append(serviceAccount.Secrets, corev1.ObjectReference{Name: tokenSecret.Name})
+		_, err = serviceAccounts.Update(serviceAccount)
+	}
+	if err != nil {
+		klog.Warningf("Failed to reference token secret %q from service account %q: %v", tokenSecret.Name, claims.ServiceAccountName, err)
+	}
+
+	err = wait.PollImmediate(1*time.Second, tokenSecretTimeout, func() (bool, error) {
+		secret, err := secrets.Get(tokenSecret.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		tokenSecret = secret
+		return len(secret.Data[util.TokenSecretKey]) > 0, nil
+	})
+	if err != nil {
+		deleteErr := secrets.Delete(tokenSecret.Name, &metav1.DeleteOptions{})
+		if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			klog.Errorf("Failed to delete unpopulated token secret %q: %v", tokenSecret.Name, deleteErr)
+		}
+		return nil, errors.Wrapf(err, "The token of secret %q was not populated in the cluster", tokenSecret.Name)
+	}
+	return tokenSecret, nil
+}
+
+// verifyToken checks that the given token authenticates as the service
+// account of the given claims.
+func verifyToken(clusterConfig *restclient.Config, token string, claims *serviceAccountClaims) error {
+	config := restclient.CopyConfig(clusterConfig)
+	config.BearerToken = token
+	config.BearerTokenFile = ""
+	client, err := kubeclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().ServiceAccounts(claims.Namespace).Get(claims.ServiceAccountName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to verify the new token")
+	}
+	return nil
+}
+
+func (c *Controller) failed(cluster *fedv1a1.KubefedCluster, status *fedv1a1.CredentialRotationStatus,
+	now time.Time, err error) util.ReconciliationStatus {
+	klog.Errorf("Failed to rotate the credentials of cluster %q: %v", cluster.Name, err)
+	failureTime := metav1.NewTime(now)
+	status.LastFailureTime = &failureTime
+	status.Message = err.Error()
+	return c.updateStatus(cluster, status, util.StatusError)
+}
+
+// updateStatus updates the credential rotation status of the cluster
+// if it has changed and returns the given reconciliation status.
+func (c *Controller) updateStatus(cluster *fedv1a1.KubefedCluster, status *fedv1a1.CredentialRotationStatus,
+	reconciliationStatus util.ReconciliationStatus) util.ReconciliationStatus {
+	if reflect.DeepEqual(cluster.Status.CredentialRotation, status) {
+		return reconciliationStatus
+	}
+	cluster.Status.CredentialRotation = status
+	err := c.client.UpdateStatus(context.TODO(), cluster)
+	if err != nil {
+		klog.Errorf("Failed to update the credential rotation status of cluster %q: %v", cluster.Name, err)
+		return util.StatusError
+	}
+	return reconciliationStatus
+}
+
+// nextRotationTime returns the time a token stored in a secret with the
+// given creation time is due to be rotated.
+func nextRotationTime(creationTime metav1.Time, period time.Duration) metav1.Time {
+	return metav1.NewTime(creationTime.Add(period))
+}
+
+// serviceAccountTokenClaims returns the claims of the given token if it
+// is a service account token backed by a secret.
+func serviceAccountTokenClaims(token []byte) (*serviceAccountClaims, error) {
+	if len(token) == 0 {
+		return nil, errors.New("no token")
+	}
+	parts := strings.Split(string(token), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("the token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "the token is not a JWT")
+	}
+	claims := &serviceAccountClaims{}
+	err = json.Unmarshal(payload, claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "the token is not a JWT")
+	}
+	if claims.Issuer != serviceAccountIssuer || claims.SecretName == "" ||
+		claims.ServiceAccountName == "" || claims.Namespace == "" {
+		return nil, errors.New("the token is not a service account token backed by a secret")
+	}
+	return claims, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialrotation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestServiceAccountTokenClaims(t *testing.T) {
+	jwt := func(claims map[string]string) []byte {
+		payload, err := json.Marshal(claims)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return []byte("header." + base64.RawURLEncoding.EncodeToString(payload) + ".signature")
+	}
+	legacyTokenClaims := map[string]string{
+		"iss":                                    "kubernetes/serviceaccount",
+		"kubernetes.io/serviceaccount/namespace": "kube-federation-system",
+		"kubernetes.io/serviceaccount/secret.name":          "cluster1-host-token-abcde",
+		"kubernetes.io/serviceaccount/service-account.name": "cluster1-host",
+		"kubernetes.io/serviceaccount/service-account.uid":  "uid",
+	}
+	boundTokenClaims := map[string]string{
+		"iss": "https://kubernetes.default.svc",
+		"sub": "system:serviceaccount:kube-federation-system:cluster1-host",
+	}
+
+	testCases := map[string]struct {
+		token          []byte
+		expectedClaims *serviceAccountClaims
+	}{
+		"service account token": {
+			token: jwt(legacyTokenClaims),
+			expectedClaims: &serviceAccountClaims{
+				Issuer:             "kubernetes/serviceaccount",
+				Namespace:          "kube-federation-system",
+				SecretName:         "cluster1-host-token-abcde",
+				ServiceAccountName: "cluster1-host",
+				ServiceAccountUID:  "uid",
+			},
+		},
+		"bound service account token": {
+			token: jwt(boundTokenClaims),
+		},
+		"opaque token": {
+			token: []byte("abcdef.0123456789abcdef"),
+		},
+		"invalid payload": {
+			token: []byte("header.!!!.signature"),
+		},
+		"no token": {},
+	}
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			claims, err := serviceAccountTokenClaims(tc.token)
+			if tc.expectedClaims == nil {
+				if err == nil {
+					t.Fatalf("Expected an error, got claims %#v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(claims, tc.expectedClaims) {
+				t.Errorf("Expected claims %#v, got %#v", tc.expectedClaims, claims)
+			}
+		})
+	}
+}
@@ -91,12 +91,12 @@ func newController(config *util.ControllerConfig) (*Controller, error) {
 		smallDelay:              time.Second * 3,
 	}
 
-	s.worker = util.NewReconcileWorker(s.reconcile, util.WorkerTiming{
+	s.worker = util.NewReconcileWorker("ingressdns", s.reconcile, util.WorkerTiming{
 		ClusterSyncDelay: s.clusterAvailableDelay,
 	})
 
 	// Build deliverer for triggering cluster reconciliations.
-	s.clusterDeliverer = util.NewDelayingDeliverer()
+	s.clusterDeliverer = util.NewDelayingDeliverer("ingressdns-cluster")
 
 	// Informer for the IngressDNSRecord resource in federation.
 	var err error
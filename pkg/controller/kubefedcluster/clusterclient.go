@@ -17,6 +17,7 @@ limitations under the License.
 package kubefedcluster
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -39,6 +40,10 @@ import (
 const (
 	UserAgentName = "Cluster-Controller"
 
+	// AgentNotReportingReason is the reason of the offline condition
+	// of a pull-mode cluster whose agent has stopped reporting.
+	AgentNotReportingReason = "AgentNotReporting"
+
 	// Following labels come from k8s.io/kubernetes/pkg/kubelet/apis
 	LabelZoneFailureDomain = "failure-domain.beta.kubernetes.io/zone"
 	LabelZoneRegion        = "failure-domain.beta.kubernetes.io/region"
@@ -54,14 +59,24 @@ type ClusterClient struct {
 // NewClusterClientSet returns a ClusterClient for the given KubefedCluster.
 // The kubeClient is used to configure the ClusterClient's internal client
 // with information from a kubeconfig stored in a kubernetes secret.
-func NewClusterClientSet(c *fedv1a1.KubefedCluster, client generic.Client, fedNamespace string, timeout time.Duration) (*ClusterClient, error) {
-	clusterConfig, err := util.BuildClusterConfig(c, client, fedNamespace)
+func NewClusterClientSet(c *fedv1a1.KubefedCluster, client generic.Client, fedNamespace string,
+	allowedExecCommands []string, timeout time.Duration) (*ClusterClient, error) {
+	clusterConfig, err := util.BuildClusterConfig(c, client, fedNamespace, allowedExecCommands)
 	if err != nil {
 		return nil, err
 	}
-	clusterConfig.Timeout = timeout
-	var clusterClientSet = ClusterClient{clusterName: c.Name}
+	return NewClusterClientForConfig(c.Name, clusterConfig, timeout)
+}
+
+// NewClusterClientForConfig returns a ClusterClient for the named
+// cluster that uses the given configuration.  It is used by the agent
+// of a pull-mode cluster to determine the status of the cluster in
+// which the agent runs.
+func NewClusterClientForConfig(clusterName string, clusterConfig *restclient.Config, timeout time.Duration) (*ClusterClient, error) {
+	var clusterClientSet = ClusterClient{clusterName: clusterName}
 	if clusterConfig != nil {
+		clusterConfig = restclient.CopyConfig(clusterConfig)
+		clusterConfig.Timeout = timeout
 		clusterClientSet.kubeClient = kubeclientset.NewForConfigOrDie((restclient.AddUserAgent(clusterConfig, UserAgentName)))
 		if clusterClientSet.kubeClient == nil {
 			return nil, nil
@@ -70,8 +85,11 @@ func NewClusterClientSet(c *fedv1a1.KubefedCluster, client generic.Client, fedNa
 	return &clusterClientSet, nil
 }
 
-// GetClusterHealthStatus gets the kubernetes cluster health status by requesting "/healthz"
-func (self *ClusterClient) GetClusterHealthStatus() *fedv1a1.KubefedClusterStatus {
+// GetClusterHealthStatus gets the kubernetes cluster health status by
+// requesting "/healthz" and running the given health checks.  The
+// cluster is only ready if the checks named by the given ready gates
+// pass.
+func (self *ClusterClient) GetClusterHealthStatus(checks []fedv1a1.ClusterHealthCheck, readyGates []string) *fedv1a1.KubefedClusterStatus {
 	clusterStatus := fedv1a1.KubefedClusterStatus{}
 	currentTime := metav1.Now()
 	newClusterReadyCondition := fedv1a1.ClusterCondition{
@@ -106,17 +124,27 @@ func (self *ClusterClient) GetClusterHealthStatus() *fedv1a1.KubefedClusterStatu
 		LastProbeTime:      currentTime,
 		LastTransitionTime: currentTime,
 	}
+	startTime := time.Now()
 	body, err := self.kubeClient.DiscoveryClient.RESTClient().Get().AbsPath("/healthz").Do().Raw()
+	latency := time.Since(startTime)
 	if err != nil {
 		runtime.HandleError(errors.Wrapf(err, "Failed to do cluster health check for cluster %q", self.clusterName))
 		clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterOfflineCondition)
+		clusterStatus.Conditions = append(clusterStatus.Conditions, unknownHealthCheckConditions(checks, currentTime)...)
+		return &clusterStatus
+	}
+
+	checkConditions := self.runHealthChecks(checks, latency, currentTime)
+	if !strings.EqualFold(string(body), "ok") {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterNotReadyCondition, newClusterNotOfflineCondition)
+	} else if failed := failedReadyGates(checkConditions, readyGates); len(failed) > 0 {
+		newClusterNotReadyCondition.Reason = HealthCheckFailedReason
+		newClusterNotReadyCondition.Message = fmt.Sprintf("health checks have not passed: %s", strings.Join(failed, ", "))
+		clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterNotReadyCondition, newClusterNotOfflineCondition)
 	} else {
-		if !strings.EqualFold(string(body), "ok") {
-			clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterNotReadyCondition, newClusterNotOfflineCondition)
-		} else {
-			clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterReadyCondition)
-		}
+		clusterStatus.Conditions = append(clusterStatus.Conditions, newClusterReadyCondition)
 	}
+	clusterStatus.Conditions = append(clusterStatus.Conditions, checkConditions...)
 
 	return &clusterStatus
 }
@@ -18,9 +18,12 @@ package kubefedcluster
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -29,10 +32,12 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
 
+	fedcommon "sigs.k8s.io/kubefed/pkg/apis/core/common"
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 	"sigs.k8s.io/kubefed/pkg/features"
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 // ClusterData stores cluster client and previous health check probe results of individual cluster.
@@ -67,6 +72,10 @@ type ClusterController struct {
 	// fedNamespace is the name of the namespace containing
 	// KubefedCluster resources and their associated secrets.
 	fedNamespace string
+
+	// allowedExecCommands are the commands that the exec plugins of
+	// clusters are allowed to run.
+	allowedExecCommands []string
 }
 
 // StartClusterController starts a new cluster controller.
@@ -91,6 +100,7 @@ func newClusterController(config *util.ControllerConfig, clusterHealthCheckConfi
 		clusterHealthCheckConfig: clusterHealthCheckConfig,
 		clusterDataMap:           make(map[string]*ClusterData),
 		fedNamespace:             config.KubefedNamespace,
+		allowedExecCommands:      config.AllowedExecCommands,
 	}
 	var err error
 	_, cc.clusterController, err = util.NewGenericInformerWithEventHandler(
@@ -101,6 +111,7 @@ func newClusterController(config *util.ControllerConfig, clusterHealthCheckConfi
 		&cache.ResourceEventHandlerFuncs{
 			DeleteFunc: cc.delFromClusterSet,
 			AddFunc:    cc.addToClusterSet,
+			UpdateFunc: cc.updateClusterSet,
 		},
 	)
 	return cc, err
@@ -113,6 +124,22 @@ func (cc *ClusterController) delFromClusterSet(obj interface{}) {
 	cluster := obj.(*fedv1a1.KubefedCluster)
 	klog.V(1).Infof("ClusterController observed a cluster deletion: %v", cluster.Name)
 	delete(cc.clusterDataMap, cluster.Name)
+	metrics.ClusterRemoved(cluster.Name)
+}
+
+// updateClusterSet recreates the client of a cluster whose spec has
+// changed, e.g. because its credentials have been rotated.
+func (cc *ClusterController) updateClusterSet(oldObj, newObj interface{}) {
+	oldCluster := oldObj.(*fedv1a1.KubefedCluster)
+	newCluster := newObj.(*fedv1a1.KubefedCluster)
+	if reflect.DeepEqual(oldCluster.Spec, newCluster.Spec) {
+		return
+	}
+	klog.V(1).Infof("ClusterController observed a change to the spec of cluster %v", newCluster.Name)
+	cc.mu.Lock()
+	delete(cc.clusterDataMap, newCluster.Name)
+	cc.mu.Unlock()
+	cc.addToClusterSet(newCluster)
 }
 
 // addToClusterSet creates a new client for the cluster and stores it in cluster data map.
@@ -125,9 +152,14 @@ func (cc *ClusterController) addToClusterSet(obj interface{}) {
 		return
 	}
 	klog.V(1).Infof("ClusterController observed a new cluster: %v", cluster.Name)
+	if cluster.IsPullMode() {
+		// The health of a pull-mode cluster is reported by its agent.
+		cc.clusterDataMap[cluster.Name] = &ClusterData{}
+		return
+	}
 	// create the restclient of cluster
 	clientTimeout := time.Duration(cc.clusterHealthCheckConfig.TimeoutSeconds) * time.Second
-	restClient, err := NewClusterClientSet(cluster, cc.client, cc.fedNamespace, clientTimeout)
+	restClient, err := NewClusterClientSet(cluster, cc.client, cc.fedNamespace, cc.allowedExecCommands, clientTimeout)
 	if err != nil || restClient == nil {
 		klog.Errorf("Failed to create corresponding restclient of kubernetes cluster: %v", err)
 		return
@@ -167,6 +199,15 @@ func (cc *ClusterController) updateClusterStatus() error {
 		}
 
 		wg.Add(1)
+		if cluster.IsPullMode() {
+			go cc.updatePullClusterStatus(cluster, &wg)
+			continue
+		}
+		if clusterData.clusterKubeClient == nil {
+			klog.Warningf("Cluster %s has no client; its connection mode may have changed", cluster.Name)
+			wg.Done()
+			continue
+		}
 		go cc.updateIndividualClusterStatus(cluster, clusterData, &wg)
 	}
 
@@ -178,21 +219,80 @@ func (cc *ClusterController) updateIndividualClusterStatus(cluster *fedv1a1.Kube
 	storedData *ClusterData, wg *sync.WaitGroup) {
 	clusterClient := storedData.clusterKubeClient
 
-	currentClusterStatus := clusterClient.GetClusterHealthStatus()
+	startTime := time.Now()
+	config := cc.clusterHealthCheckConfig
+	currentClusterStatus := clusterClient.GetClusterHealthStatus(config.Checks, config.ReadyGates)
 	currentClusterStatus = thresholdAdjustedClusterStatus(currentClusterStatus, storedData, cc.clusterHealthCheckConfig)
+	metrics.ClusterHealth(cluster.Name, util.IsClusterReady(currentClusterStatus), isClusterOffline(currentClusterStatus), time.Since(startTime))
 
 	if utilfeature.DefaultFeatureGate.Enabled(features.CrossClusterServiceDiscovery) {
 		currentClusterStatus = updateClusterZonesAndRegion(currentClusterStatus, cluster, clusterClient)
 	}
 
 	storedData.clusterStatus = currentClusterStatus
+	credentialRotation := cluster.Status.CredentialRotation
 	cluster.Status = *currentClusterStatus
+	cluster.Status.CredentialRotation = credentialRotation
 	if err := cc.client.UpdateStatus(context.TODO(), cluster); err != nil {
 		klog.Warningf("Failed to update the status of cluster %q: %v", cluster.Name, err)
 	}
 	wg.Done()
 }
 
+// updatePullClusterStatus marks a pull-mode cluster offline if its
+// agent has not reported the health of the cluster for the number of
+// health check periods of the failure threshold.
+func (cc *ClusterController) updatePullClusterStatus(cluster *fedv1a1.KubefedCluster, wg *sync.WaitGroup) {
+	defer wg.Done()
+	config := cc.clusterHealthCheckConfig
+	timeout := time.Duration(config.PeriodSeconds*config.FailureThreshold) * time.Second
+	currentClusterStatus := agentReportedClusterStatus(&cluster.Status, timeout, metav1.Now())
+	if currentClusterStatus == &cluster.Status {
+		return
+	}
+	cluster.Status = *currentClusterStatus
+	if err := cc.client.UpdateStatus(context.TODO(), cluster); err != nil {
+		klog.Warningf("Failed to update the status of cluster %q: %v", cluster.Name, err)
+	}
+}
+
+// agentReportedClusterStatus returns the given status of a pull-mode
+// cluster, or an offline status if the agent of the cluster has not
+// reported within the given timeout.
+func agentReportedClusterStatus(clusterStatus *fedv1a1.KubefedClusterStatus, timeout time.Duration, now metav1.Time) *fedv1a1.KubefedClusterStatus {
+	var lastProbeTime metav1.Time
+	for _, condition := range clusterStatus.Conditions {
+		if condition.Reason == AgentNotReportingReason {
+			// Already marked offline
+			return clusterStatus
+		}
+		if lastProbeTime.Before(&condition.LastProbeTime) {
+			lastProbeTime = condition.LastProbeTime
+		}
+	}
+	if now.Sub(lastProbeTime.Time) <= timeout {
+		return clusterStatus
+	}
+	message := "agent has never reported"
+	if !lastProbeTime.IsZero() {
+		message = fmt.Sprintf("agent has not reported since %s", lastProbeTime.UTC().Format(time.RFC3339))
+	}
+	return &fedv1a1.KubefedClusterStatus{
+		Conditions: []fedv1a1.ClusterCondition{{
+			Type:   fedcommon.ClusterOffline,
+			Status: corev1.ConditionTrue,
+			Reason: AgentNotReportingReason,
+			// The probe time is retained so that the status is
+			// updated again only when the agent reports.
+			Message:            message,
+			LastProbeTime:      lastProbeTime,
+			LastTransitionTime: now,
+		}},
+		Zones:  clusterStatus.Zones,
+		Region: clusterStatus.Region,
+	}
+}
+
 func thresholdAdjustedClusterStatus(clusterStatus *fedv1a1.KubefedClusterStatus, storedData *ClusterData,
 	clusterHealthCheckConfig util.ClusterHealthCheckConfig) *fedv1a1.KubefedClusterStatus {
 
@@ -207,16 +307,17 @@ func thresholdAdjustedClusterStatus(clusterStatus *fedv1a1.KubefedClusterStatus,
 	}
 
 	if storedData.resultRun < threshold {
-		// Success/Failure is below threshold - leave the probe state unchanged.
+		// Success/Failure is below threshold - leave the probe state
+		// unchanged.  The conditions of health checks are reported
+		// as probed.
 		probeTime := clusterStatus.Conditions[0].LastProbeTime
-		clusterStatus = storedData.clusterStatus
+		checkConditions := healthCheckConditions(clusterStatus)
+		clusterStatus = storedData.clusterStatus.DeepCopy()
+		clusterStatus.Conditions = append(clusterConditions(clusterStatus), checkConditions...)
 		setProbeTime(clusterStatus, probeTime)
-	} else {
-		if clusterStatusEqual(clusterStatus, storedData.clusterStatus) {
-			// preserve the last transition time
-			setTransitionTime(clusterStatus, storedData.clusterStatus.Conditions[0].LastTransitionTime)
-		}
 	}
+	// preserve the last transition time of unchanged conditions
+	PreserveTransitionTimes(clusterStatus, storedData.clusterStatus)
 
 	if clusterStatusEqual(clusterStatus, storedData.clusterStatus) {
 		// Increment the result run has there is no change in cluster condition
@@ -255,6 +356,15 @@ func updateClusterZonesAndRegion(clusterStatus *fedv1a1.KubefedClusterStatus, cl
 	return clusterStatus
 }
 
+func isClusterOffline(clusterStatus *fedv1a1.KubefedClusterStatus) bool {
+	for _, condition := range clusterStatus.Conditions {
+		if condition.Type == fedcommon.ClusterOffline && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func clusterStatusEqual(newClusterStatus, oldClusterStatus *fedv1a1.KubefedClusterStatus) bool {
 	return util.IsClusterReady(newClusterStatus) == util.IsClusterReady(oldClusterStatus)
 }
@@ -265,8 +375,49 @@ func setProbeTime(clusterStatus *fedv1a1.KubefedClusterStatus, probeTime metav1.
 	}
 }
 
-func setTransitionTime(clusterStatus *fedv1a1.KubefedClusterStatus, transitionTime metav1.Time) {
-	for i := 0; i < len(clusterStatus.Conditions); i++ {
-		clusterStatus.Conditions[i].LastTransitionTime = transitionTime
+// PreserveTransitionTimes sets the last transition time of each
+// condition of the given status to that of the condition of the same
+// type and status of the stored status.  A cluster that changes
+// between being not ready and offline remains unavailable, so the
+// condition indicating that it is unavailable retains the time it
+// became unavailable.
+func PreserveTransitionTimes(clusterStatus, storedStatus *fedv1a1.KubefedClusterStatus) {
+	for i := range clusterStatus.Conditions {
+		condition := &clusterStatus.Conditions[i]
+		for _, storedCondition := range storedStatus.Conditions {
+			if storedCondition.Type == condition.Type && storedCondition.Status == condition.Status {
+				condition.LastTransitionTime = storedCondition.LastTransitionTime
+				break
+			}
+		}
+	}
+	unavailableCondition := util.ClusterUnavailableCondition(clusterStatus)
+	storedUnavailableCondition := util.ClusterUnavailableCondition(storedStatus)
+	if unavailableCondition != nil && storedUnavailableCondition != nil {
+		unavailableCondition.LastTransitionTime = storedUnavailableCondition.LastTransitionTime
+	}
+}
+
+// clusterConditions returns the ready and offline conditions of the
+// given status.
+func clusterConditions(clusterStatus *fedv1a1.KubefedClusterStatus) []fedv1a1.ClusterCondition {
+	conditions := []fedv1a1.ClusterCondition{}
+	for _, condition := range clusterStatus.Conditions {
+		if condition.Type == fedcommon.ClusterReady || condition.Type == fedcommon.ClusterOffline {
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+// healthCheckConditions returns the conditions of the given status
+// reported by health checks.
+func healthCheckConditions(clusterStatus *fedv1a1.KubefedClusterStatus) []fedv1a1.ClusterCondition {
+	conditions := []fedv1a1.ClusterCondition{}
+	for _, condition := range clusterStatus.Conditions {
+		if condition.Type != fedcommon.ClusterReady && condition.Type != fedcommon.ClusterOffline {
+			conditions = append(conditions, condition)
+		}
 	}
+	return conditions
 }
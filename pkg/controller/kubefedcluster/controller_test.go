@@ -18,6 +18,7 @@ package kubefedcluster
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -87,6 +88,22 @@ func TestThresholdCheckedClusterStatus(t *testing.T) {
 			expectedClusterStatus: clusterStatus(corev1.ConditionTrue, t5, t5),
 			expectedResultRun:     1,
 		},
+		"HealthCheckReportedWithinFailureThreshold": {
+			clusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionFalse, t3, t3), corev1.ConditionFalse, t3, t3),
+			storedClusterData: &ClusterData{
+				clusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t2, t1), corev1.ConditionTrue, t2, t1),
+				resultRun:     2},
+			expectedClusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t3, t1), corev1.ConditionFalse, t3, t3),
+			expectedResultRun:     3,
+		},
+		"HealthCheckUnchanged": {
+			clusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t3, t3), corev1.ConditionTrue, t3, t3),
+			storedClusterData: &ClusterData{
+				clusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t2, t1), corev1.ConditionTrue, t2, t1),
+				resultRun:     1},
+			expectedClusterStatus: withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t3, t1), corev1.ConditionTrue, t3, t1),
+			expectedResultRun:     2,
+		},
 	}
 
 	for testName, tc := range testCases {
@@ -104,6 +121,101 @@ func TestThresholdCheckedClusterStatus(t *testing.T) {
 
 }
 
+func TestAgentReportedClusterStatus(t *testing.T) {
+	now := metav1.Now()
+	recent := metav1.Time{Time: now.Add(-10 * time.Second)}
+	stale := metav1.Time{Time: now.Add(-time.Minute)}
+	timeout := 30 * time.Second
+
+	testCases := map[string]struct {
+		clusterStatus   *fedv1a1.KubefedClusterStatus
+		expectUnchanged bool
+		expectedMessage string
+	}{
+		"AgentReportedRecently": {
+			clusterStatus:   clusterStatus(corev1.ConditionTrue, recent, stale),
+			expectUnchanged: true,
+		},
+		"AgentStoppedReporting": {
+			clusterStatus:   clusterStatus(corev1.ConditionTrue, stale, stale),
+			expectedMessage: "agent has not reported since",
+		},
+		"AgentNeverReported": {
+			clusterStatus:   &fedv1a1.KubefedClusterStatus{},
+			expectedMessage: "agent has never reported",
+		},
+		"AlreadyMarkedOffline": {
+			clusterStatus: &fedv1a1.KubefedClusterStatus{
+				Conditions: []fedv1a1.ClusterCondition{{
+					Type:          common.ClusterOffline,
+					Status:        corev1.ConditionTrue,
+					Reason:        AgentNotReportingReason,
+					LastProbeTime: stale,
+				}},
+			},
+			expectUnchanged: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			newClusterStatus := agentReportedClusterStatus(tc.clusterStatus, timeout, now)
+			if tc.expectUnchanged {
+				if newClusterStatus != tc.clusterStatus {
+					t.Fatalf("Expected the status to be unchanged, got: %v", newClusterStatus)
+				}
+				return
+			}
+			if util.IsClusterReady(newClusterStatus) || !isClusterOffline(newClusterStatus) {
+				t.Fatalf("Expected the cluster to be offline, got: %v", newClusterStatus)
+			}
+			condition := newClusterStatus.Conditions[0]
+			if condition.Reason != AgentNotReportingReason || !strings.HasPrefix(condition.Message, tc.expectedMessage) {
+				t.Fatalf("Unexpected condition: %v", condition)
+			}
+			if agentReportedClusterStatus(newClusterStatus, timeout, now) != newClusterStatus {
+				t.Fatalf("Expected the offline status to be unchanged until the agent reports")
+			}
+		})
+	}
+}
+
+func TestPreserveTransitionTimes(t *testing.T) {
+	epoch := metav1.Now()
+	t1 := metav1.Time{Time: epoch.Add(1 * time.Second)}
+	t2 := metav1.Time{Time: epoch.Add(2 * time.Second)}
+	t3 := metav1.Time{Time: epoch.Add(3 * time.Second)}
+
+	storedStatus := withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t2, t1), corev1.ConditionTrue, t2, t2)
+	newStatus := withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t3, t3), corev1.ConditionFalse, t3, t3)
+	PreserveTransitionTimes(newStatus, storedStatus)
+
+	expectedStatus := withHealthCheckCondition(clusterStatus(corev1.ConditionTrue, t3, t1), corev1.ConditionFalse, t3, t3)
+	if !reflect.DeepEqual(expectedStatus, newStatus) {
+		t.Fatalf("Unexpected state, expected: %v, got:%v", expectedStatus, newStatus)
+	}
+
+	// A cluster that goes offline after being not ready retains the
+	// time it became unavailable.
+	storedStatus = withHealthCheckCondition(clusterStatus(corev1.ConditionFalse, t2, t1), corev1.ConditionFalse, t2, t2)
+	newStatus = withHealthCheckCondition(&fedv1a1.KubefedClusterStatus{}, corev1.ConditionUnknown, t3, t3)
+	newStatus.Conditions = append(newStatus.Conditions, fedv1a1.ClusterCondition{
+		Type:               common.ClusterOffline,
+		Status:             corev1.ConditionTrue,
+		LastProbeTime:      t3,
+		LastTransitionTime: t3,
+	})
+	PreserveTransitionTimes(newStatus, storedStatus)
+
+	unavailableCondition := util.ClusterUnavailableCondition(newStatus)
+	if unavailableCondition == nil || unavailableCondition.Type != common.ClusterOffline || unavailableCondition.LastTransitionTime != t1 {
+		t.Fatalf("Expected the offline condition to have transitioned at %v, got: %v", t1, unavailableCondition)
+	}
+	if newStatus.Conditions[0].LastTransitionTime != t3 {
+		t.Fatalf("Expected the changed health check condition to have transitioned at %v, got: %v", t3, newStatus.Conditions[0])
+	}
+}
+
 func clusterStatus(status corev1.ConditionStatus, lastProbeTime, lastTransitionTime metav1.Time) *fedv1a1.KubefedClusterStatus {
 	return &fedv1a1.KubefedClusterStatus{
 		Conditions: []fedv1a1.ClusterCondition{{
@@ -114,3 +226,14 @@ func clusterStatus(status corev1.ConditionStatus, lastProbeTime, lastTransitionT
 		}},
 	}
 }
+
+func withHealthCheckCondition(clusterStatus *fedv1a1.KubefedClusterStatus, status corev1.ConditionStatus,
+	lastProbeTime, lastTransitionTime metav1.Time) *fedv1a1.KubefedClusterStatus {
+	clusterStatus.Conditions = append(clusterStatus.Conditions, fedv1a1.ClusterCondition{
+		Type:               "NodesReady",
+		Status:             status,
+		LastProbeTime:      lastProbeTime,
+		LastTransitionTime: lastTransitionTime,
+	})
+	return clusterStatus
+}
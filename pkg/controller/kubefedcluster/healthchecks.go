@@ -0,0 +1,230 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubefedcluster
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	fedcommon "sigs.k8s.io/kubefed/pkg/apis/core/common"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+const (
+	defaultMaxLatency           = time.Second
+	defaultMinReadyNodesPercent = 100
+
+	// HealthCheckFailedReason is the reason of the ready condition of
+	// a cluster for which a health check gating readiness has not
+	// passed.
+	HealthCheckFailedReason = "HealthCheckFailed"
+)
+
+// ValidateHealthChecks checks that the given health checks can be run
+// and that the given ready gates name one of the checks.
+func ValidateHealthChecks(checks []fedv1a1.ClusterHealthCheck, readyGates []string) error {
+	names := sets.NewString()
+	for _, check := range checks {
+		switch fedcommon.ClusterConditionType(check.Name) {
+		case "":
+			return errors.New("health checks must have a name")
+		case fedcommon.ClusterReady, fedcommon.ClusterOffline:
+			return errors.Errorf("health check %q may not be named for a cluster condition", check.Name)
+		}
+		if names.Has(check.Name) {
+			return errors.Errorf("health check %q is defined more than once", check.Name)
+		}
+		names.Insert(check.Name)
+
+		switch check.Type {
+		case fedv1a1.ReadyzHealthCheck, fedv1a1.LatencyHealthCheck:
+		case fedv1a1.NodeReadinessHealthCheck:
+			if check.MinReadyNodesPercent < 0 || check.MinReadyNodesPercent > 100 {
+				return errors.Errorf("health check %q must have a minimum percentage of ready nodes between 0 and 100", check.Name)
+			}
+		case fedv1a1.ProbeHealthCheck:
+			probe := check.Probe
+			if probe == nil || probe.APIVersion == "" || probe.Resource == "" || probe.Name == "" {
+				return errors.Errorf("health check %q must have a probe with an api version, resource and name", check.Name)
+			}
+		default:
+			return errors.Errorf("health check %q has unsupported type %q: supported types are %q, %q, %q and %q", check.Name, check.Type,
+				fedv1a1.ReadyzHealthCheck, fedv1a1.LatencyHealthCheck, fedv1a1.NodeReadinessHealthCheck, fedv1a1.ProbeHealthCheck)
+		}
+	}
+	for _, gate := range readyGates {
+		if !names.Has(gate) {
+			return errors.Errorf("ready gate %q does not name a health check", gate)
+		}
+	}
+	return nil
+}
+
+// runHealthChecks runs the given health checks against a cluster whose
+// /healthz responded within the given latency and returns a condition
+// for each check.
+func (self *ClusterClient) runHealthChecks(checks []fedv1a1.ClusterHealthCheck, latency time.Duration,
+	probeTime metav1.Time) []fedv1a1.ClusterCondition {
+	conditions := []fedv1a1.ClusterCondition{}
+	for _, check := range checks {
+		var status corev1.ConditionStatus
+		var reason, message string
+		switch check.Type {
+		case fedv1a1.ReadyzHealthCheck:
+			status, reason, message = self.checkReadyz()
+		case fedv1a1.LatencyHealthCheck:
+			status, reason, message = checkLatency(latency, check.MaxLatency.Duration)
+		case fedv1a1.NodeReadinessHealthCheck:
+			status, reason, message = self.checkNodeReadiness(check.MinReadyNodesPercent)
+		case fedv1a1.ProbeHealthCheck:
+			status, reason, message = self.checkProbe(check.Probe)
+		}
+		conditions = append(conditions, fedv1a1.ClusterCondition{
+			Type:               fedcommon.ClusterConditionType(check.Name),
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastProbeTime:      probeTime,
+			LastTransitionTime: probeTime,
+		})
+	}
+	return conditions
+}
+
+// unknownHealthCheckConditions returns a condition of unknown status
+// for each of the given health checks of an unreachable cluster.
+func unknownHealthCheckConditions(checks []fedv1a1.ClusterHealthCheck, probeTime metav1.Time) []fedv1a1.ClusterCondition {
+	conditions := []fedv1a1.ClusterCondition{}
+	for _, check := range checks {
+		conditions = append(conditions, fedv1a1.ClusterCondition{
+			Type:               fedcommon.ClusterConditionType(check.Name),
+			Status:             corev1.ConditionUnknown,
+			Reason:             "ClusterNotReachable",
+			Message:            "cluster is not reachable",
+			LastProbeTime:      probeTime,
+			LastTransitionTime: probeTime,
+		})
+	}
+	return conditions
+}
+
+// failedReadyGates returns the names of the given ready gates whose
+// condition is not true.
+func failedReadyGates(conditions []fedv1a1.ClusterCondition, readyGates []string) []string {
+	failed := []string{}
+	for _, gate := range readyGates {
+		passed := false
+		for _, condition := range conditions {
+			if string(condition.Type) == gate {
+				passed = condition.Status == corev1.ConditionTrue
+				break
+			}
+		}
+		if !passed {
+			failed = append(failed, gate)
+		}
+	}
+	return failed
+}
+
+func (self *ClusterClient) checkReadyz() (corev1.ConditionStatus, string, string) {
+	body, err := self.kubeClient.DiscoveryClient.RESTClient().Get().AbsPath("/readyz").Do().Raw()
+	if err != nil {
+		return corev1.ConditionFalse, "ReadyzFailed", fmt.Sprintf("/readyz request failed: %v", err)
+	}
+	if !strings.EqualFold(string(body), "ok") {
+		return corev1.ConditionFalse, "ReadyzFailed", "/readyz responded without ok"
+	}
+	return corev1.ConditionTrue, "ReadyzSucceeded", "/readyz responded with ok"
+}
+
+func checkLatency(latency, maxLatency time.Duration) (corev1.ConditionStatus, string, string) {
+	if maxLatency == 0 {
+		maxLatency = defaultMaxLatency
+	}
+	if latency > maxLatency {
+		return corev1.ConditionFalse, "LatencyExceeded",
+			fmt.Sprintf("/healthz responded in %v, more than the maximum of %v", latency.Round(time.Millisecond), maxLatency)
+	}
+	return corev1.ConditionTrue, "LatencyWithinLimit",
+		fmt.Sprintf("/healthz responded in %v", latency.Round(time.Millisecond))
+}
+
+func (self *ClusterClient) checkNodeReadiness(minReadyPercent int) (corev1.ConditionStatus, string, string) {
+	nodes, err := self.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return corev1.ConditionUnknown, "NodeListFailed", fmt.Sprintf("failed to list nodes: %v", err)
+	}
+	return nodeReadiness(nodes.Items, minReadyPercent)
+}
+
+// nodeReadiness compares the percentage of the given nodes that are
+// ready to the given minimum.
+func nodeReadiness(nodes []corev1.Node, minReadyPercent int) (corev1.ConditionStatus, string, string) {
+	if minReadyPercent == 0 {
+		minReadyPercent = defaultMinReadyNodesPercent
+	}
+	ready := 0
+	for _, node := range nodes {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+				ready++
+				break
+			}
+		}
+	}
+	message := fmt.Sprintf("%d of %d nodes are ready", ready, len(nodes))
+	// A cluster without nodes cannot run workloads.
+	if len(nodes) == 0 || ready*100 < minReadyPercent*len(nodes) {
+		return corev1.ConditionFalse, "InsufficientNodesReady",
+			fmt.Sprintf("%s, less than the minimum of %d%%", message, minReadyPercent)
+	}
+	return corev1.ConditionTrue, "NodesReady", message
+}
+
+func (self *ClusterClient) checkProbe(probe *fedv1a1.ClusterHealthProbe) (corev1.ConditionStatus, string, string) {
+	probePath := probePath(probe)
+	_, err := self.kubeClient.DiscoveryClient.RESTClient().Get().AbsPath(probePath).Do().Raw()
+	if err != nil {
+		return corev1.ConditionFalse, "ProbeFailed", fmt.Sprintf("GET %s failed: %v", probePath, err)
+	}
+	return corev1.ConditionTrue, "ProbeSucceeded", fmt.Sprintf("GET %s succeeded", probePath)
+}
+
+// probePath returns the path of the resource identified by the given
+// probe.
+func probePath(probe *fedv1a1.ClusterHealthProbe) string {
+	prefix := "/apis"
+	if !strings.Contains(probe.APIVersion, "/") {
+		// The core group
+		prefix = "/api"
+	}
+	segments := []string{prefix, probe.APIVersion}
+	if probe.Namespace != "" {
+		segments = append(segments, "namespaces", probe.Namespace)
+	}
+	segments = append(segments, probe.Resource, probe.Name)
+	return path.Join(segments...)
+}
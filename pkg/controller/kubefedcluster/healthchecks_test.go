@@ -0,0 +1,254 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubefedcluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestValidateHealthChecks(t *testing.T) {
+	readyz := fedv1a1.ClusterHealthCheck{Name: "APIServerReady", Type: fedv1a1.ReadyzHealthCheck}
+	probe := &fedv1a1.ClusterHealthProbe{APIVersion: "v1", Resource: "namespaces", Name: "default"}
+
+	testCases := map[string]struct {
+		checks      []fedv1a1.ClusterHealthCheck
+		readyGates  []string
+		expectError bool
+	}{
+		"NoChecks": {},
+		"ValidChecksAndGates": {
+			checks: []fedv1a1.ClusterHealthCheck{
+				readyz,
+				{Name: "NodesReady", Type: fedv1a1.NodeReadinessHealthCheck, MinReadyNodesPercent: 50},
+				{Name: "DefaultNamespace", Type: fedv1a1.ProbeHealthCheck, Probe: probe},
+			},
+			readyGates: []string{"APIServerReady", "DefaultNamespace"},
+		},
+		"MissingName": {
+			checks:      []fedv1a1.ClusterHealthCheck{{Type: fedv1a1.ReadyzHealthCheck}},
+			expectError: true,
+		},
+		"NamedForClusterCondition": {
+			checks:      []fedv1a1.ClusterHealthCheck{{Name: "Ready", Type: fedv1a1.ReadyzHealthCheck}},
+			expectError: true,
+		},
+		"DuplicateName": {
+			checks:      []fedv1a1.ClusterHealthCheck{readyz, readyz},
+			expectError: true,
+		},
+		"UnsupportedType": {
+			checks:      []fedv1a1.ClusterHealthCheck{{Name: "Check", Type: "Unknown"}},
+			expectError: true,
+		},
+		"InvalidReadyNodesPercent": {
+			checks:      []fedv1a1.ClusterHealthCheck{{Name: "NodesReady", Type: fedv1a1.NodeReadinessHealthCheck, MinReadyNodesPercent: 101}},
+			expectError: true,
+		},
+		"ProbeWithoutName": {
+			checks: []fedv1a1.ClusterHealthCheck{{
+				Name:  "DefaultNamespace",
+				Type:  fedv1a1.ProbeHealthCheck,
+				Probe: &fedv1a1.ClusterHealthProbe{APIVersion: "v1", Resource: "namespaces"},
+			}},
+			expectError: true,
+		},
+		"GateWithoutCheck": {
+			checks:      []fedv1a1.ClusterHealthCheck{readyz},
+			readyGates:  []string{"NodesReady"},
+			expectError: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateHealthChecks(tc.checks, tc.readyGates)
+			if tc.expectError && err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckLatency(t *testing.T) {
+	testCases := map[string]struct {
+		latency        time.Duration
+		maxLatency     time.Duration
+		expectedStatus corev1.ConditionStatus
+	}{
+		"WithinDefaultLimit": {
+			latency:        500 * time.Millisecond,
+			expectedStatus: corev1.ConditionTrue,
+		},
+		"ExceedsDefaultLimit": {
+			latency:        2 * time.Second,
+			expectedStatus: corev1.ConditionFalse,
+		},
+		"WithinConfiguredLimit": {
+			latency:        2 * time.Second,
+			maxLatency:     3 * time.Second,
+			expectedStatus: corev1.ConditionTrue,
+		},
+		"ExceedsConfiguredLimit": {
+			latency:        200 * time.Millisecond,
+			maxLatency:     100 * time.Millisecond,
+			expectedStatus: corev1.ConditionFalse,
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			status, _, _ := checkLatency(tc.latency, tc.maxLatency)
+			if status != tc.expectedStatus {
+				t.Fatalf("Expected status %v, got: %v", tc.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestNodeReadiness(t *testing.T) {
+	ready := node(corev1.ConditionTrue)
+	notReady := node(corev1.ConditionFalse)
+
+	testCases := map[string]struct {
+		nodes           []corev1.Node
+		minReadyPercent int
+		expectedStatus  corev1.ConditionStatus
+	}{
+		"NoNodes": {
+			expectedStatus: corev1.ConditionFalse,
+		},
+		"AllNodesReady": {
+			nodes:          []corev1.Node{ready, ready},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		"NodeNotReadyWithDefaultMinimum": {
+			nodes:          []corev1.Node{ready, notReady},
+			expectedStatus: corev1.ConditionFalse,
+		},
+		"MinimumReached": {
+			nodes:           []corev1.Node{ready, notReady},
+			minReadyPercent: 50,
+			expectedStatus:  corev1.ConditionTrue,
+		},
+		"MinimumNotReached": {
+			nodes:           []corev1.Node{ready, notReady, notReady},
+			minReadyPercent: 50,
+			expectedStatus:  corev1.ConditionFalse,
+		},
+		"NodeWithoutConditions": {
+			nodes:           []corev1.Node{ready, {}},
+			minReadyPercent: 75,
+			expectedStatus:  corev1.ConditionFalse,
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			status, _, _ := nodeReadiness(tc.nodes, tc.minReadyPercent)
+			if status != tc.expectedStatus {
+				t.Fatalf("Expected status %v, got: %v", tc.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestFailedReadyGates(t *testing.T) {
+	conditions := []fedv1a1.ClusterCondition{
+		{Type: "APIServerReady", Status: corev1.ConditionTrue},
+		{Type: "NodesReady", Status: corev1.ConditionFalse},
+		{Type: "DefaultNamespace", Status: corev1.ConditionUnknown},
+	}
+
+	testCases := map[string]struct {
+		readyGates     []string
+		expectedFailed []string
+	}{
+		"NoGates": {
+			expectedFailed: []string{},
+		},
+		"GatePassed": {
+			readyGates:     []string{"APIServerReady"},
+			expectedFailed: []string{},
+		},
+		"GatesFailed": {
+			readyGates:     []string{"APIServerReady", "NodesReady", "DefaultNamespace"},
+			expectedFailed: []string{"NodesReady", "DefaultNamespace"},
+		},
+		"GateWithoutCondition": {
+			readyGates:     []string{"Latency"},
+			expectedFailed: []string{"Latency"},
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			failed := failedReadyGates(conditions, tc.readyGates)
+			if !reflect.DeepEqual(tc.expectedFailed, failed) {
+				t.Fatalf("Expected failed gates %v, got: %v", tc.expectedFailed, failed)
+			}
+		})
+	}
+}
+
+func TestProbePath(t *testing.T) {
+	testCases := map[string]struct {
+		probe        fedv1a1.ClusterHealthProbe
+		expectedPath string
+	}{
+		"CoreClusterScoped": {
+			probe:        fedv1a1.ClusterHealthProbe{APIVersion: "v1", Resource: "namespaces", Name: "default"},
+			expectedPath: "/api/v1/namespaces/default",
+		},
+		"CoreNamespaced": {
+			probe:        fedv1a1.ClusterHealthProbe{APIVersion: "v1", Resource: "configmaps", Namespace: "kube-system", Name: "probe"},
+			expectedPath: "/api/v1/namespaces/kube-system/configmaps/probe",
+		},
+		"GroupNamespaced": {
+			probe:        fedv1a1.ClusterHealthProbe{APIVersion: "apps/v1", Resource: "deployments", Namespace: "kube-system", Name: "coredns"},
+			expectedPath: "/apis/apps/v1/namespaces/kube-system/deployments/coredns",
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			path := probePath(&tc.probe)
+			if path != tc.expectedPath {
+				t.Fatalf("Expected path %q, got: %q", tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func node(ready corev1.ConditionStatus) corev1.Node {
+	return corev1.Node{
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: ready,
+			}},
+		},
+	}
+}
@@ -18,6 +18,7 @@ package schedulingpreference
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -105,7 +106,7 @@ func newSchedulingPreferenceController(config *util.ControllerConfig, scheduling
 		eventRecorder:           recorder,
 	}
 
-	s.worker = util.NewReconcileWorker(s.reconcile, util.WorkerTiming{
+	s.worker = util.NewReconcileWorker(strings.ToLower(userAgent), s.reconcile, util.WorkerTiming{
 		ClusterSyncDelay: s.clusterAvailableDelay,
 	})
 
@@ -133,7 +134,7 @@ func newSchedulingPreferenceController(config *util.ControllerConfig, scheduling
 	s.scheduler = scheduler
 
 	// Build deliverer for triggering cluster reconciliations.
-	s.clusterDeliverer = util.NewDelayingDeliverer()
+	s.clusterDeliverer = util.NewDelayingDeliverer(strings.ToLower(userAgent) + "-cluster")
 
 	s.store, s.controller, err = util.NewGenericInformer(
 		config.KubeConfig,
@@ -101,12 +101,12 @@ func newController(config *util.ControllerConfig) (*Controller, error) {
 		fedNamespace:            config.KubefedNamespace,
 	}
 
-	s.worker = util.NewReconcileWorker(s.reconcile, util.WorkerTiming{
+	s.worker = util.NewReconcileWorker("servicedns", s.reconcile, util.WorkerTiming{
 		ClusterSyncDelay: s.clusterAvailableDelay,
 	})
 
 	// Build deliverer for triggering cluster reconciliations.
-	s.clusterDeliverer = util.NewDelayingDeliverer()
+	s.clusterDeliverer = util.NewDelayingDeliverer("servicedns-cluster")
 
 	// Informer for the ServiceDNSRecord resource in federation.
 	var err error
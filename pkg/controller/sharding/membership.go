@@ -0,0 +1,268 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1beta1"
+	"k8s.io/klog"
+)
+
+const (
+	// MemberLabel is the label key of the leases of replicas that
+	// are members of the shard set.
+	MemberLabel = "kubefed.k8s.io/controller-manager-shard"
+
+	leaseNamePrefix = "kubefed-controller-manager-shard-"
+)
+
+// Membership maintains the shard lease of a replica of the controller
+// manager in the kubefed namespace and tracks the replicas whose
+// leases are current.  Shard keys are divided between the current
+// members.
+type Membership struct {
+	client        coordinationclient.LeasesGetter
+	namespace     string
+	identity      string
+	leaseName     string
+	leaseDuration time.Duration
+	renewPeriod   time.Duration
+
+	sync.RWMutex
+	// Sorted identities of the replicas whose leases are current
+	members []string
+	// Functions to call when members change
+	listeners    map[int]func()
+	nextListener int
+	// Closed once the replica has observed its own lease
+	synced chan struct{}
+}
+
+// NewMembership returns a membership for the replica with the given
+// identity.  The lease name must be unique to the replica.
+func NewMembership(client coordinationclient.LeasesGetter, namespace, identity, leaseName string, leaseDuration, renewPeriod time.Duration) *Membership {
+	return &Membership{
+		client:        client,
+		namespace:     namespace,
+		identity:      identity,
+		leaseName:     leaseNamePrefix + leaseName,
+		leaseDuration: leaseDuration,
+		renewPeriod:   renewPeriod,
+		listeners:     make(map[int]func()),
+		synced:        make(chan struct{}),
+	}
+}
+
+// Run renews the lease of the replica and observes the leases of
+// other replicas until the stop channel is closed, and then releases
+// the lease so that its keys are taken over without waiting for it to
+// expire.
+func (m *Membership) Run(stopChan <-chan struct{}) {
+	wait.Until(m.sync, m.renewPeriod, stopChan)
+	err := m.client.Leases(m.namespace).Delete(m.leaseName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		runtime.HandleError(errors.Wrapf(err, "Failed to release shard lease %q", m.leaseName))
+	}
+}
+
+// WaitForSync blocks until the replica has observed its own lease
+// and returns true, or returns false if the stop channel is closed
+// first.  Controllers consulting the membership should not be started
+// until it has synced.
+func (m *Membership) WaitForSync(stopChan <-chan struct{}) bool {
+	select {
+	case <-m.synced:
+		return true
+	case <-stopChan:
+		return false
+	}
+}
+
+// Owns indicates whether the replica owns the given shard key.  Until
+// the membership has synced, the replica is considered the only
+// member and owns every key.  Callers that cannot tolerate work being
+// duplicated with other replicas should wait for the membership to
+// sync before consulting it.
+func (m *Membership) Owns(key string) bool {
+	m.RLock()
+	defer m.RUnlock()
+	members := m.members
+	if len(members) == 0 {
+		members = []string{m.identity}
+	}
+	return Owner(members, key) == m.identity
+}
+
+// OnRebalance calls the given function whenever the members change,
+// until the stop channel is closed.
+func (m *Membership) OnRebalance(stopChan <-chan struct{}, rebalanceFunc func()) {
+	m.Lock()
+	id := m.nextListener
+	m.nextListener++
+	m.listeners[id] = rebalanceFunc
+	m.Unlock()
+
+	go func() {
+		<-stopChan
+		m.Lock()
+		delete(m.listeners, id)
+		m.Unlock()
+	}()
+}
+
+func (m *Membership) sync() {
+	now := time.Now()
+	if err := m.renew(now); err != nil {
+		runtime.HandleError(errors.Wrapf(err, "Failed to renew shard lease %q", m.leaseName))
+	}
+	members, err := m.currentMembers(now)
+	if err != nil {
+		runtime.HandleError(errors.Wrap(err, "Failed to list shard leases"))
+		return
+	}
+
+	m.Lock()
+	if equalMembers(m.members, members) {
+		m.Unlock()
+		return
+	}
+	klog.Infof("Shard members changed from %v to %v", m.members, members)
+	m.members = members
+	for _, member := range members {
+		if member == m.identity {
+			m.markSynced()
+			break
+		}
+	}
+	listeners := []func(){}
+	for _, listener := range m.listeners {
+		listeners = append(listeners, listener)
+	}
+	m.Unlock()
+
+	// Listeners are called asynchronously so that a listener blocked
+	// by a stopping controller cannot prevent renewal of the lease.
+	for _, listener := range listeners {
+		go listener()
+	}
+}
+
+func (m *Membership) markSynced() {
+	select {
+	case <-m.synced:
+	default:
+		close(m.synced)
+	}
+}
+
+// renew ensures the lease of the replica exists and records the given
+// time as its renewal time.
+func (m *Membership) renew(now time.Time) error {
+	leases := m.client.Leases(m.namespace)
+	renewTime := metav1.NewMicroTime(now)
+	leaseDurationSeconds := int32(m.leaseDuration / time.Second)
+
+	lease, err := leases.Get(m.leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1beta1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.leaseName,
+				Namespace: m.namespace,
+				Labels: map[string]string{
+					MemberLabel: "true",
+				},
+			},
+			Spec: coordinationv1beta1.LeaseSpec{
+				HolderIdentity:       &m.identity,
+				LeaseDurationSeconds: &leaseDurationSeconds,
+				AcquireTime:          &renewTime,
+				RenewTime:            &renewTime,
+			},
+		}
+		_, err = leases.Create(lease)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	lease.Spec.HolderIdentity = &m.identity
+	lease.Spec.LeaseDurationSeconds = &leaseDurationSeconds
+	lease.Spec.RenewTime = &renewTime
+	_, err = leases.Update(lease)
+	return err
+}
+
+// currentMembers returns the sorted identities of the replicas whose
+// leases have not expired at the given time.  Expired leases are
+// deleted since the replicas holding them have left the shard set.
+func (m *Membership) currentMembers(now time.Time) ([]string, error) {
+	leases := m.client.Leases(m.namespace)
+	selector := labels.SelectorFromSet(labels.Set{MemberLabel: "true"})
+	leaseList, err := leases.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	members := []string{}
+	for _, lease := range leaseList.Items {
+		if leaseExpired(&lease, now) {
+			if lease.Name == m.leaseName {
+				continue
+			}
+			klog.V(2).Infof("Deleting expired shard lease %q", lease.Name)
+			err := leases.Delete(lease.Name, &metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				runtime.HandleError(errors.Wrapf(err, "Failed to delete expired shard lease %q", lease.Name))
+			}
+			continue
+		}
+		members = append(members, *lease.Spec.HolderIdentity)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func leaseExpired(lease *coordinationv1beta1.Lease, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	return !now.Before(expiry)
+}
+
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"hash/fnv"
+)
+
+// Owner returns the member that owns the given shard key, or the
+// empty string if there are no members.
+//
+// Ownership is determined by rendezvous hashing: the owner is the
+// member with the highest hash of the member and key.  When a member
+// joins or leaves, only the keys owned by that member change owner.
+func Owner(members []string, key string) string {
+	var owner string
+	var ownerWeight uint64
+	for _, member := range members {
+		weight := hash(member, key)
+		if owner == "" || weight > ownerWeight || weight == ownerWeight && member < owner {
+			owner = member
+			ownerWeight = weight
+		}
+	}
+	return owner
+}
+
+func hash(member, key string) uint64 {
+	h := fnv.New64a()
+	// Errors are never returned by fnv
+	_, _ = h.Write([]byte(member))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	// FNV does not mix its input well enough for the hashes of similar
+	// members to be compared, so the final mixing step of murmur3 is
+	// applied.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOwner(t *testing.T) {
+	if owner := Owner(nil, "key"); owner != "" {
+		t.Fatalf("Expected no owner without members, got %q", owner)
+	}
+
+	members := []string{"a", "b", "c"}
+	keys := []string{}
+	for i := 0; i < 300; i++ {
+		keys = append(keys, fmt.Sprintf("deployments.apps/ns-%d", i))
+	}
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for _, key := range keys {
+		owner := Owner(members, key)
+		owners[key] = owner
+		counts[owner]++
+		// Ownership must not depend on the order of members
+		if reordered := Owner([]string{"c", "a", "b"}, key); reordered != owner {
+			t.Fatalf("Expected owner %q for key %q regardless of member order, got %q", owner, key, reordered)
+		}
+	}
+	for _, member := range members {
+		if counts[member] == 0 {
+			t.Fatalf("Expected member %q to own at least one key", member)
+		}
+	}
+
+	// Removing a member should only move the keys it owned.
+	remaining := []string{"a", "c"}
+	for _, key := range keys {
+		owner := Owner(remaining, key)
+		if owners[key] != "b" && owner != owners[key] {
+			t.Fatalf("Expected key %q to remain with %q, got %q", key, owners[key], owner)
+		}
+	}
+}
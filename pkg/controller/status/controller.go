@@ -37,6 +37,7 @@ import (
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 const (
@@ -75,6 +76,10 @@ type FederationStatusController struct {
 	statusClient util.ResourceClient
 
 	fedNamespace string
+
+	// Determines the federated resources whose status this replica
+	// of the controller manager collects.
+	sharding util.ShardingConfig
 }
 
 // StartFederationStatusController starts a new status controller for a type config
@@ -116,14 +121,15 @@ func newFederationStatusController(controllerConfig *util.ControllerConfig, type
 		client:                  client,
 		statusClient:            statusClient,
 		fedNamespace:            controllerConfig.KubefedNamespace,
+		sharding:                controllerConfig.Sharding,
 	}
 
-	s.worker = util.NewReconcileWorker(s.reconcile, util.WorkerTiming{
+	s.worker = util.NewReconcileWorker(userAgent, s.reconcile, util.WorkerTiming{
 		ClusterSyncDelay: s.clusterAvailableDelay,
 	})
 
 	// Build deliverer for triggering cluster reconciliations.
-	s.clusterDeliverer = util.NewDelayingDeliverer()
+	s.clusterDeliverer = util.NewDelayingDeliverer(userAgent + "-cluster")
 
 	// Start informers on the resources for the federated type
 	enqueueObj := s.worker.EnqueueObject
@@ -181,6 +187,16 @@ func (s *FederationStatusController) Run(stopChan <-chan struct{}) {
 
 	s.worker.Run(stopChan)
 
+	// Resources whose ownership may have moved to this replica need
+	// to be reconciled when the members of the shard set change.
+	s.sharding.OnRebalance(stopChan, func() {
+		select {
+		case <-stopChan:
+		default:
+			s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now())
+		}
+	})
+
 	// Ensure all goroutines are cleaned up when the stop channel closes
 	go func() {
 		<-stopChan
@@ -232,6 +248,12 @@ func (s *FederationStatusController) reconcile(qualifiedName util.QualifiedName)
 		return util.StatusNotSynced
 	}
 
+	if !s.sharding.OwnsResourceStatus(s.typeConfig.GetObjectMeta().Name, qualifiedName.Namespace) {
+		// Another replica of the controller manager is responsible
+		// for the status of the resource.
+		return util.StatusAllOK
+	}
+
 	federatedKind := s.typeConfig.GetFederatedType().Kind
 	statusKind := s.typeConfig.GetStatus().Kind
 	key := qualifiedName.String()
@@ -239,6 +261,7 @@ func (s *FederationStatusController) reconcile(qualifiedName util.QualifiedName)
 	klog.V(4).Infof("Starting to reconcile %v %v", statusKind, key)
 	startTime := time.Now()
 	defer klog.V(4).Infof("Finished reconciling %v %v (duration: %v)", statusKind, key, time.Since(startTime))
+	defer metrics.ReconcileDurationFromStart("status", s.typeConfig.GetObjectMeta().Name, startTime)
 
 	fedObject, err := s.objFromCache(s.federatedStore, federatedKind, key)
 	if err != nil {
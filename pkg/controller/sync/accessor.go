@@ -25,7 +25,9 @@ import (
 	"k8s.io/klog"
 
 	"sigs.k8s.io/kubefed/pkg/apis/core/typeconfig"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/version"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
@@ -37,6 +39,7 @@ type FederatedResourceAccessor interface {
 	HasSynced() bool
 	FederatedResource(qualifiedName util.QualifiedName) (federatedResource FederatedResource, possibleOrphan bool, err error)
 	VisitFederatedResources(visitFunc func(obj interface{}))
+	VisitFederatedResourcesForCluster(cluster *fedv1a1.KubefedCluster, visitFunc func(obj interface{})) error
 }
 
 type resourceAccessor struct {
@@ -45,8 +48,9 @@ type resourceAccessor struct {
 	targetIsNamespace bool
 	fedNamespace      string
 
-	// The informer for the federated type.
-	federatedStore      cache.Store
+	// The informer for the federated type.  Resources are indexed by
+	// the clusters that their placement includes or could include.
+	federatedStore      cache.Indexer
 	federatedController cache.Controller
 
 	// The informer used to source namespaces for templates of
@@ -63,6 +67,10 @@ type resourceAccessor struct {
 	fedNamespaceStore      cache.Store
 	fedNamespaceController cache.Controller
 
+	// Resolves the propagation and override policies selecting a
+	// federated resource.
+	policyAccessor *policyAccessor
+
 	// Manages propagated versions
 	versionManager *version.VersionManager
 
@@ -94,7 +102,7 @@ func NewFederatedResourceAccessor(
 	if err != nil {
 		return nil, err
 	}
-	a.federatedStore, a.federatedController = util.NewResourceInformer(federatedTypeClient, targetNamespace, enqueueObj)
+	a.federatedStore, a.federatedController = util.NewIndexedResourceInformer(federatedTypeClient, targetNamespace, enqueueObj, clusterIndexers())
 
 	if a.targetIsNamespace {
 		// Initialize an informer for namespaces.  The namespace
@@ -108,6 +116,16 @@ func NewFederatedResourceAccessor(
 		a.namespaceStore, a.namespaceController = util.NewResourceInformer(namespaceTypeClient, targetNamespace, enqueueObj)
 	}
 
+	enqueueNamespace := func(namespace string) {
+		for _, rawObj := range a.federatedStore.List() {
+			obj := rawObj.(pkgruntime.Object)
+			qualifiedName := util.NewQualifiedName(obj)
+			if qualifiedName.Namespace == namespace {
+				enqueueObj(obj)
+			}
+		}
+	}
+
 	if typeConfig.GetNamespaced() {
 		fedNamespaceEnqueue := func(fedNamespaceObj pkgruntime.Object) {
 			// When a federated namespace changes, every resource in
@@ -116,14 +134,7 @@ func NewFederatedResourceAccessor(
 			// TODO(marun) Consider optimizing this to only reconcile
 			// contained resources in response to a change in
 			// placement for the federated namespace.
-			namespace := util.NewQualifiedName(fedNamespaceObj).Namespace
-			for _, rawObj := range a.federatedStore.List() {
-				obj := rawObj.(pkgruntime.Object)
-				qualifiedName := util.NewQualifiedName(obj)
-				if qualifiedName.Namespace == namespace {
-					enqueueObj(obj)
-				}
-			}
+			enqueueNamespace(util.NewQualifiedName(fedNamespaceObj).Namespace)
 		}
 		// Initialize an informer for federated namespaces.  Placement
 		// for a resource is computed as the intersection of resource
@@ -135,6 +146,16 @@ func NewFederatedResourceAccessor(
 		a.fedNamespaceStore, a.fedNamespaceController = util.NewResourceInformer(fedNamespaceClient, targetNamespace, fedNamespaceEnqueue)
 	}
 
+	enqueueAll := func() {
+		for _, rawObj := range a.federatedStore.List() {
+			enqueueObj(rawObj.(pkgruntime.Object))
+		}
+	}
+	a.policyAccessor, err = newPolicyAccessor(controllerConfig, enqueueNamespace, enqueueAll)
+	if err != nil {
+		return nil, err
+	}
+
 	a.versionManager = version.NewVersionManager(
 		client,
 		typeConfig.GetFederatedNamespaced(),
@@ -142,6 +163,11 @@ func NewFederatedResourceAccessor(
 		typeConfig.GetTarget().Kind,
 		targetNamespace,
 	)
+	if sharding := controllerConfig.Sharding; sharding.Sharded() && sharding.Mode == fedv1a1.ShardByCluster {
+		// Versions for the clusters of other replicas are recorded
+		// in the same propagated version.
+		a.versionManager.ShareClusters(sharding.OwnsCluster)
+	}
 
 	return a, nil
 }
@@ -155,6 +181,7 @@ func (a *resourceAccessor) Run(stopChan <-chan struct{}) {
 	if a.fedNamespaceController != nil {
 		go a.fedNamespaceController.Run(stopChan)
 	}
+	a.policyAccessor.Run(stopChan)
 }
 
 func (a *resourceAccessor) HasSynced() bool {
@@ -175,6 +202,10 @@ func (a *resourceAccessor) HasSynced() bool {
 		klog.V(2).Infof("FederatedNamespace informer for %s not synced", kind)
 		return false
 	}
+	if !a.policyAccessor.HasSynced() {
+		klog.V(2).Infof("Policy informers for %s not synced", kind)
+		return false
+	}
 	return true
 }
 
@@ -261,6 +292,25 @@ func (a *resourceAccessor) FederatedResource(eventSource util.QualifiedName) (Fe
 		// will be removed.
 	}
 
+	var propagationPolicy *resolvedPropagationPolicy
+	if !hasPlacement(resource) {
+		propagationPolicy, err = a.policyAccessor.propagationPolicyForResource(kind, resource)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	overridePolicy, err := a.policyAccessor.overridePolicyForResource(kind, resource)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Clusters previously selected by spread constraints are
+	// preferred when placement is computed.
+	selectedClusters, err := status.GetSelectedClusters(resource)
+	if err != nil {
+		return nil, false, err
+	}
+
 	return &federatedResource{
 		limitedScope:      a.limitedScope,
 		typeConfig:        a.typeConfig,
@@ -272,6 +322,9 @@ func (a *resourceAccessor) FederatedResource(eventSource util.QualifiedName) (Fe
 		versionManager:    a.versionManager,
 		namespace:         namespace,
 		fedNamespace:      fedNamespace,
+		propagationPolicy: propagationPolicy,
+		overridePolicy:    overridePolicy,
+		selectedClusters:  selectedClusters,
 		eventRecorder:     a.eventRecorder,
 	}, false, nil
 }
@@ -282,6 +335,20 @@ func (a *resourceAccessor) VisitFederatedResources(visitFunc func(obj interface{
 	}
 }
 
+// VisitFederatedResourcesForCluster visits the federated resources
+// whose placement includes or could include the given cluster, or
+// that have been propagated to it.
+func (a *resourceAccessor) VisitFederatedResourcesForCluster(cluster *fedv1a1.KubefedCluster, visitFunc func(obj interface{})) error {
+	resources, err := resourcesForCluster(a.federatedStore, cluster)
+	if err != nil {
+		return err
+	}
+	for _, obj := range resources {
+		visitFunc(obj)
+	}
+	return nil
+}
+
 func (a *resourceAccessor) isSystemNamespace(namespace string) bool {
 	// TODO(font): Need a configurable or discoverable list of namespaces
 	// to not propagate beyond just the default system namespaces e.g.
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+const (
+	// clusterNameIndex indexes federated resources by the names of
+	// the clusters that their placement names or that are recorded
+	// in their status.
+	clusterNameIndex = "clusterName"
+
+	// clusterSelectorIndex indexes federated resources by the
+	// cluster selector of their placement.
+	clusterSelectorIndex = "clusterSelector"
+
+	// anyClusterIndexValue is the clusterSelectorIndex value of
+	// federated resources whose placement may include any cluster
+	// (e.g. placement determined by a propagation policy).  It is not
+	// a valid label selector.
+	anyClusterIndexValue = "<any>"
+)
+
+// clusterIndexers returns the indexes used to determine the federated
+// resources affected by a change to a cluster.  Index functions
+// cannot return errors since an error would cause the informer to
+// panic, so a resource whose placement cannot be parsed is indexed as
+// affected by any cluster.
+func clusterIndexers() cache.Indexers {
+	return cache.Indexers{
+		clusterNameIndex:     clusterNameIndexFunc,
+		clusterSelectorIndex: clusterSelectorIndexFunc,
+	}
+}
+
+func clusterNameIndexFunc(obj interface{}) ([]string, error) {
+	resource, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil
+	}
+	clusterNames := sets.String{}
+	if placement, err := util.UnmarshalGenericPlacement(resource); err == nil {
+		clusterNames.Insert(placement.ClusterNames()...)
+		if failover := placement.Spec.Placement.Failover; failover != nil {
+			for _, cluster := range failover.PreferredClusters {
+				clusterNames.Insert(cluster.Name)
+			}
+		}
+	}
+	// Clusters to which the resource was propagated are affected
+	// even if they are no longer selected.
+	if statusMap, err := status.GetClusterStatus(resource); err == nil {
+		for clusterName := range statusMap {
+			clusterNames.Insert(clusterName)
+		}
+	}
+	if pendingDeletions, err := status.GetPendingDeletions(resource); err == nil {
+		for clusterName := range pendingDeletions {
+			clusterNames.Insert(clusterName)
+		}
+	}
+	return clusterNames.List(), nil
+}
+
+func clusterSelectorIndexFunc(obj interface{}) ([]string, error) {
+	resource, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil
+	}
+	if !hasPlacement(resource) {
+		return []string{anyClusterIndexValue}, nil
+	}
+	placement, err := util.UnmarshalGenericPlacement(resource)
+	if err != nil {
+		return []string{anyClusterIndexValue}, nil
+	}
+	// Explicit cluster names take precedence over a selector.
+	fields := placement.Spec.Placement
+	if fields.Failover != nil || len(fields.Clusters) > 0 || fields.ClusterSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(fields.ClusterSelector)
+	if err != nil {
+		return []string{anyClusterIndexValue}, nil
+	}
+	return []string{selector.String()}, nil
+}
+
+// resourcesForCluster returns the federated resources in the given
+// indexer whose placement includes or could include the given
+// cluster, or that have been propagated to it.
+func resourcesForCluster(indexer cache.Indexer, cluster *fedv1a1.KubefedCluster) ([]pkgruntime.Object, error) {
+	resources := []pkgruntime.Object{}
+	keys := sets.String{}
+	add := func(objs []interface{}) {
+		for _, obj := range objs {
+			resource := obj.(pkgruntime.Object)
+			key := util.NewQualifiedName(resource).String()
+			if !keys.Has(key) {
+				keys.Insert(key)
+				resources = append(resources, resource)
+			}
+		}
+	}
+
+	objs, err := indexer.ByIndex(clusterNameIndex, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	add(objs)
+
+	clusterLabels := labels.Set(cluster.Labels)
+	for _, value := range indexer.ListIndexFuncValues(clusterSelectorIndex) {
+		if value != anyClusterIndexValue {
+			selector, err := labels.Parse(value)
+			if err == nil && !selector.Matches(clusterLabels) {
+				continue
+			}
+		}
+		objs, err := indexer.ByIndex(clusterSelectorIndex, value)
+		if err != nil {
+			return nil, err
+		}
+		add(objs)
+	}
+	return resources, nil
+}
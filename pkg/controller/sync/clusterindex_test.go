@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func TestResourcesForCluster(t *testing.T) {
+	newResource := func(name string, placement, status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec": map[string]interface{}{},
+			},
+		}
+		obj.SetAPIVersion("types.kubefed.k8s.io/v1beta1")
+		obj.SetKind("FederatedConfigMap")
+		obj.SetNamespace("ns")
+		obj.SetName(name)
+		if placement != nil {
+			obj.Object["spec"].(map[string]interface{})["placement"] = placement
+		}
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		return obj
+	}
+	clusterNames := func(names ...string) []interface{} {
+		clusters := []interface{}{}
+		for _, name := range names {
+			clusters = append(clusters, map[string]interface{}{"name": name})
+		}
+		return clusters
+	}
+
+	indexer := cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc, clusterIndexers())
+	resources := []*unstructured.Unstructured{
+		newResource("named", map[string]interface{}{"clusters": clusterNames("c1")}, nil),
+		newResource("failover", map[string]interface{}{
+			"failover": map[string]interface{}{"preferredClusters": clusterNames("c2", "c1")},
+		}, nil),
+		newResource("all", map[string]interface{}{"clusterSelector": map[string]interface{}{}}, nil),
+		newResource("selected", map[string]interface{}{
+			"clusterSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"region": "us"}},
+		}, nil),
+		newResource("none", map[string]interface{}{}, nil),
+		newResource("policy", nil, nil),
+		newResource("propagated", map[string]interface{}{"clusters": clusterNames("c2")}, map[string]interface{}{
+			"clusters": []interface{}{map[string]interface{}{"name": "c3"}},
+		}),
+	}
+	for _, resource := range resources {
+		if err := indexer.Add(resource); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	testCases := map[string]struct {
+		clusterName   string
+		clusterLabels map[string]string
+		expectedNames sets.String
+	}{
+		"named and selected clusters": {
+			clusterName:   "c1",
+			clusterLabels: map[string]string{"region": "us"},
+			expectedNames: sets.NewString("named", "failover", "all", "selected", "policy"),
+		},
+		"preferred cluster without matching labels": {
+			clusterName:   "c2",
+			clusterLabels: map[string]string{"region": "eu"},
+			expectedNames: sets.NewString("failover", "all", "policy", "propagated"),
+		},
+		"propagated cluster": {
+			clusterName:   "c3",
+			expectedNames: sets.NewString("all", "policy", "propagated"),
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			cluster := &fedv1a1.KubefedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   testCase.clusterName,
+					Labels: testCase.clusterLabels,
+				},
+			}
+			objs, err := resourcesForCluster(indexer, cluster)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			names := sets.NewString()
+			for _, obj := range objs {
+				names.Insert(util.NewQualifiedName(obj).Name)
+			}
+			if !names.Equal(testCase.expectedNames) {
+				t.Fatalf("Expected resources %v, got %v", testCase.expectedNames.List(), names.List())
+			}
+		})
+	}
+}
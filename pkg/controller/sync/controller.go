@@ -42,14 +42,20 @@ import (
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/dispatch"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/health"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 	finalizersutil "sigs.k8s.io/kubefed/pkg/controller/util/finalizers"
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 const (
 	allClustersKey = "ALL_CLUSTERS"
 
+	// How long to wait before checking again whether the dependencies
+	// of a federated resource have been propagated.
+	defaultDependencyRecheckDelay = 10 * time.Second
+
 	// If this finalizer is present on a federated resource, the sync
 	// controller will have the opportunity to perform pre-deletion operations
 	// (like deleting managed resources from member clusters).
@@ -60,6 +66,11 @@ const (
 	// If the annotation is not present (the default), resources in member
 	// clusters will be deleted before the federated resource is deleted.
 	OrphanManagedResources = "kubefed.k8s.io/orphan"
+
+	// If this annotation is set to "true" on a federated resource with
+	// a rollout strategy, updates will not be rolled out to further
+	// member clusters until the annotation is removed.
+	PauseRollout = "kubefed.k8s.io/rollout-paused"
 )
 
 // FederationSyncController synchronizes the state of a federated type
@@ -83,13 +94,31 @@ type FederationSyncController struct {
 	smallDelay              time.Duration
 	updateTimeout           time.Duration
 
+	// Limits the number of operations dispatched concurrently.
+	maxConcurrentDispatches int
+	clusterLimiter          *util.ClusterOperationLimiter
+
 	typeConfig typeconfig.Interface
 
+	// Assesses the health of target resources, or nil if the health
+	// of the target type is not assessed.
+	healthChecker health.Checker
+
 	fedAccessor FederatedResourceAccessor
 
+	dependencyAccessor *dependencyAccessor
+	// How long to wait before checking again whether the dependencies
+	// of a federated resource have been propagated.
+	dependencyRecheckDelay time.Duration
+
 	hostClusterClient genericclient.Client
 
-	skipAdoptingResources bool
+	// The adoption policy of types that do not specify one
+	defaultAdoptionPolicy fedv1a1.AdoptionPolicy
+
+	// Determines the federated resources and member clusters this
+	// replica of the controller manager propagates.
+	sharding util.ShardingConfig
 }
 
 // StartFederationSyncController starts a new sync controller for a type config
@@ -122,25 +151,46 @@ func newFederationSyncController(controllerConfig *util.ControllerConfig, typeCo
 	s := &FederationSyncController{
 		clusterAvailableDelay:   controllerConfig.ClusterAvailableDelay,
 		clusterUnavailableDelay: controllerConfig.ClusterUnavailableDelay,
-		smallDelay:              time.Second * 3,
-		updateTimeout:           time.Second * 30,
+		smallDelay:              controllerConfig.SyncConfig.ReconcileDelay,
+		updateTimeout:           controllerConfig.SyncConfig.UpdateTimeout,
+		maxConcurrentDispatches: controllerConfig.SyncConfig.MaxConcurrentDispatches,
+		clusterLimiter:          controllerConfig.ClusterOperationLimiter,
 		eventRecorder:           recorder,
 		typeConfig:              typeConfig,
 		hostClusterClient:       client,
-		skipAdoptingResources:   controllerConfig.SkipAdoptingResources,
+		defaultAdoptionPolicy:   fedv1a1.AdoptionPolicyAlways,
+		dependencyRecheckDelay:  defaultDependencyRecheckDelay,
+		sharding:                controllerConfig.Sharding,
+	}
+	if controllerConfig.SkipAdoptingResources {
+		s.defaultAdoptionPolicy = fedv1a1.AdoptionPolicyNever
+	}
+	var err error
+	s.dependencyAccessor, err = newDependencyAccessor(controllerConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.healthChecker, err = health.CheckerForType(typeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to initialize health check")
+	}
+	if s.smallDelay == 0 {
+		s.smallDelay = util.DefaultSyncReconcileDelay
+	}
+	if s.updateTimeout == 0 {
+		s.updateTimeout = util.DefaultSyncUpdateTimeout
 	}
 
-	s.worker = util.NewReconcileWorker(s.reconcile, util.WorkerTiming{
+	s.worker = util.NewReconcileWorker(userAgent, s.reconcile, util.WorkerTiming{
 		ClusterSyncDelay: s.clusterAvailableDelay,
 	})
 
 	// Build deliverer for triggering cluster reconciliations.
-	s.clusterDeliverer = util.NewDelayingDeliverer()
+	s.clusterDeliverer = util.NewDelayingDeliverer(userAgent + "-cluster")
 
 	targetAPIResource := typeConfig.GetTarget()
 
 	// Federated informer on the resource type in members of federation.
-	var err error
 	s.informer, err = util.NewFederatedInformer(
 		controllerConfig,
 		client,
@@ -150,13 +200,20 @@ func newFederationSyncController(controllerConfig *util.ControllerConfig, typeCo
 			s.worker.EnqueueForRetry(qualifiedName)
 		},
 		&util.ClusterLifecycleHandlerFuncs{
+			// When a cluster becomes available or unavailable,
+			// process the resources that may be propagated to it.
 			ClusterAvailable: func(cluster *fedv1a1.KubefedCluster) {
-				// When new cluster becomes available process all the target resources again.
-				s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now().Add(s.clusterAvailableDelay))
+				s.reconcileForCluster(cluster, s.clusterAvailableDelay)
 			},
-			// When a cluster becomes unavailable process all the target resources again.
 			ClusterUnavailable: func(cluster *fedv1a1.KubefedCluster, _ []interface{}) {
-				s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now().Add(s.clusterUnavailableDelay))
+				s.reconcileForCluster(cluster, s.clusterUnavailableDelay)
+			},
+			// When the labels of a cluster change, process the
+			// resources whose selectors matched the old or match
+			// the new labels.
+			ClusterLabelsChanged: func(oldCluster, curCluster *fedv1a1.KubefedCluster) {
+				s.reconcileForCluster(oldCluster, s.smallDelay)
+				s.reconcileForCluster(curCluster, s.smallDelay)
 			},
 		},
 	)
@@ -180,11 +237,21 @@ func (s *FederationSyncController) minimizeLatency() {
 	s.clusterUnavailableDelay = time.Second
 	s.smallDelay = 20 * time.Millisecond
 	s.updateTimeout = 5 * time.Second
+	s.dependencyRecheckDelay = time.Second
 	s.worker.SetDelay(50*time.Millisecond, s.clusterAvailableDelay)
 }
 
+func (s *FederationSyncController) dispatchConfig() dispatch.DispatchConfig {
+	return dispatch.DispatchConfig{
+		Timeout:                 s.updateTimeout,
+		MaxConcurrentOperations: s.maxConcurrentDispatches,
+		ClusterLimiter:          s.clusterLimiter,
+	}
+}
+
 func (s *FederationSyncController) Run(stopChan <-chan struct{}) {
 	s.fedAccessor.Run(stopChan)
+	s.dependencyAccessor.Run(stopChan)
 	s.informer.Start()
 	s.clusterDeliverer.StartWithHandler(func(_ *util.DelayingDelivererItem) {
 		s.reconcileOnClusterChange()
@@ -192,6 +259,16 @@ func (s *FederationSyncController) Run(stopChan <-chan struct{}) {
 
 	s.worker.Run(stopChan)
 
+	// Resources whose ownership may have moved to this replica need
+	// to be reconciled when the members of the shard set change.
+	s.sharding.OnRebalance(stopChan, func() {
+		select {
+		case <-stopChan:
+		default:
+			s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now())
+		}
+	})
+
 	// Ensure all goroutines are cleaned up when the stop channel closes
 	go func() {
 		<-stopChan
@@ -236,11 +313,36 @@ func (s *FederationSyncController) reconcileOnClusterChange() {
 	})
 }
 
+// reconcileForCluster triggers reconciliation of the target federated
+// resources affected by a change to the given cluster.  All target
+// federated resources are reconciled if the affected resources cannot
+// be determined.
+func (s *FederationSyncController) reconcileForCluster(cluster *fedv1a1.KubefedCluster, delay time.Duration) {
+	if !s.fedAccessor.HasSynced() {
+		s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now().Add(delay))
+		return
+	}
+	err := s.fedAccessor.VisitFederatedResourcesForCluster(cluster, func(obj interface{}) {
+		qualifiedName := util.NewQualifiedName(obj.(pkgruntime.Object))
+		s.worker.EnqueueWithDelay(qualifiedName, delay)
+	})
+	if err != nil {
+		runtime.HandleError(errors.Wrapf(err, "Failed to determine the resources affected by a change to cluster %q", cluster.Name))
+		s.clusterDeliverer.DeliverAt(allClustersKey, nil, time.Now().Add(delay))
+	}
+}
+
 func (s *FederationSyncController) reconcile(qualifiedName util.QualifiedName) util.ReconciliationStatus {
 	if !s.isSynced() {
 		return util.StatusNotSynced
 	}
 
+	if !s.sharding.OwnsResource(s.typeConfig.GetObjectMeta().Name, qualifiedName.Namespace) {
+		// Another replica of the controller manager is responsible
+		// for the resource.
+		return util.StatusAllOK
+	}
+
 	kind := s.typeConfig.GetFederatedType().Kind
 
 	fedResource, possibleOrphan, err := s.fedAccessor.FederatedResource(qualifiedName)
@@ -251,7 +353,7 @@ func (s *FederationSyncController) reconcile(qualifiedName util.QualifiedName) u
 	if possibleOrphan {
 		targetKind := s.typeConfig.GetTarget().Kind
 		klog.V(2).Infof("Ensuring the removal of the label %q from %s %q in member clusters.", util.ManagedByFederationLabelKey, targetKind, qualifiedName)
-		err = s.removeManagedLabel(targetKind, qualifiedName)
+		_, err = s.removeManagedLabel(targetKind, qualifiedName)
 		if err != nil {
 			wrappedErr := errors.Wrapf(err, "failed to remove the label %q from %s %q in member clusters", util.ManagedByFederationLabelKey, targetKind, qualifiedName)
 			runtime.HandleError(wrappedErr)
@@ -269,6 +371,7 @@ func (s *FederationSyncController) reconcile(qualifiedName util.QualifiedName) u
 	klog.V(4).Infof("Starting to reconcile %s %q", kind, key)
 	startTime := time.Now()
 	defer klog.V(4).Infof("Finished reconciling %s %q (duration: %v)", kind, key, time.Since(startTime))
+	defer metrics.ReconcileDurationFromStart("sync", s.typeConfig.GetObjectMeta().Name, startTime)
 
 	if fedResource.Object().GetDeletionTimestamp() != nil {
 		klog.V(3).Infof("Handling deletion of %s %q", kind, key)
@@ -287,28 +390,84 @@ func (s *FederationSyncController) reconcile(qualifiedName util.QualifiedName) u
 // syncToClusters ensures that the state of the given object is
 // synchronized to member clusters.
 func (s *FederationSyncController) syncToClusters(fedResource FederatedResource) util.ReconciliationStatus {
+	// The deletion times of clusters pending deletion are carried
+	// over from the last reconcile so that the grace period of the
+	// DelayedDelete policy starts when a cluster stops being selected.
+	// They are also retained if propagation fails before the pending
+	// deletions can be determined.
+	previousDeletions, err := status.GetPendingDeletions(fedResource.Object())
+	if err != nil {
+		runtime.HandleError(err)
+		previousDeletions = make(map[string]time.Time)
+	}
+
 	clusters, err := s.informer.GetClusters()
 	if err != nil {
 		fedResource.RecordError(string(status.ClusterRetrievalFailed), errors.Wrap(err, "Failed to retrieve list of clusters"))
-		return s.setPropagationStatus(fedResource, status.ClusterRetrievalFailed, nil)
+		return s.setPropagationStatus(fedResource, status.ClusterRetrievalFailed, nil, nil, nil, previousDeletions, nil)
 	}
 
 	selectedClusterNames, err := fedResource.ComputePlacement(clusters)
 	if err != nil {
 		fedResource.RecordError(string(status.ComputePlacementFailed), errors.Wrap(err, "Failed to compute placement"))
-		return s.setPropagationStatus(fedResource, status.ComputePlacementFailed, nil)
+		return s.setPropagationStatus(fedResource, status.ComputePlacementFailed, nil, nil, nil, previousDeletions, nil)
+	}
+	if delay := fedResource.PlacementRecheckDelay(); delay > 0 {
+		// Ensure unavailable clusters are replaced once they have
+		// been unavailable for longer than failover placement allows.
+		s.worker.EnqueueWithDelay(fedResource.FederatedName(), delay)
 	}
 
+	rolloutStrategy, err := util.GetRolloutStrategy(fedResource.Object())
+	if err != nil {
+		fedResource.RecordError(string(status.RolloutStrategyInvalid), errors.Wrap(err, "Failed to read rollout strategy"))
+		return s.setPropagationStatus(fedResource, status.RolloutStrategyInvalid, nil, nil, nil, previousDeletions, nil)
+	}
+
+	deletionPolicy, gracePeriod, err := fedResource.DeletionPolicy()
+	if err != nil {
+		fedResource.RecordError(string(status.DeletionPolicyInvalid), errors.Wrap(err, "Failed to read deletion policy"))
+		return s.setPropagationStatus(fedResource, status.DeletionPolicyInvalid, nil, nil, nil, previousDeletions, nil)
+	}
+	adoptionPolicy, err := fedResource.AdoptionPolicy(s.defaultAdoptionPolicy)
+	if err != nil {
+		fedResource.RecordError(string(status.AdoptionPolicyInvalid), errors.Wrap(err, "Failed to read adoption policy"))
+		return s.setPropagationStatus(fedResource, status.AdoptionPolicyInvalid, nil, nil, nil, previousDeletions, nil)
+	}
+	dependencies, err := fedResource.Dependencies()
+	if err != nil {
+		fedResource.RecordError(string(status.DependenciesInvalid), errors.Wrap(err, "Failed to determine dependencies"))
+		return s.setPropagationStatus(fedResource, status.DependenciesInvalid, nil, nil, nil, previousDeletions, nil)
+	}
+	previousStatusMap, err := status.GetClusterStatus(fedResource.Object())
+	if err != nil {
+		runtime.HandleError(err)
+		previousStatusMap = make(status.PropagationStatusMap)
+	}
+	// The propagation of dependencies is only retrieved if the
+	// resource needs to be created in a cluster.
+	var dependencyClusters map[util.Dependency]sets.String
+	waitingForDependencies := false
+	pendingDeletions := make(map[string]time.Time)
+	now := time.Now()
+
 	kind := fedResource.TargetKind()
 	key := fedResource.TargetName().String()
 	klog.V(4).Infof("Syncing %s %q in underlying clusters, selected clusters are: %s", kind, key, selectedClusterNames)
 
-	dispatcher := dispatch.NewManagedDispatcher(s.informer.GetClientForCluster, fedResource, s.skipAdoptingResources)
+	dispatcher := dispatch.NewManagedDispatcher(s.informer.GetClientForClusterWithContext, fedResource, adoptionPolicy, s.dispatchConfig())
 
+	rolloutCandidates := []rolloutCandidate{}
 	for _, cluster := range clusters {
 		clusterName := cluster.Name
 		selectedCluster := selectedClusterNames.Has(clusterName)
 
+		if !s.sharding.OwnsCluster(clusterName) {
+			// Another replica of the controller manager propagates
+			// to the cluster and reports its status.
+			continue
+		}
+
 		if !util.IsClusterReady(&cluster.Status) {
 			if selectedCluster {
 				// Cluster state only needs to be reported in resource
@@ -346,7 +505,31 @@ func (s *FederationSyncController) syncToClusters(fedResource FederatedResource)
 				// Host cluster namespace needs to have the managed
 				// label removed so it won't be cached anymore.
 				dispatcher.RemoveManagedLabel(clusterName, clusterObj)
-			} else {
+				continue
+			}
+			if util.IsAdopted(clusterObj) {
+				// A resource that kubefed did not create is returned
+				// to its unmanaged state rather than deleted.
+				dispatcher.RemoveManagedLabel(clusterName, clusterObj)
+				continue
+			}
+			switch deletionPolicy {
+			case fedv1a1.DeletionPolicyOrphan:
+				dispatcher.RemoveManagedLabel(clusterName, clusterObj)
+			case fedv1a1.DeletionPolicyDelayedDelete:
+				deleteAfter, ok := previousDeletions[clusterName]
+				if !ok {
+					deleteAfter = now.Add(gracePeriod)
+					fedResource.RecordEvent("DeletionScheduled", "%s %q in cluster %q will be deleted after %s",
+						kind, key, clusterName, deleteAfter.UTC().Format(time.RFC3339))
+				}
+				if now.Before(deleteAfter) {
+					pendingDeletions[clusterName] = deleteAfter
+					dispatcher.RecordStatus(clusterName, status.DeletionPending)
+					continue
+				}
+				dispatcher.Delete(clusterName)
+			default:
 				dispatcher.Delete(clusterName)
 			}
 			continue
@@ -359,11 +542,55 @@ func (s *FederationSyncController) syncToClusters(fedResource FederatedResource)
 		// subsequent operations.  Otherwise the object won't be found
 		// but an add operation will fail with AlreadyExists.
 		if clusterObj == nil {
+			if len(dependencies) > 0 && dependencyClusters == nil {
+				dependencyClusters, err = s.dependencyAccessor.PropagatedClusters(dependencies)
+				if err != nil {
+					dispatcher.RecordClusterError(status.DependencyRetrievalFailed, clusterName, err)
+					continue
+				}
+			}
+			if unpropagated := unpropagatedDependencies(dependencies, dependencyClusters, clusterName); len(unpropagated) > 0 {
+				// Avoid recording an event every time the resource
+				// is rechecked while waiting.
+				if previousStatusMap[clusterName] != status.WaitingForDependencies {
+					fedResource.RecordEvent(string(status.WaitingForDependencies), "Creation of %s %q in cluster %q is waiting for the propagation of %v",
+						kind, key, clusterName, unpropagated)
+				}
+				dispatcher.RecordStatus(clusterName, status.WaitingForDependencies)
+				waitingForDependencies = true
+				continue
+			}
 			dispatcher.Create(clusterName)
+		} else if rolloutStrategy != nil {
+			// Updates are dispatched once the clusters to roll out
+			// to have been determined.
+			candidate, err := newRolloutCandidate(fedResource, clusterName, clusterObj)
+			if err != nil {
+				dispatcher.RecordClusterError(status.VersionRetrievalFailed, clusterName, err)
+				continue
+			}
+			rolloutCandidates = append(rolloutCandidates, candidate)
 		} else {
 			dispatcher.Update(clusterName, clusterObj)
 		}
 	}
+
+	var rolloutStatus *status.RolloutStatus
+	if rolloutStrategy != nil {
+		paused := fedResource.Object().GetAnnotations()[PauseRollout] == "true"
+		var updateClusters sets.String
+		updateClusters, rolloutStatus = planRollout(rolloutStrategy, paused, selectedClusterNames.Len(), rolloutCandidates)
+		for _, candidate := range rolloutCandidates {
+			// Current clusters are still updated to ensure their
+			// metadata is consistent with the federated resource.
+			if candidate.current || updateClusters.Has(candidate.clusterName) {
+				dispatcher.Update(candidate.clusterName, candidate.clusterObj)
+			} else {
+				dispatcher.RecordStatus(candidate.clusterName, status.RolloutPending)
+			}
+		}
+	}
+
 	_, timeoutErr := dispatcher.Wait()
 	if timeoutErr != nil {
 		fedResource.RecordError("OperationTimeoutError", timeoutErr)
@@ -379,12 +606,102 @@ func (s *FederationSyncController) syncToClusters(fedResource FederatedResource)
 		runtime.HandleError(err)
 	}
 
+	// Ensure resources pending deletion are deleted once their grace
+	// period has elapsed.
+	var deletionDelay time.Duration
+	for _, deleteAfter := range pendingDeletions {
+		delay := deleteAfter.Sub(now)
+		if deletionDelay == 0 || delay < deletionDelay {
+			deletionDelay = delay
+		}
+	}
+	if deletionDelay > 0 {
+		s.worker.EnqueueWithDelay(fedResource.FederatedName(), deletionDelay)
+	}
+	if waitingForDependencies {
+		s.worker.EnqueueWithDelay(fedResource.FederatedName(), s.dependencyRecheckDelay)
+	}
+
+	resourceStatus, err := s.resourceStatus(fedResource, clusters, selectedClusterNames)
+	if err != nil {
+		fedResource.RecordError("StatusAggregationFailed", errors.Wrap(err, "Failed to aggregate status"))
+	}
+
 	statusMap := dispatcher.StatusMap()
-	return s.setPropagationStatus(fedResource, status.AggregateSuccess, statusMap)
+	detailMap := dispatcher.DetailMap()
+	return s.setPropagationStatus(fedResource, status.AggregateSuccess, statusMap, detailMap, rolloutStatus, pendingDeletions, resourceStatus)
+}
+
+// resourceStatus computes the aggregated status and health of the
+// given resource from the target resources in the selected clusters
+// that are ready.  Clusters propagated to by other replicas of the
+// controller manager are included since every replica caches all
+// clusters.  Nil is returned if the status could not be computed.
+func (s *FederationSyncController) resourceStatus(fedResource FederatedResource, clusters []*fedv1a1.KubefedCluster,
+	selectedClusterNames sets.String) (*status.ResourceStatus, error) {
+
+	rules := s.typeConfig.GetStatusAggregation()
+	resourceStatus := &status.ResourceStatus{}
+	if s.healthChecker != nil {
+		resourceStatus.Health = make(map[string]health.Result)
+	}
+	clusterObjs := make(map[string]*unstructured.Unstructured)
+	key := fedResource.TargetName().String()
+	for _, cluster := range clusters {
+		if !selectedClusterNames.Has(cluster.Name) {
+			continue
+		}
+		if !util.IsClusterReady(&cluster.Status) {
+			if s.healthChecker != nil {
+				resourceStatus.Health[cluster.Name] = health.Result{Status: health.Unknown, Message: "Cluster not ready"}
+			}
+			continue
+		}
+		if len(rules) == 0 && s.healthChecker == nil {
+			continue
+		}
+		rawClusterObj, _, err := s.informer.GetTargetStore().GetByKey(cluster.Name, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve cached object for cluster %q", cluster.Name)
+		}
+		if rawClusterObj == nil {
+			if s.healthChecker != nil {
+				resourceStatus.Health[cluster.Name] = health.Result{Status: health.Unknown, Message: "Resource does not exist"}
+			}
+			continue
+		}
+		clusterObj := rawClusterObj.(*unstructured.Unstructured)
+		clusterObjs[cluster.Name] = clusterObj
+		if s.healthChecker != nil {
+			resourceStatus.Health[cluster.Name] = s.healthChecker.Check(clusterObj)
+		}
+	}
+	aggregatedStatus, err := status.AggregateClusterStatus(rules, clusterObjs)
+	if err != nil {
+		return nil, err
+	}
+	resourceStatus.Aggregated = aggregatedStatus
+	return resourceStatus, nil
+}
+
+// newRolloutCandidate determines whether the resource in the given
+// cluster is current and healthy.
+func newRolloutCandidate(fedResource FederatedResource, clusterName string, clusterObj *unstructured.Unstructured) (rolloutCandidate, error) {
+	version, err := fedResource.VersionForCluster(clusterName)
+	if err != nil {
+		return rolloutCandidate{}, err
+	}
+	return rolloutCandidate{
+		clusterName: clusterName,
+		clusterObj:  clusterObj,
+		current:     version != "" && version == util.ObjectVersion(clusterObj),
+		healthy:     clusterObjectHealthy(clusterObj),
+	}, nil
 }
 
 func (s *FederationSyncController) setPropagationStatus(fedResource FederatedResource,
-	reason status.AggregateReason, statusMap status.PropagationStatusMap) util.ReconciliationStatus {
+	reason status.AggregateReason, statusMap status.PropagationStatusMap, detailMap status.ClusterDetailMap,
+	rolloutStatus *status.RolloutStatus, pendingDeletions map[string]time.Time, resourceStatus *status.ResourceStatus) util.ReconciliationStatus {
 
 	kind := fedResource.FederatedKind()
 	name := fedResource.FederatedName()
@@ -393,11 +710,22 @@ func (s *FederationSyncController) setPropagationStatus(fedResource FederatedRes
 	// If the underlying resource has changed, attempt to retrieve and
 	// update it repeatedly.
 	err := wait.PollImmediate(1*time.Second, 5*time.Second, func() (bool, error) {
-		if err := status.SetPropagationStatus(obj, reason, statusMap); err != nil {
+		clusterStatusMap, clusterPendingDeletions, err := s.withUnownedClusterStatus(obj, statusMap, pendingDeletions)
+		if err != nil {
+			return false, err
+		}
+		changed, err := status.SetPropagationStatus(obj, reason, clusterStatusMap, detailMap, fedResource.PlacementStatus(), rolloutStatus, clusterPendingDeletions, resourceStatus)
+		if err != nil {
 			return false, errors.Wrapf(err, "failed to set the status")
 		}
+		if !changed {
+			// Avoid writing to the API when only the probe times of
+			// conditions or the remote resource versions of clusters
+			// would be updated.
+			return true, nil
+		}
 
-		err := s.hostClusterClient.UpdateStatus(context.TODO(), obj)
+		err = s.hostClusterClient.UpdateStatus(context.TODO(), obj)
 		if err == nil {
 			return true, nil
 		}
@@ -419,6 +747,45 @@ func (s *FederationSyncController) setPropagationStatus(fedResource FederatedRes
 	return util.StatusAllOK
 }
 
+// withUnownedClusterStatus returns the given cluster status and
+// pending deletions with the addition of those last recorded in the
+// status of the given object for clusters that are propagated to by
+// other replicas of the controller manager.
+func (s *FederationSyncController) withUnownedClusterStatus(obj *unstructured.Unstructured, statusMap status.PropagationStatusMap,
+	pendingDeletions map[string]time.Time) (status.PropagationStatusMap, map[string]time.Time, error) {
+
+	if statusMap == nil || !s.sharding.Sharded() || s.sharding.Mode != fedv1a1.ShardByCluster {
+		return statusMap, pendingDeletions, nil
+	}
+	recordedStatusMap, err := status.GetClusterStatus(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordedDeletions, err := status.GetPendingDeletions(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergedStatusMap := make(status.PropagationStatusMap)
+	for clusterName, value := range recordedStatusMap {
+		if !s.sharding.OwnsCluster(clusterName) {
+			mergedStatusMap[clusterName] = value
+		}
+	}
+	for clusterName, value := range statusMap {
+		mergedStatusMap[clusterName] = value
+	}
+	mergedDeletions := make(map[string]time.Time)
+	for clusterName, deleteAfter := range recordedDeletions {
+		if !s.sharding.OwnsCluster(clusterName) {
+			mergedDeletions[clusterName] = deleteAfter
+		}
+	}
+	for clusterName, deleteAfter := range pendingDeletions {
+		mergedDeletions[clusterName] = deleteAfter
+	}
+	return mergedStatusMap, mergedDeletions, nil
+}
+
 func (s *FederationSyncController) ensureDeletion(fedResource FederatedResource) util.ReconciliationStatus {
 	fedResource.DeleteVersions()
 
@@ -435,23 +802,49 @@ func (s *FederationSyncController) ensureDeletion(fedResource FederatedResource)
 		return util.StatusAllOK
 	}
 
+	// The orphan annotation takes precedence over the deletion
+	// policy.  The grace period of the DelayedDelete policy only
+	// applies to clusters that are no longer selected, and managed
+	// resources are deleted immediately when the federated resource
+	// is deleted.
 	annotations := obj.GetAnnotations()
 	orphanResources := annotations != nil && annotations[OrphanManagedResources] == "true"
 	if orphanResources {
 		klog.V(2).Infof("Found %q annotation on %s %q. Removing the finalizer.", OrphanManagedResources, kind, key)
-		err := s.removeFinalizer(fedResource)
+	} else {
+		deletionPolicy, _, err := fedResource.DeletionPolicy()
 		if err != nil {
-			wrappedErr := errors.Wrapf(err, "failed to remove finalizer %q from %s %q", OrphanManagedResources, kind, key)
-			runtime.HandleError(wrappedErr)
+			runtime.HandleError(errors.Wrapf(err, "failed to read the deletion policy of %s %q", kind, key))
 			return util.StatusError
 		}
+		orphanResources = deletionPolicy == fedv1a1.DeletionPolicyOrphan
+		if orphanResources {
+			klog.V(2).Infof("%s %q has deletion policy %q. Removing the finalizer.", kind, key, deletionPolicy)
+		}
+	}
+	if orphanResources {
 		klog.V(2).Infof("Initiating the removal of the label %q from resources previously managed by %s %q.", util.ManagedByFederationLabelKey, kind, key)
-		err = s.removeManagedLabel(fedResource.TargetKind(), fedResource.TargetName())
+		remainingClusters, err := s.removeManagedLabel(fedResource.TargetKind(), fedResource.TargetName())
 		if err != nil {
 			wrappedErr := errors.Wrapf(err, "failed to remove the label %q from all resources previously managed by %s %q", util.ManagedByFederationLabelKey, kind, key)
 			runtime.HandleError(wrappedErr)
 			return util.StatusError
 		}
+		// The finalizer is only removed once the label has been
+		// removed in every cluster, since the replicas responsible
+		// for other clusters will not observe the federated resource
+		// once it has been deleted.
+		if len(remainingClusters) > 0 {
+			klog.V(2).Infof("Waiting for the label %q to be removed from resources previously managed by %s %q in the following clusters: %s",
+				util.ManagedByFederationLabelKey, kind, key, strings.Join(remainingClusters, ", "))
+			return util.StatusNeedsRecheck
+		}
+		err = s.removeFinalizer(fedResource)
+		if err != nil {
+			wrappedErr := errors.Wrapf(err, "failed to remove finalizer %q from %s %q", FinalizerSyncController, kind, key)
+			runtime.HandleError(wrappedErr)
+			return util.StatusError
+		}
 		return util.StatusAllOK
 	}
 
@@ -469,22 +862,32 @@ func (s *FederationSyncController) ensureDeletion(fedResource FederatedResource)
 }
 
 // removeManagedLabel attempts to remove the managed label from
-// resources with the given name in member clusters.
-func (s *FederationSyncController) removeManagedLabel(kind string, qualifiedName util.QualifiedName) error {
+// resources with the given name in member clusters.  The names of the
+// clusters in which resources were still labeled are returned.
+func (s *FederationSyncController) removeManagedLabel(kind string, qualifiedName util.QualifiedName) ([]string, error) {
+	remainingClusters := []string{}
 	ok, err := s.handleDeletionInClusters(kind, qualifiedName, func(dispatcher dispatch.UnmanagedDispatcher, clusterName string, clusterObj *unstructured.Unstructured) {
 		if clusterObj.GetDeletionTimestamp() != nil {
 			return
 		}
 
+		remainingClusters = append(remainingClusters, clusterName)
+
+		// Leave the removal of the label from resources in clusters
+		// owned by other replicas to those replicas.
+		if !s.sharding.OwnsCluster(clusterName) {
+			return
+		}
+
 		dispatcher.RemoveManagedLabel(clusterName, clusterObj)
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !ok {
-		return errors.Errorf("failed to remove the label from resources in one or more clusters.")
+		return nil, errors.Errorf("failed to remove the label from resources in one or more clusters.")
 	}
-	return nil
+	return remainingClusters, nil
 }
 
 func (s *FederationSyncController) deleteFromClusters(fedResource FederatedResource) (bool, error) {
@@ -504,8 +907,10 @@ func (s *FederationSyncController) deleteFromClusters(fedResource FederatedResou
 
 		remainingClusters = append(remainingClusters, clusterName)
 
-		// Avoid attempting any operation on a deleted resource.
-		if clusterObj.GetDeletionTimestamp() != nil {
+		// Avoid attempting any operation on a deleted resource, and
+		// leave the removal of resources from clusters owned by
+		// other replicas to those replicas.
+		if clusterObj.GetDeletionTimestamp() != nil || !s.sharding.OwnsCluster(clusterName) {
 			return
 		}
 
@@ -515,6 +920,10 @@ func (s *FederationSyncController) deleteFromClusters(fedResource FederatedResou
 			// Removing the managed label will ensure a host cluster
 			// namespace is no longer cached.
 			dispatcher.RemoveManagedLabel(clusterName, clusterObj)
+		} else if util.IsAdopted(clusterObj) {
+			// A resource that kubefed did not create is returned to
+			// its unmanaged state rather than deleted.
+			dispatcher.RemoveManagedLabel(clusterName, clusterObj)
 		} else {
 			dispatcher.Delete(clusterName)
 		}
@@ -550,7 +959,7 @@ func (s *FederationSyncController) ensureRemovedOrUnmanaged(fedResource Federate
 		return errors.Wrap(err, "failed to get a list of clusters")
 	}
 
-	dispatcher := dispatch.NewCheckUnmanagedDispatcher(s.informer.GetClientForCluster, fedResource.TargetKind(), fedResource.TargetName())
+	dispatcher := dispatch.NewCheckUnmanagedDispatcher(s.informer.GetClientForClusterWithContext, fedResource.TargetKind(), fedResource.TargetName(), s.dispatchConfig())
 	unreadyClusters := []string{}
 	for _, cluster := range clusters {
 		if !util.IsClusterReady(&cluster.Status) {
@@ -582,7 +991,7 @@ func (s *FederationSyncController) handleDeletionInClusters(kind string, qualifi
 		return false, errors.Wrap(err, "failed to get a list of clusters")
 	}
 
-	dispatcher := dispatch.NewUnmanagedDispatcher(s.informer.GetClientForCluster, kind, qualifiedName)
+	dispatcher := dispatch.NewUnmanagedDispatcher(s.informer.GetClientForClusterWithContext, kind, qualifiedName, s.dispatchConfig())
 	key := qualifiedName.String()
 	retrievalFailureClusters := []string{}
 	unreadyClusters := []string{}
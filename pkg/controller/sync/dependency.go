@@ -0,0 +1,197 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// dependencyAccessor determines the member clusters to which the
+// dependencies of a federated resource have been propagated.
+//
+// Dependencies are propagated by the sync controllers of other types,
+// so the federated resources of a type are watched once a dependency
+// on the type is first encountered, and a federated resource waiting
+// for its dependencies is periodically reconciled until they have
+// been propagated.
+type dependencyAccessor struct {
+	config          *rest.Config
+	targetNamespace string
+
+	typeConfigStore      cache.Store
+	typeConfigController cache.Controller
+
+	sync.Mutex
+	stopChan <-chan struct{}
+	// Stores of federated resources, keyed by federated type name.
+	resourceStores map[string]cache.Store
+	// Indicates whether the stores of federated resources have
+	// synced, keyed by federated type name.
+	resourceSynced map[string]cache.InformerSynced
+}
+
+func newDependencyAccessor(controllerConfig *util.ControllerConfig) (*dependencyAccessor, error) {
+	typeConfigStore, typeConfigController, err := util.NewGenericInformer(
+		controllerConfig.KubeConfig,
+		controllerConfig.KubefedNamespace,
+		&fedv1a1.FederatedTypeConfig{},
+		util.NoResyncPeriod,
+		func(pkgruntime.Object) {},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create informer for FederatedTypeConfigs")
+	}
+	return &dependencyAccessor{
+		config:               controllerConfig.KubeConfig,
+		targetNamespace:      controllerConfig.TargetNamespace,
+		typeConfigStore:      typeConfigStore,
+		typeConfigController: typeConfigController,
+		resourceStores:       make(map[string]cache.Store),
+		resourceSynced:       make(map[string]cache.InformerSynced),
+	}, nil
+}
+
+// Run starts the informer for type configs.  Informers for federated
+// types are started on demand and stopped with the given channel.
+func (a *dependencyAccessor) Run(stopChan <-chan struct{}) {
+	a.Lock()
+	a.stopChan = stopChan
+	a.Unlock()
+	go a.typeConfigController.Run(stopChan)
+}
+
+// PropagatedClusters returns the clusters to which each of the given
+// dependencies has been propagated.  Inferred dependencies that are
+// not federated are omitted, and declared dependencies that are not
+// federated are not propagated to any cluster.  Until the federated
+// resources of a type have been cached, dependencies of the type are
+// not propagated to any cluster.
+func (a *dependencyAccessor) PropagatedClusters(dependencies []util.Dependency) (map[util.Dependency]sets.String, error) {
+	propagatedClusters := make(map[util.Dependency]sets.String)
+	if !a.typeConfigController.HasSynced() {
+		for _, dependency := range dependencies {
+			propagatedClusters[dependency] = sets.NewString()
+		}
+		return propagatedClusters, nil
+	}
+
+	typeConfigs := []*fedv1a1.FederatedTypeConfig{}
+	for _, obj := range a.typeConfigStore.List() {
+		typeConfigs = append(typeConfigs, obj.(*fedv1a1.FederatedTypeConfig))
+	}
+
+	for _, dependency := range dependencies {
+		typeConfig := typeConfigForDependency(typeConfigs, dependency)
+		if typeConfig == nil {
+			if !dependency.Inferred {
+				propagatedClusters[dependency] = sets.NewString()
+			}
+			continue
+		}
+
+		store, synced, err := a.resourceStore(typeConfig)
+		if err != nil {
+			return nil, err
+		}
+		if !synced() {
+			propagatedClusters[dependency] = sets.NewString()
+			continue
+		}
+		fedName := util.QualifiedName{Name: dependency.Name}
+		if dependency.Kind == util.NamespaceKind {
+			// A federated namespace is contained by the namespace it
+			// propagates.
+			fedName.Namespace = dependency.Name
+		} else if typeConfig.GetNamespaced() {
+			fedName.Namespace = dependency.Namespace
+		}
+		obj, exists, err := store.GetByKey(fedName.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve %s %q from the cache", typeConfig.GetFederatedType().Kind, fedName)
+		}
+		if !exists {
+			if !dependency.Inferred {
+				propagatedClusters[dependency] = sets.NewString()
+			}
+			continue
+		}
+		clusters, err := status.GetPropagatedClusters(obj.(*unstructured.Unstructured))
+		if err != nil {
+			return nil, err
+		}
+		propagatedClusters[dependency] = clusters
+	}
+	return propagatedClusters, nil
+}
+
+// resourceStore returns the store of the federated resources of the
+// given type config, starting an informer for them if necessary.
+func (a *dependencyAccessor) resourceStore(typeConfig *fedv1a1.FederatedTypeConfig) (cache.Store, cache.InformerSynced, error) {
+	a.Lock()
+	defer a.Unlock()
+	store, ok := a.resourceStores[typeConfig.Name]
+	if ok {
+		return store, a.resourceSynced[typeConfig.Name], nil
+	}
+	apiResource := typeConfig.GetFederatedType()
+	client, err := util.NewResourceClient(a.config, &apiResource)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to create client for %s", apiResource.Kind)
+	}
+	store, controller := util.NewResourceInformer(client, a.targetNamespace, func(pkgruntime.Object) {})
+	go controller.Run(a.stopChan)
+	a.resourceStores[typeConfig.Name] = store
+	a.resourceSynced[typeConfig.Name] = controller.HasSynced
+	return store, controller.HasSynced, nil
+}
+
+// typeConfigForDependency returns the type config whose target type
+// is that of the dependency, or nil if the type is not federated.
+func typeConfigForDependency(typeConfigs []*fedv1a1.FederatedTypeConfig, dependency util.Dependency) *fedv1a1.FederatedTypeConfig {
+	for _, typeConfig := range typeConfigs {
+		target := typeConfig.GetTarget()
+		if target.Kind == dependency.Kind && target.Group == dependency.Group {
+			return typeConfig
+		}
+	}
+	return nil
+}
+
+// unpropagatedDependencies returns the dependencies that have not been
+// propagated to the named cluster.
+func unpropagatedDependencies(dependencies []util.Dependency, propagatedClusters map[util.Dependency]sets.String, clusterName string) []util.Dependency {
+	unpropagated := []util.Dependency{}
+	for _, dependency := range dependencies {
+		clusters, ok := propagatedClusters[dependency]
+		if ok && !clusters.Has(clusterName) {
+			unpropagated = append(unpropagated, dependency)
+		}
+	}
+	return unpropagated
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// ObjectForApply returns the object to write to a member cluster with
+// server-side apply, given the desired object before field retention.
+// Only fields rendered by kubefed are included so that kubefed does
+// not take ownership of fields retained from the member cluster.
+func ObjectForApply(desiredObj, fedObj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj := desiredObj.DeepCopy()
+	// Optimistic concurrency is not required for an apply.
+	unstructured.RemoveNestedField(obj.Object, util.MetadataField, "resourceVersion")
+
+	// Replicas managed by HPA in the member cluster must not be owned
+	// by kubefed.
+	retainReplicas, ok, err := unstructured.NestedBool(fedObj.Object, util.SpecField, util.RetainReplicasField)
+	if err != nil {
+		return nil, err
+	}
+	if ok && retainReplicas {
+		unstructured.RemoveNestedField(obj.Object, util.SpecField, util.ReplicasField)
+	}
+	return obj, nil
+}
+
+// RetainUnownedMetadata adds to the desired object the labels and
+// annotations of the cluster object that the desired object does not
+// specify.  When resources are written with server-side apply these
+// are owned by other actors and should not be considered a
+// difference from the desired state.
+func RetainUnownedMetadata(desiredObj, clusterObj *unstructured.Unstructured) {
+	desiredObj.SetLabels(mergeUnowned(desiredObj.GetLabels(), clusterObj.GetLabels()))
+	desiredObj.SetAnnotations(mergeUnowned(desiredObj.GetAnnotations(), clusterObj.GetAnnotations()))
+}
+
+func mergeUnowned(desired, cluster map[string]string) map[string]string {
+	if len(cluster) == 0 {
+		return desired
+	}
+	merged := make(map[string]string)
+	for key, value := range cluster {
+		merged[key] = value
+	}
+	for key, value := range desired {
+		merged[key] = value
+	}
+	return merged
+}
+
+// AppliedByFieldManager returns whether the given field manager has
+// written the given cluster object with server-side apply.
+func AppliedByFieldManager(clusterObj *unstructured.Unstructured, fieldManager string) bool {
+	managedFields, _, _ := unstructured.NestedSlice(clusterObj.Object, util.MetadataField, "managedFields")
+	for _, entry := range managedFields {
+		fields, ok := entry.(map[string]interface{})
+		if ok && fields["manager"] == fieldManager && fields["operation"] == "Apply" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func TestObjectForApply(t *testing.T) {
+	testCases := map[string]struct {
+		retainReplicas   bool
+		expectedReplicas bool
+	}{
+		"replicas applied when retainReplicas=false": {
+			retainReplicas:   false,
+			expectedReplicas: true,
+		},
+		"replicas not applied when retainReplicas=true": {
+			retainReplicas:   true,
+			expectedReplicas: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			desiredObj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":            "foo",
+						"resourceVersion": "42",
+					},
+					"spec": map[string]interface{}{
+						"replicas": int64(1),
+					},
+				},
+			}
+			fedObj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"retainReplicas": testCase.retainReplicas,
+					},
+				},
+			}
+			obj, err := ObjectForApply(desiredObj, fedObj)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if obj.GetResourceVersion() != "" {
+				t.Fatalf("Expected the resource version to be removed")
+			}
+			_, ok, _ := unstructured.NestedInt64(obj.Object, util.SpecField, util.ReplicasField)
+			if ok != testCase.expectedReplicas {
+				t.Fatalf("Expected replicas present=%v, got %v", testCase.expectedReplicas, ok)
+			}
+			if desiredObj.GetResourceVersion() != "42" {
+				t.Fatalf("Expected the desired object to be unmodified")
+			}
+		})
+	}
+}
+
+func TestRetainUnownedMetadata(t *testing.T) {
+	desiredObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	desiredObj.SetLabels(map[string]string{"app": "desired"})
+	clusterObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	clusterObj.SetLabels(map[string]string{"app": "cluster", "team": "local"})
+	clusterObj.SetAnnotations(map[string]string{"sidecar.istio.io/status": "injected"})
+
+	RetainUnownedMetadata(desiredObj, clusterObj)
+
+	expectedLabels := map[string]string{"app": "desired", "team": "local"}
+	if !reflect.DeepEqual(desiredObj.GetLabels(), expectedLabels) {
+		t.Fatalf("Expected labels %v, got %v", expectedLabels, desiredObj.GetLabels())
+	}
+	if !reflect.DeepEqual(desiredObj.GetAnnotations(), clusterObj.GetAnnotations()) {
+		t.Fatalf("Expected annotations %v, got %v", clusterObj.GetAnnotations(), desiredObj.GetAnnotations())
+	}
+}
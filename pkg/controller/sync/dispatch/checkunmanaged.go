@@ -43,8 +43,8 @@ type checkUnmanagedDispatcherImpl struct {
 	targetKind string
 }
 
-func NewCheckUnmanagedDispatcher(clientAccessor clientAccessorFunc, targetKind string, targetName util.QualifiedName) CheckUnmanagedDispatcher {
-	dispatcher := newOperationDispatcher(clientAccessor, nil)
+func NewCheckUnmanagedDispatcher(clientAccessor clientAccessorFunc, targetKind string, targetName util.QualifiedName, config DispatchConfig) CheckUnmanagedDispatcher {
+	dispatcher := newOperationDispatcher(clientAccessor, nil, targetKind, config)
 	return &checkUnmanagedDispatcherImpl{
 		dispatcher: dispatcher,
 		targetName: targetName,
@@ -20,16 +20,20 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 // FederatedResourceForDispatch is the subset of the FederatedResource
@@ -40,6 +44,10 @@ type FederatedResourceForDispatch interface {
 	Object() *unstructured.Unstructured
 	VersionForCluster(clusterName string) (string, error)
 	ObjectForCluster(clusterName string) (*unstructured.Unstructured, error)
+	RetainFields() []fedv1a1.RetainField
+	ServerSideApply() *fedv1a1.ServerSideApply
+	FederatedKind() string
+	FederatedName() util.QualifiedName
 	RecordError(errorCode string, err error)
 	RecordEvent(reason, messageFmt string, args ...interface{})
 }
@@ -53,6 +61,7 @@ type ManagedDispatcher interface {
 	Update(clusterName string, clusterObj *unstructured.Unstructured)
 	VersionMap() map[string]string
 	StatusMap() status.PropagationStatusMap
+	DetailMap() status.ClusterDetailMap
 
 	RecordClusterError(propStatus status.PropagationStatus, clusterName string, err error)
 	RecordStatus(clusterName string, propStatus status.PropagationStatus)
@@ -61,22 +70,24 @@ type ManagedDispatcher interface {
 type managedDispatcherImpl struct {
 	sync.RWMutex
 
-	dispatcher            *operationDispatcherImpl
-	unmanagedDispatcher   *unmanagedDispatcherImpl
-	fedResource           FederatedResourceForDispatch
-	versionMap            map[string]string
-	statusMap             status.PropagationStatusMap
-	skipAdoptingResources bool
+	dispatcher          *operationDispatcherImpl
+	unmanagedDispatcher *unmanagedDispatcherImpl
+	fedResource         FederatedResourceForDispatch
+	versionMap          map[string]string
+	statusMap           status.PropagationStatusMap
+	detailMap           status.ClusterDetailMap
+	adoptionPolicy      fedv1a1.AdoptionPolicy
 }
 
-func NewManagedDispatcher(clientAccessor clientAccessorFunc, fedResource FederatedResourceForDispatch, skipAdoptingResources bool) ManagedDispatcher {
+func NewManagedDispatcher(clientAccessor clientAccessorFunc, fedResource FederatedResourceForDispatch, adoptionPolicy fedv1a1.AdoptionPolicy, config DispatchConfig) ManagedDispatcher {
 	d := &managedDispatcherImpl{
-		fedResource:           fedResource,
-		versionMap:            make(map[string]string),
-		statusMap:             make(status.PropagationStatusMap),
-		skipAdoptingResources: skipAdoptingResources,
+		fedResource:    fedResource,
+		versionMap:     make(map[string]string),
+		statusMap:      make(status.PropagationStatusMap),
+		detailMap:      make(status.ClusterDetailMap),
+		adoptionPolicy: adoptionPolicy,
 	}
-	d.dispatcher = newOperationDispatcher(clientAccessor, d)
+	d.dispatcher = newOperationDispatcher(clientAccessor, d, fedResource.TargetKind(), config)
 	d.unmanagedDispatcher = newUnmanagedDispatcher(d.dispatcher, d, fedResource.TargetKind(), fedResource.TargetName())
 	return d
 }
@@ -130,10 +141,18 @@ func (d *managedDispatcherImpl) Create(clusterName string) {
 		if err != nil {
 			return d.recordOperationError(status.ComputeResourceFailed, clusterName, op, err)
 		}
-		createdObj, err := client.Resources(obj.GetNamespace()).Create(obj, metav1.CreateOptions{})
+		var createdObj *unstructured.Unstructured
+		if d.fedResource.ServerSideApply() != nil {
+			// Fields are owned by the field manager of kubefed from
+			// creation onwards.
+			createdObj, err = client.Create(obj, util.FieldManager)
+		} else {
+			createdObj, err = client.Resources(obj.GetNamespace()).Create(obj, metav1.CreateOptions{})
+		}
 		if err == nil {
 			version := util.ObjectVersion(createdObj)
 			d.recordVersion(clusterName, version)
+			d.recordClusterObject(clusterName, createdObj)
 			return util.StatusAllOK
 		}
 
@@ -144,8 +163,8 @@ func (d *managedDispatcherImpl) Create(clusterName string) {
 			return d.recordOperationError(status.CreationFailed, clusterName, op, err)
 		}
 
-		if d.skipAdoptingResources {
-			_ = d.recordOperationError(status.AlreadyExists, clusterName, op, errors.Errorf("Resource pre-exist in cluster"))
+		if d.adoptionPolicy == fedv1a1.AdoptionPolicyNever {
+			_ = d.recordOperationError(status.AlreadyExists, clusterName, op, errors.Errorf("Resource pre-exist in cluster and the adoption policy is %s", d.adoptionPolicy))
 			return util.StatusAllOK
 		}
 
@@ -156,14 +175,31 @@ func (d *managedDispatcherImpl) Create(clusterName string) {
 			wrappedErr := errors.Wrapf(err, "failed to retrieve object potentially requiring adoption")
 			return d.recordOperationError(status.RetrievalFailed, clusterName, op, wrappedErr)
 		}
+		err = RetainClusterFields(d.fedResource.RetainFields(), obj, clusterObj, d.fedResource.Object())
+		if err != nil {
+			wrappedErr := errors.Wrapf(err, "failed to retain fields")
+			return d.recordOperationError(status.FieldRetentionFailed, clusterName, op, wrappedErr)
+		}
+		if allowed, reason := util.AdoptionAllowed(d.adoptionPolicy, obj, clusterObj); !allowed {
+			_ = d.recordOperationError(status.AlreadyExists, clusterName, op, errors.Errorf("Resource pre-exist in cluster and cannot be adopted because %s", reason))
+			return util.StatusAllOK
+		}
 		d.recordError(clusterName, op, errors.Errorf("An update will be attempted instead of a creation due to an existing resource"))
-		d.Update(clusterName, clusterObj)
+		d.update(clusterName, clusterObj, true)
 		return util.StatusAllOK
 	})
 }
 
 func (d *managedDispatcherImpl) Update(clusterName string, clusterObj *unstructured.Unstructured) {
+	d.update(clusterName, clusterObj, false)
+}
+
+// update updates the given resource in a member cluster.  If adopting
+// is true, the resource is not yet managed and the adoption is
+// recorded on the resource.
+func (d *managedDispatcherImpl) update(clusterName string, clusterObj *unstructured.Unstructured, adopting bool) {
 	d.RecordStatus(clusterName, status.UpdateTimedOut)
+	d.recordClusterObject(clusterName, clusterObj)
 
 	d.dispatcher.incrementOperationsInitiated()
 	const op = "update"
@@ -173,11 +209,36 @@ func (d *managedDispatcherImpl) Update(clusterName string, clusterObj *unstructu
 			return d.recordOperationError(status.ComputeResourceFailed, clusterName, op, err)
 		}
 
-		err = RetainClusterFields(d.fedResource.TargetKind(), obj, clusterObj, d.fedResource.Object())
+		serverSideApply := d.fedResource.ServerSideApply()
+		var applyObj *unstructured.Unstructured
+		if serverSideApply != nil {
+			applyObj, err = ObjectForApply(obj, d.fedResource.Object())
+			if err != nil {
+				return d.recordOperationError(status.ComputeResourceFailed, clusterName, op, err)
+			}
+		}
+
+		err = RetainClusterFields(d.fedResource.RetainFields(), obj, clusterObj, d.fedResource.Object())
 		if err != nil {
 			wrappedErr := errors.Wrapf(err, "failed to retain fields")
 			return d.recordOperationError(status.FieldRetentionFailed, clusterName, op, wrappedErr)
 		}
+		if serverSideApply != nil {
+			RetainUnownedMetadata(obj, clusterObj)
+		}
+		if applyObj != nil {
+			// Adoption annotations are written by the field manager
+			// and would otherwise be removed by the next apply.
+			util.RetainAdoptionAnnotations(applyObj, clusterObj)
+		}
+		if adopting {
+			adoptedBy := fmt.Sprintf("%s %s", d.fedResource.FederatedKind(), d.fedResource.FederatedName())
+			adoptedAt := time.Now()
+			util.AddAdoptionAnnotations(obj, adoptedAt, adoptedBy)
+			if applyObj != nil {
+				util.AddAdoptionAnnotations(applyObj, adoptedAt, adoptedBy)
+			}
+		}
 
 		version, err := d.fedResource.VersionForCluster(clusterName)
 		if err != nil {
@@ -188,15 +249,51 @@ func (d *managedDispatcherImpl) Update(clusterName string, clusterObj *unstructu
 			return util.StatusAllOK
 		}
 
+		// A recorded version indicates that the federated resource
+		// has not changed since the resource was last written to the
+		// cluster, so any difference was made in the cluster.
+		if len(version) > 0 {
+			driftedFields := util.DriftedFields(obj, clusterObj)
+			if len(driftedFields) > 0 {
+				driftPolicy, err := util.GetDriftPolicy(d.fedResource.Object())
+				if err != nil {
+					return d.recordOperationError(status.ComputeResourceFailed, clusterName, op, err)
+				}
+				d.recordDrift(clusterName, driftedFields, driftPolicy)
+				d.RecordStatus(clusterName, status.Drifted)
+				if driftPolicy == util.DriftPolicyReport {
+					return util.StatusAllOK
+				}
+			}
+		}
+
 		// Only record an event if the resource is not current
 		d.recordEvent(clusterName, op, "Updating")
 
-		updatedObj, err := client.Resources(obj.GetNamespace()).Update(obj, metav1.UpdateOptions{})
+		var updatedObj *unstructured.Unstructured
+		if serverSideApply != nil {
+			// Fields of a resource that kubefed has not yet applied
+			// are owned by the creator of the resource, or by
+			// kubefed as a creator rather than an applier, and are
+			// taken over by the first apply.
+			force := serverSideApply.Force || !AppliedByFieldManager(clusterObj, util.FieldManager)
+			updatedObj, err = client.Apply(applyObj, util.FieldManager, force)
+			if apierrors.IsConflict(err) {
+				wrappedErr := errors.Wrapf(err, "fields of the resource are managed by another actor")
+				return d.recordOperationError(status.ApplyConflict, clusterName, op, wrappedErr)
+			}
+		} else {
+			updatedObj, err = client.Resources(obj.GetNamespace()).Update(obj, metav1.UpdateOptions{})
+		}
 		if err != nil {
 			return d.recordOperationError(status.UpdateFailed, clusterName, op, err)
 		}
+		if adopting {
+			d.recordEvent(clusterName, "adopt", "Adopted")
+		}
 		version = util.ObjectVersion(updatedObj)
 		d.recordVersion(clusterName, version)
+		d.recordClusterObject(clusterName, updatedObj)
 		return util.StatusAllOK
 	})
 }
@@ -216,6 +313,8 @@ func (d *managedDispatcherImpl) RemoveManagedLabel(clusterName string, clusterOb
 func (d *managedDispatcherImpl) RecordClusterError(propStatus status.PropagationStatus, clusterName string, err error) {
 	d.fedResource.RecordError(string(propStatus), err)
 	d.RecordStatus(clusterName, propStatus)
+	d.recordMessage(clusterName, err.Error())
+	metrics.DispatchError(d.fedResource.TargetKind(), clusterName, string(propStatus))
 }
 
 func (d *managedDispatcherImpl) RecordStatus(clusterName string, propStatus status.PropagationStatus) {
@@ -225,8 +324,16 @@ func (d *managedDispatcherImpl) RecordStatus(clusterName string, propStatus stat
 }
 
 func (d *managedDispatcherImpl) recordOperationError(propStatus status.PropagationStatus, clusterName, operation string, err error) util.ReconciliationStatus {
+	if d.dispatcher.cancelled() {
+		// The error is likely the result of cancellation on timeout,
+		// and the cluster has already been assigned a timed out
+		// status.
+		return util.StatusError
+	}
 	d.recordError(clusterName, operation, err)
 	d.RecordStatus(clusterName, propStatus)
+	d.recordMessage(clusterName, err.Error())
+	metrics.DispatchError(d.fedResource.TargetKind(), clusterName, string(propStatus))
 	return util.StatusError
 }
 
@@ -242,6 +349,25 @@ func (d *managedDispatcherImpl) recordEvent(clusterName, operation, operationCon
 	d.fedResource.RecordEvent(eventType, eventTemplate, args...)
 }
 
+// recordDrift records an event for drift of the resource in the
+// given cluster unless the drift was already reported by the last
+// recorded status of the cluster.
+func (d *managedDispatcherImpl) recordDrift(clusterName string, driftedFields []string, driftPolicy util.DriftPolicy) {
+	previousStatusMap, err := status.GetClusterStatus(d.fedResource.Object())
+	if err != nil {
+		runtime.HandleError(err)
+	} else if previousStatusMap[clusterName] == status.Drifted {
+		return
+	}
+	action := "overwriting"
+	if driftPolicy == util.DriftPolicyReport {
+		action = "not overwriting due to drift policy Report"
+	}
+	err = errors.Errorf("Fields %s of %s %q in cluster %q differ from the desired state, %s",
+		strings.Join(driftedFields, ", "), d.fedResource.TargetKind(), d.fedResource.TargetName(), clusterName, action)
+	d.fedResource.RecordError("DriftDetectedInCluster", err)
+}
+
 func (d *managedDispatcherImpl) VersionMap() map[string]string {
 	d.RLock()
 	defer d.RUnlock()
@@ -267,3 +393,34 @@ func (d *managedDispatcherImpl) StatusMap() status.PropagationStatusMap {
 	}
 	return statusMap
 }
+
+func (d *managedDispatcherImpl) DetailMap() status.ClusterDetailMap {
+	d.RLock()
+	defer d.RUnlock()
+	detailMap := make(status.ClusterDetailMap)
+	for key, value := range d.detailMap {
+		detailMap[key] = value
+	}
+	return detailMap
+}
+
+// recordMessage records the error message of the last operation in
+// the given cluster.
+func (d *managedDispatcherImpl) recordMessage(clusterName, message string) {
+	d.Lock()
+	defer d.Unlock()
+	detail := d.detailMap[clusterName]
+	detail.Message = message
+	d.detailMap[clusterName] = detail
+}
+
+// recordClusterObject records the resource version and generation of
+// the resource in the given cluster.
+func (d *managedDispatcherImpl) recordClusterObject(clusterName string, clusterObj *unstructured.Unstructured) {
+	d.Lock()
+	defer d.Unlock()
+	detail := d.detailMap[clusterName]
+	detail.RemoteResourceVersion = clusterObj.GetResourceVersion()
+	detail.RemoteGeneration = clusterObj.GetGeneration()
+	d.detailMap[clusterName] = detail
+}
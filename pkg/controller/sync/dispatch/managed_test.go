@@ -0,0 +1,278 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// applyClient records the writes of a managed dispatcher that uses
+// server-side apply.
+type applyClient struct {
+	sync.Mutex
+	createFieldManagers []string
+	applyForces         []bool
+}
+
+func (c *applyClient) Resources(namespace string) dynamic.ResourceInterface {
+	return nil
+}
+
+func (c *applyClient) Kind() string {
+	return "ConfigMap"
+}
+
+func (c *applyClient) Create(obj *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.createFieldManagers = append(c.createFieldManagers, fieldManager)
+	return writtenObject(obj, fieldManager, "Update", "1"), nil
+}
+
+func (c *applyClient) Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.applyForces = append(c.applyForces, force)
+	return writtenObject(obj, fieldManager, "Apply", "2"), nil
+}
+
+func writtenObject(obj *unstructured.Unstructured, fieldManager, operation, resourceVersion string) *unstructured.Unstructured {
+	writtenObj := obj.DeepCopy()
+	writtenObj.SetResourceVersion(resourceVersion)
+	_ = unstructured.SetNestedSlice(writtenObj.Object, []interface{}{
+		map[string]interface{}{
+			"manager":   fieldManager,
+			"operation": operation,
+		},
+	}, util.MetadataField, "managedFields")
+	return writtenObj
+}
+
+// applyResource is a federated resource propagated with server-side
+// apply.
+type applyResource struct {
+	obj *unstructured.Unstructured
+}
+
+func (r *applyResource) TargetName() util.QualifiedName {
+	return util.NewQualifiedName(r.obj)
+}
+
+func (r *applyResource) TargetKind() string {
+	return r.obj.GetKind()
+}
+
+func (r *applyResource) Object() *unstructured.Unstructured {
+	return r.obj
+}
+
+func (r *applyResource) VersionForCluster(clusterName string) (string, error) {
+	return "", nil
+}
+
+func (r *applyResource) ObjectForCluster(clusterName string) (*unstructured.Unstructured, error) {
+	return r.obj.DeepCopy(), nil
+}
+
+func (r *applyResource) RetainFields() []fedv1a1.RetainField {
+	return nil
+}
+
+func (r *applyResource) ServerSideApply() *fedv1a1.ServerSideApply {
+	return &fedv1a1.ServerSideApply{}
+}
+
+func (r *applyResource) FederatedKind() string {
+	return "FederatedConfigMap"
+}
+
+func (r *applyResource) FederatedName() util.QualifiedName {
+	return util.NewQualifiedName(r.obj)
+}
+
+func (r *applyResource) RecordError(errorCode string, err error) {}
+
+func (r *applyResource) RecordEvent(reason, messageFmt string, args ...interface{}) {}
+
+func TestManagedDispatcherCreateThenApply(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("ns")
+	obj.SetName("foo")
+	util.AddManagedLabel(obj)
+	fedResource := &applyResource{obj: obj}
+
+	client := &applyClient{}
+	clientAccessor := func(ctx context.Context, clusterName string) (util.ResourceClient, error) {
+		return client, nil
+	}
+	config := DispatchConfig{Timeout: 10 * time.Second}
+	dispatch := func(operation func(d ManagedDispatcher)) {
+		d := NewManagedDispatcher(clientAccessor, fedResource, fedv1a1.AdoptionPolicyNever, config)
+		operation(d)
+		ok, err := d.Wait()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !ok {
+			t.Fatalf("Expected the operation to succeed")
+		}
+	}
+
+	dispatch(func(d ManagedDispatcher) {
+		d.Create("c1")
+	})
+	if !reflect.DeepEqual(client.createFieldManagers, []string{util.FieldManager}) {
+		t.Fatalf("Expected a creation by field manager %q, got: %v", util.FieldManager, client.createFieldManagers)
+	}
+
+	// The first apply takes over the fields written by the creation.
+	createdObj := writtenObject(obj, util.FieldManager, "Update", "1")
+	dispatch(func(d ManagedDispatcher) {
+		d.Update("c1", createdObj)
+	})
+	// Subsequent applies are not forced.
+	appliedObj := writtenObject(obj, util.FieldManager, "Apply", "2")
+	dispatch(func(d ManagedDispatcher) {
+		d.Update("c1", appliedObj)
+	})
+	if !reflect.DeepEqual(client.applyForces, []bool{true, false}) {
+		t.Fatalf("Expected only the first apply to be forced, got: %v", client.applyForces)
+	}
+}
+
+// driftResource is a federated resource whose resource in a member
+// cluster has drifted since it was last propagated.
+type driftResource struct {
+	applyResource
+	fedObj *unstructured.Unstructured
+
+	sync.Mutex
+	driftEvents int
+}
+
+func (r *driftResource) Object() *unstructured.Unstructured {
+	return r.fedObj
+}
+
+func (r *driftResource) VersionForCluster(clusterName string) (string, error) {
+	return "rv:1", nil
+}
+
+func (r *driftResource) RecordError(errorCode string, err error) {
+	r.Lock()
+	defer r.Unlock()
+	if errorCode == "DriftDetectedInCluster" {
+		r.driftEvents++
+	}
+}
+
+func TestManagedDispatcherDrift(t *testing.T) {
+	testCases := map[string]struct {
+		driftPolicy         util.DriftPolicy
+		previousStatus      status.PropagationStatus
+		expectedDriftEvents int
+		expectedApplies     int
+	}{
+		"drift is overwritten and reported when it first appears": {
+			driftPolicy:         util.DriftPolicyOverwrite,
+			previousStatus:      status.ClusterPropagationOK,
+			expectedDriftEvents: 1,
+			expectedApplies:     1,
+		},
+		"drift is reported when it first appears": {
+			driftPolicy:         util.DriftPolicyReport,
+			previousStatus:      status.ClusterPropagationOK,
+			expectedDriftEvents: 1,
+		},
+		"drift is not reported again": {
+			driftPolicy:    util.DriftPolicyReport,
+			previousStatus: status.Drifted,
+		},
+		"drift is overwritten without being reported again": {
+			driftPolicy:     util.DriftPolicyOverwrite,
+			previousStatus:  status.Drifted,
+			expectedApplies: 1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetNamespace("ns")
+			obj.SetName("foo")
+			util.AddManagedLabel(obj)
+			_ = unstructured.SetNestedField(obj.Object, "desired", "data", "key")
+
+			fedObj := &unstructured.Unstructured{Object: map[string]interface{}{
+				util.SpecField: map[string]interface{}{
+					util.DriftPolicyField: string(testCase.driftPolicy),
+				},
+				util.StatusField: map[string]interface{}{
+					"clusters": []interface{}{
+						map[string]interface{}{
+							"name":   "c1",
+							"status": string(testCase.previousStatus),
+						},
+					},
+				},
+			}}
+			fedResource := &driftResource{applyResource: applyResource{obj: obj}, fedObj: fedObj}
+
+			clusterObj := writtenObject(obj, util.FieldManager, "Apply", "5")
+			_ = unstructured.SetNestedField(clusterObj.Object, "drifted", "data", "key")
+
+			client := &applyClient{}
+			clientAccessor := func(ctx context.Context, clusterName string) (util.ResourceClient, error) {
+				return client, nil
+			}
+			d := NewManagedDispatcher(clientAccessor, fedResource, fedv1a1.AdoptionPolicyNever, DispatchConfig{Timeout: 10 * time.Second})
+			d.Update("c1", clusterObj)
+			ok, err := d.Wait()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !ok {
+				t.Fatalf("Expected the operation to succeed")
+			}
+
+			if propStatus := d.StatusMap()["c1"]; propStatus != status.Drifted {
+				t.Fatalf("Expected status %q, got %q", status.Drifted, propStatus)
+			}
+			if fedResource.driftEvents != testCase.expectedDriftEvents {
+				t.Fatalf("Expected %d drift events, got %d", testCase.expectedDriftEvents, fedResource.driftEvents)
+			}
+			if len(client.applyForces) != testCase.expectedApplies {
+				t.Fatalf("Expected %d applies, got %d", testCase.expectedApplies, len(client.applyForces))
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package dispatch
 
 import (
+	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -26,9 +28,29 @@ import (
 
 	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
-type clientAccessorFunc func(clusterName string) (util.ResourceClient, error)
+type clientAccessorFunc func(ctx context.Context, clusterName string) (util.ResourceClient, error)
+
+// DispatchConfig configures how operations are dispatched to member
+// clusters.
+type DispatchConfig struct {
+	// Time to wait for dispatched operations to complete, measured
+	// from when the dispatcher is created.  Time an operation spends
+	// waiting for a concurrency limit to permit it to run counts
+	// against the timeout, so that the wait for a federated resource
+	// is bounded even when operations for other resources hold the
+	// slots of a cluster.  Operations still in progress or waiting to
+	// run when the timeout expires are cancelled.
+	Timeout time.Duration
+	// Maximum number of operations a dispatcher runs concurrently.
+	// Zero indicates no limit.
+	MaxConcurrentOperations int
+	// Limits the number of operations running concurrently in each
+	// member cluster across dispatchers.  Nil indicates no limit.
+	ClusterLimiter *util.ClusterOperationLimiter
+}
 
 type dispatchRecorder interface {
 	recordEvent(clusterName, operation, operationContinuous string)
@@ -52,49 +74,91 @@ type operationDispatcherImpl struct {
 
 	timeout time.Duration
 
+	// Cancelled when the timeout expires or when Wait returns.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Bounds the number of operations running concurrently.  Nil if
+	// there is no limit.
+	operationSlots chan struct{}
+	// Bounds the number of operations running concurrently in each
+	// cluster.  Nil if there is no limit.
+	clusterSlots func(clusterName string) chan struct{}
+
 	recorder dispatchRecorder
+
+	// Kind of the target resource, used to label metrics.
+	targetKind string
+
+	// Guards pendingOperations and timedOut.
+	metricsLock sync.Mutex
+	// Operations that have yet to complete, used to determine
+	// which operations to record as timed out.
+	pendingOperations map[pendingOperation]int
+	// Whether the dispatcher timed out waiting for operations
+	timedOut bool
 }
 
-func newOperationDispatcher(clientAccessor clientAccessorFunc, recorder dispatchRecorder) *operationDispatcherImpl {
-	return &operationDispatcherImpl{
-		clientAccessor: clientAccessor,
-		resultChan:     make(chan util.ReconciliationStatus),
-		timeout:        30 * time.Second, // TODO(marun) Make this configurable
-		recorder:       recorder,
+type pendingOperation struct {
+	clusterName string
+	operation   string
+}
+
+func newOperationDispatcher(clientAccessor clientAccessorFunc, recorder dispatchRecorder, targetKind string, config DispatchConfig) *operationDispatcherImpl {
+	d := &operationDispatcherImpl{
+		clientAccessor:    clientAccessor,
+		resultChan:        make(chan util.ReconciliationStatus),
+		timeout:           config.Timeout,
+		recorder:          recorder,
+		targetKind:        targetKind,
+		pendingOperations: make(map[pendingOperation]int),
+	}
+	d.ctx, d.cancel = context.WithTimeout(context.Background(), d.timeout)
+	if config.MaxConcurrentOperations > 0 {
+		d.operationSlots = make(chan struct{}, config.MaxConcurrentOperations)
+	}
+	if config.ClusterLimiter != nil {
+		d.clusterSlots = config.ClusterLimiter.ClusterSlots
 	}
+	return d
 }
 
 func (d *operationDispatcherImpl) Wait() (bool, error) {
+	defer d.cancel()
 	ok := true
 	timedOut := false
-	start := time.Now()
 	for i := int32(0); i < atomic.LoadInt32(&d.operationsInitiated); i++ {
-		now := time.Now()
-		if !now.Before(start.Add(d.timeout)) {
-			timedOut = true
-			break
-		}
 		select {
 		case result := <-d.resultChan:
 			if result == util.StatusError {
 				ok = false
 			}
-			break
-		case <-time.After(start.Add(d.timeout).Sub(now)):
+		case <-d.ctx.Done():
 			timedOut = true
+		}
+		if timedOut {
 			break
 		}
 	}
 	if timedOut {
+		d.recordTimedOutOperations()
 		return false, errors.Errorf("Failed to finish %d operations in %v", atomic.LoadInt32(&d.operationsInitiated), d.timeout)
 	}
 	return ok, nil
 }
 
 func (d *operationDispatcherImpl) clusterOperation(clusterName, op string, opFunc func(util.ResourceClient) util.ReconciliationStatus) {
-	// TODO(marun) Update to generic client and support cancellation
-	// on timeout.
-	client, err := d.clientAccessor(clusterName)
+	d.operationStarted(clusterName, op)
+
+	if !d.acquireSlots(clusterName) {
+		// The dispatcher timed out before the operation could start.
+		return
+	}
+	defer d.releaseSlots(clusterName)
+
+	// The client is bound to the context of the dispatcher so that
+	// requests still in progress on timeout are cancelled.
+	client, err := d.clientAccessor(d.ctx, clusterName)
 	if err != nil {
 		wrappedErr := errors.Wrapf(err, "Error retrieving client for cluster")
 		if d.recorder == nil {
@@ -102,13 +166,101 @@ func (d *operationDispatcherImpl) clusterOperation(clusterName, op string, opFun
 		} else {
 			d.recorder.recordOperationError(status.ClientRetrievalFailed, clusterName, op, wrappedErr)
 		}
-		d.resultChan <- util.StatusError
+		d.operationCompleted(clusterName, op, util.StatusError)
+		d.sendResult(util.StatusError)
 		return
 	}
 
 	// TODO(marun) Retry on recoverable errors (e.g. IsConflict, AlreadyExists)
 	ok := opFunc(client)
-	d.resultChan <- ok
+	d.operationCompleted(clusterName, op, ok)
+	d.sendResult(ok)
+}
+
+// acquireSlots blocks until the operation is permitted to run by the
+// concurrency limits of the dispatcher, returning false if the
+// dispatcher timed out first.
+func (d *operationDispatcherImpl) acquireSlots(clusterName string) bool {
+	if d.operationSlots != nil {
+		select {
+		case d.operationSlots <- struct{}{}:
+		case <-d.ctx.Done():
+			return false
+		}
+	}
+	if d.clusterSlots != nil {
+		select {
+		case d.clusterSlots(clusterName) <- struct{}{}:
+		case <-d.ctx.Done():
+			if d.operationSlots != nil {
+				<-d.operationSlots
+			}
+			return false
+		}
+	}
+	return true
+}
+
+func (d *operationDispatcherImpl) releaseSlots(clusterName string) {
+	if d.clusterSlots != nil {
+		<-d.clusterSlots(clusterName)
+	}
+	if d.operationSlots != nil {
+		<-d.operationSlots
+	}
+}
+
+// sendResult sends the result of an operation to Wait unless Wait
+// has already returned.
+func (d *operationDispatcherImpl) sendResult(result util.ReconciliationStatus) {
+	select {
+	case d.resultChan <- result:
+	case <-d.ctx.Done():
+	}
+}
+
+// cancelled indicates whether the dispatcher has timed out or
+// finished waiting for operations.
+func (d *operationDispatcherImpl) cancelled() bool {
+	return d.ctx.Err() != nil
+}
+
+func (d *operationDispatcherImpl) operationStarted(clusterName, op string) {
+	d.metricsLock.Lock()
+	defer d.metricsLock.Unlock()
+	d.pendingOperations[pendingOperation{clusterName, op}]++
+}
+
+// operationCompleted records the result of an operation. Operations
+// completing after the dispatcher has timed out have already been
+// recorded as timed out.
+func (d *operationDispatcherImpl) operationCompleted(clusterName, op string, result util.ReconciliationStatus) {
+	d.metricsLock.Lock()
+	defer d.metricsLock.Unlock()
+	if d.timedOut {
+		return
+	}
+	key := pendingOperation{clusterName, op}
+	d.pendingOperations[key]--
+	if d.pendingOperations[key] == 0 {
+		delete(d.pendingOperations, key)
+	}
+	metricsResult := metrics.DispatchSucceeded
+	if result == util.StatusError {
+		metricsResult = metrics.DispatchFailed
+	}
+	metrics.DispatchOperation(d.targetKind, clusterName, op, metricsResult)
+}
+
+func (d *operationDispatcherImpl) recordTimedOutOperations() {
+	d.metricsLock.Lock()
+	defer d.metricsLock.Unlock()
+	d.timedOut = true
+	for key, count := range d.pendingOperations {
+		for i := 0; i < count; i++ {
+			metrics.DispatchOperation(d.targetKind, key.clusterName, key.operation, metrics.DispatchTimedOut)
+		}
+	}
 }
 
 func (d *operationDispatcherImpl) incrementOperationsInitiated() {
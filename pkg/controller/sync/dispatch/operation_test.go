@@ -0,0 +1,183 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func nilClientAccessor(ctx context.Context, clusterName string) (util.ResourceClient, error) {
+	return nil, nil
+}
+
+// concurrencyTracker records the maximum number of operations that
+// were running concurrently.
+type concurrencyTracker struct {
+	sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (t *concurrencyTracker) operation(client util.ResourceClient) util.ReconciliationStatus {
+	t.Lock()
+	t.running++
+	if t.running > t.maxRunning {
+		t.maxRunning = t.running
+	}
+	t.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	t.Lock()
+	t.running--
+	t.Unlock()
+	return util.StatusAllOK
+}
+
+func TestOperationDispatcherConcurrency(t *testing.T) {
+	testCases := map[string]struct {
+		config             DispatchConfig
+		clusterNames       []string
+		expectedMaxRunning int
+	}{
+		"operations limited per dispatcher": {
+			config: DispatchConfig{
+				MaxConcurrentOperations: 2,
+			},
+			clusterNames:       []string{"c1", "c2", "c3", "c4", "c5", "c6"},
+			expectedMaxRunning: 2,
+		},
+		"operations limited per cluster": {
+			config: DispatchConfig{
+				ClusterLimiter: util.NewClusterOperationLimiter(1),
+			},
+			clusterNames:       []string{"c1", "c1", "c1", "c1"},
+			expectedMaxRunning: 1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			testCase.config.Timeout = 10 * time.Second
+			d := newOperationDispatcher(nilClientAccessor, nil, "Widget", testCase.config)
+			tracker := &concurrencyTracker{}
+			for _, clusterName := range testCase.clusterNames {
+				d.incrementOperationsInitiated()
+				go d.clusterOperation(clusterName, "update", tracker.operation)
+			}
+			ok, err := d.Wait()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !ok {
+				t.Fatalf("Expected all operations to succeed")
+			}
+			if tracker.maxRunning != testCase.expectedMaxRunning {
+				t.Fatalf("Expected at most %d operations to run concurrently, got %d", testCase.expectedMaxRunning, tracker.maxRunning)
+			}
+		})
+	}
+}
+
+func TestOperationDispatcherTimeout(t *testing.T) {
+	config := DispatchConfig{
+		Timeout:                 50 * time.Millisecond,
+		MaxConcurrentOperations: 1,
+	}
+	var clientContext context.Context
+	clientAccessor := func(ctx context.Context, clusterName string) (util.ResourceClient, error) {
+		clientContext = ctx
+		return nil, nil
+	}
+	d := newOperationDispatcher(clientAccessor, nil, "Widget", config)
+
+	started := make(chan string, 2)
+	blockUntilCancelled := func(client util.ResourceClient) util.ReconciliationStatus {
+		started <- "started"
+		<-clientContext.Done()
+		return util.StatusError
+	}
+	for i := 0; i < 2; i++ {
+		d.incrementOperationsInitiated()
+		go d.clusterOperation(fmt.Sprintf("c%d", i), "update", blockUntilCancelled)
+	}
+
+	_, err := d.Wait()
+	if err == nil {
+		t.Fatalf("Expected a timeout error")
+	}
+	if len(started) != 1 {
+		t.Fatalf("Expected only one operation to start, got %d", len(started))
+	}
+	if !d.cancelled() {
+		t.Fatalf("Expected the dispatcher to be cancelled")
+	}
+}
+
+func TestOperationDispatcherTimeoutIncludesQueuedTime(t *testing.T) {
+	limiter := util.NewClusterOperationLimiter(1)
+
+	holder := newOperationDispatcher(nilClientAccessor, nil, "Widget", DispatchConfig{
+		Timeout:        10 * time.Second,
+		ClusterLimiter: limiter,
+	})
+	holding := make(chan struct{})
+	release := make(chan struct{})
+	holder.incrementOperationsInitiated()
+	go holder.clusterOperation("c1", "update", func(client util.ResourceClient) util.ReconciliationStatus {
+		close(holding)
+		<-release
+		return util.StatusAllOK
+	})
+	<-holding
+
+	// The operation of the second dispatcher waits for the slot held
+	// by the first, and that wait counts against its timeout.
+	d := newOperationDispatcher(nilClientAccessor, nil, "Widget", DispatchConfig{
+		Timeout:        50 * time.Millisecond,
+		ClusterLimiter: limiter,
+	})
+	started := make(chan string, 1)
+	d.incrementOperationsInitiated()
+	go d.clusterOperation("c1", "update", func(client util.ResourceClient) util.ReconciliationStatus {
+		started <- "started"
+		return util.StatusAllOK
+	})
+
+	_, err := d.Wait()
+	if err == nil {
+		t.Fatalf("Expected a timeout error")
+	}
+	if len(started) != 0 {
+		t.Fatalf("Expected the queued operation not to start")
+	}
+
+	close(release)
+	ok, err := holder.Wait()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("Expected the holding operation to succeed")
+	}
+}
@@ -17,113 +17,153 @@ limitations under the License.
 package dispatch
 
 import (
+	"reflect"
+
 	"github.com/pkg/errors"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
 
 // RetainClusterFields updates the desired object with values retained
 // from the cluster object.
-func RetainClusterFields(targetKind string, desiredObj, clusterObj, fedObj *unstructured.Unstructured) error {
+func RetainClusterFields(retainFields []fedv1a1.RetainField, desiredObj, clusterObj, fedObj *unstructured.Unstructured) error {
 	// Pass the same ResourceVersion as in the cluster object for update operation, otherwise operation will fail.
 	desiredObj.SetResourceVersion(clusterObj.GetResourceVersion())
 
-	if targetKind == util.ServiceKind {
-		return retainServiceFields(desiredObj, clusterObj)
-	}
-	if targetKind == util.ServiceAccountKind {
-		return retainServiceAccountFields(desiredObj, clusterObj)
+	util.RetainAdoptionAnnotations(desiredObj, clusterObj)
+
+	for _, field := range retainFields {
+		if err := retainField(field, desiredObj, clusterObj); err != nil {
+			return errors.Wrapf(err, "Error retaining field %q", field.Path)
+		}
 	}
 	return retainReplicas(desiredObj, clusterObj, fedObj)
 }
 
-func retainServiceFields(desiredObj, clusterObj *unstructured.Unstructured) error {
-	// ClusterIP and NodePort are allocated to Service by cluster, so retain the same if any while updating
-
-	// Retain clusterip
-	clusterIP, ok, err := unstructured.NestedString(clusterObj.Object, "spec", "clusterIP")
+// retainField sets the value of the given field in the desired object
+// to its value in the cluster object.  Fields that are absent or
+// empty in the cluster object are not retained.
+func retainField(field fedv1a1.RetainField, desiredObj, clusterObj *unstructured.Unstructured) error {
+	path, err := util.ParseJSONPointer(field.Path)
 	if err != nil {
-		return errors.Wrap(err, "Error retrieving clusterIP from cluster service")
+		return err
 	}
-	// !ok could indicate that a cluster ip was not assigned
-	if ok && clusterIP != "" {
-		err := unstructured.SetNestedField(desiredObj.Object, clusterIP, "spec", "clusterIP")
-		if err != nil {
-			return errors.Wrap(err, "Error setting clusterIP for service")
-		}
+	value, changed := retainValue(desiredObj.Object, clusterObj.Object, path, field)
+	if changed {
+		desiredObj.Object = value.(map[string]interface{})
 	}
+	return nil
+}
 
-	// Retain nodeports
-	clusterPorts, ok, err := unstructured.NestedSlice(clusterObj.Object, "spec", "ports")
-	if err != nil {
-		return errors.Wrap(err, "Error retrieving ports from cluster service")
-	}
-	if !ok {
-		return nil
-	}
-	var desiredPorts []interface{}
-	desiredPorts, ok, err = unstructured.NestedSlice(desiredObj.Object, "spec", "ports")
-	if err != nil {
-		return errors.Wrap(err, "Error retrieving ports from service")
-	}
-	if !ok {
-		desiredPorts = []interface{}{}
+// retainValue returns the desired value updated with the value at the
+// given path in the cluster value, and whether the desired value was
+// changed.  Maps missing from the desired value are only created if a
+// value is retained.
+func retainValue(desired, cluster interface{}, path []string, field fedv1a1.RetainField) (interface{}, bool) {
+	if len(path) == 0 {
+		if isEmptyValue(cluster) || (field.OnlyIfAbsent && !isEmptyValue(desired)) {
+			return desired, false
+		}
+		return runtime.DeepCopyJSONValue(cluster), true
 	}
-	for desiredIndex := range desiredPorts {
-		for clusterIndex := range clusterPorts {
-			fPort := desiredPorts[desiredIndex].(map[string]interface{})
-			cPort := clusterPorts[clusterIndex].(map[string]interface{})
-			if !(fPort["name"] == cPort["name"] && fPort["protocol"] == cPort["protocol"] && fPort["port"] == cPort["port"]) {
+
+	if path[0] == "*" {
+		desiredList, ok := desired.([]interface{})
+		if !ok {
+			return desired, false
+		}
+		clusterList, ok := cluster.([]interface{})
+		if !ok {
+			return desired, false
+		}
+		changed := false
+		for i, desiredElem := range desiredList {
+			clusterElem, ok := matchListElement(desiredElem, i, clusterList, field.MatchKeys)
+			if !ok {
 				continue
 			}
-			nodePort, ok := cPort["nodePort"]
-			if ok {
-				fPort["nodePort"] = nodePort
+			value, elemChanged := retainValue(desiredElem, clusterElem, path[1:], field)
+			if elemChanged {
+				desiredList[i] = value
+				changed = true
 			}
 		}
-	}
-	err = unstructured.SetNestedSlice(desiredObj.Object, desiredPorts, "spec", "ports")
-	if err != nil {
-		return errors.Wrap(err, "Error setting ports for service")
+		return desiredList, changed
 	}
 
-	return nil
+	clusterMap, ok := cluster.(map[string]interface{})
+	if !ok {
+		return desired, false
+	}
+	clusterValue, ok := clusterMap[path[0]]
+	if !ok {
+		return desired, false
+	}
+	var desiredMap map[string]interface{}
+	switch value := desired.(type) {
+	case map[string]interface{}:
+		desiredMap = value
+	case nil:
+		desiredMap = make(map[string]interface{})
+	default:
+		return desired, false
+	}
+	value, changed := retainValue(desiredMap[path[0]], clusterValue, path[1:], field)
+	if !changed {
+		return desired, false
+	}
+	desiredMap[path[0]] = value
+	return desiredMap, true
 }
 
-// retainServiceAccountFields retains the 'secrets' field of a service account
-// if the desired representation does not include a value for the field.  This
-// ensures that the sync controller doesn't continually clear a generated
-// secret from a service account, prompting continual regeneration by the
-// service account controller in the member cluster.
-//
-// TODO(marun) Clearing a manually-set secrets field will require resetting
-// placement.  Is there a better way to do this?
-func retainServiceAccountFields(desiredObj, clusterObj *unstructured.Unstructured) error {
-	// Check whether the secrets field is populated in the desired object.
-	desiredSecrets, ok, err := unstructured.NestedSlice(desiredObj.Object, util.SecretsField)
-	if err != nil {
-		return errors.Wrap(err, "Error retrieving secrets from desired service account")
-	}
-	if ok && len(desiredSecrets) > 0 {
-		// Field is populated, so an update to the target resource does not
-		// risk triggering a race with the service account controller.
-		return nil
+// matchListElement returns the element of the cluster list matching
+// the desired list element at the given index.
+func matchListElement(desiredElem interface{}, index int, clusterList []interface{}, matchKeys []string) (interface{}, bool) {
+	if len(matchKeys) == 0 {
+		if index < len(clusterList) {
+			return clusterList[index], true
+		}
+		return nil, false
 	}
-
-	// Retrieve the secrets from the cluster object and retain them.
-	secrets, ok, err := unstructured.NestedSlice(clusterObj.Object, util.SecretsField)
-	if err != nil {
-		return errors.Wrap(err, "Error retrieving secrets from service account")
+	desiredMap, ok := desiredElem.(map[string]interface{})
+	if !ok {
+		return nil, false
 	}
-	if ok && len(secrets) > 0 {
-		err := unstructured.SetNestedField(desiredObj.Object, secrets, util.SecretsField)
-		if err != nil {
-			return errors.Wrap(err, "Error setting secrets for service account")
+	for _, clusterElem := range clusterList {
+		clusterMap, ok := clusterElem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matched := true
+		for _, key := range matchKeys {
+			if !reflect.DeepEqual(desiredMap[key], clusterMap[key]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return clusterElem, true
 		}
 	}
-	return nil
+	return nil, false
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch typedValue := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(typedValue) == 0
+	case []interface{}:
+		return len(typedValue) == 0
+	case map[string]interface{}:
+		return len(typedValue) == 0
+	}
+	return false
 }
 
 func retainReplicas(desiredObj, clusterObj, fedObj *unstructured.Unstructured) error {
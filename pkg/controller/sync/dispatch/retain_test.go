@@ -17,10 +17,12 @@ limitations under the License.
 package dispatch
 
 import (
+	"reflect"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
 
@@ -68,7 +70,7 @@ func TestRetainClusterFields(t *testing.T) {
 					},
 				},
 			}
-			if err := RetainClusterFields("", desiredObj, clusterObj, fedObj); err != nil {
+			if err := RetainClusterFields(nil, desiredObj, clusterObj, fedObj); err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
 
@@ -85,3 +87,184 @@ func TestRetainClusterFields(t *testing.T) {
 		})
 	}
 }
+
+func TestRetainFields(t *testing.T) {
+	serviceFields := fedv1a1.DefaultRetainFields(fedv1a1.APIResource{Kind: util.ServiceKind})
+	serviceAccountFields := fedv1a1.DefaultRetainFields(fedv1a1.APIResource{Kind: util.ServiceAccountKind})
+	serviceTypeConfig := func(disableDefaults bool) *fedv1a1.FederatedTypeConfig {
+		return &fedv1a1.FederatedTypeConfig{
+			Spec: fedv1a1.FederatedTypeConfigSpec{
+				Target: fedv1a1.APIResource{Version: "v1", Kind: util.ServiceKind},
+				RetainFields: []fedv1a1.RetainField{
+					{Path: "/metadata/annotations/sidecar.istio.io~1status"},
+				},
+				DisableDefaultRetainFields: disableDefaults,
+			},
+		}
+	}
+	istioService := func(clusterIP string) map[string]interface{} {
+		spec := map[string]interface{}{}
+		if clusterIP != "" {
+			spec["clusterIP"] = clusterIP
+		}
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"sidecar.istio.io/status": "injected",
+				},
+			},
+			"spec": spec,
+		}
+	}
+
+	testCases := map[string]struct {
+		retainFields []fedv1a1.RetainField
+		desired      map[string]interface{}
+		cluster      map[string]interface{}
+		expected     map[string]interface{}
+		expectedErr  bool
+	}{
+		"service clusterIP and nodePorts retained": {
+			retainFields: serviceFields,
+			desired: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http", "port": int64(80)},
+						map[string]interface{}{"name": "https", "port": int64(443)},
+					},
+				},
+			},
+			cluster: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"clusterIP": "10.0.0.1",
+					"ports": []interface{}{
+						map[string]interface{}{"name": "https", "port": int64(443), "nodePort": int64(30443)},
+						map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"clusterIP": "10.0.0.1",
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+						map[string]interface{}{"name": "https", "port": int64(443), "nodePort": int64(30443)},
+					},
+				},
+			},
+		},
+		"service account secrets retained when absent": {
+			retainFields: serviceAccountFields,
+			desired:      map[string]interface{}{},
+			cluster: map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "token"},
+				},
+			},
+			expected: map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "token"},
+				},
+			},
+		},
+		"service account secrets not retained when present": {
+			retainFields: serviceAccountFields,
+			desired: map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "desired"},
+				},
+			},
+			cluster: map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "token"},
+				},
+			},
+			expected: map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "desired"},
+				},
+			},
+		},
+		"escaped annotation retained": {
+			retainFields: []fedv1a1.RetainField{
+				{Path: "/metadata/annotations/sidecar.istio.io~1status"},
+			},
+			desired: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "foo",
+				},
+			},
+			cluster: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "foo",
+					"annotations": map[string]interface{}{
+						"sidecar.istio.io/status": "injected",
+						"other":                   "value",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "foo",
+					"annotations": map[string]interface{}{
+						"sidecar.istio.io/status": "injected",
+					},
+				},
+			},
+		},
+		"service clusterIP retained with a custom rule": {
+			retainFields: serviceTypeConfig(false).GetRetainFields(),
+			desired:      map[string]interface{}{"spec": map[string]interface{}{}},
+			cluster:      istioService("10.0.0.1"),
+			expected:     istioService("10.0.0.1"),
+		},
+		"service clusterIP not retained with default rules disabled": {
+			retainFields: serviceTypeConfig(true).GetRetainFields(),
+			desired:      map[string]interface{}{"spec": map[string]interface{}{}},
+			cluster:      istioService("10.0.0.1"),
+			expected:     istioService(""),
+		},
+		"field absent from cluster not retained": {
+			retainFields: []fedv1a1.RetainField{
+				{Path: "/spec/clusterIP"},
+			},
+			desired:  map[string]interface{}{},
+			cluster:  map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		"invalid path": {
+			retainFields: []fedv1a1.RetainField{
+				{Path: "spec/clusterIP"},
+			},
+			desired:     map[string]interface{}{},
+			cluster:     map[string]interface{}{},
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			desiredObj := &unstructured.Unstructured{Object: testCase.desired}
+			clusterObj := &unstructured.Unstructured{Object: testCase.cluster}
+			fedObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			err := RetainClusterFields(testCase.retainFields, desiredObj, clusterObj, fedObj)
+			if testCase.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// Ignore the resource version set from the cluster object
+			unstructured.RemoveNestedField(desiredObj.Object, "metadata", "resourceVersion")
+			if metadata, ok := desiredObj.Object["metadata"].(map[string]interface{}); ok && len(metadata) == 0 {
+				delete(desiredObj.Object, "metadata")
+			}
+			if !reflect.DeepEqual(desiredObj.Object, testCase.expected) {
+				t.Fatalf("Expected %v, got %v", testCase.expected, desiredObj.Object)
+			}
+		})
+	}
+}
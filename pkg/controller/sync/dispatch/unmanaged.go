@@ -49,8 +49,8 @@ type unmanagedDispatcherImpl struct {
 	recorder dispatchRecorder
 }
 
-func NewUnmanagedDispatcher(clientAccessor clientAccessorFunc, targetKind string, targetName util.QualifiedName) UnmanagedDispatcher {
-	dispatcher := newOperationDispatcher(clientAccessor, nil)
+func NewUnmanagedDispatcher(clientAccessor clientAccessorFunc, targetKind string, targetName util.QualifiedName, config DispatchConfig) UnmanagedDispatcher {
+	dispatcher := newOperationDispatcher(clientAccessor, nil, targetKind, config)
 	return newUnmanagedDispatcher(dispatcher, nil, targetKind, targetName)
 }
 
@@ -110,6 +110,7 @@ func (d *unmanagedDispatcherImpl) RemoveManagedLabel(clusterName string, cluster
 		updateObj := clusterObj.DeepCopy()
 
 		util.RemoveManagedLabel(updateObj)
+		util.RemoveAdoptionAnnotations(updateObj)
 
 		_, err := client.Resources(updateObj.GetNamespace()).Update(updateObj, metav1.UpdateOptions{})
 		if err != nil {
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+
+	"sigs.k8s.io/kubefed/pkg/apis/core/typeconfig"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+// Status indicates the health of a target resource.
+type Status string
+
+const (
+	// Healthy indicates that the resource is serving as intended.
+	Healthy Status = "Healthy"
+	// Unhealthy indicates that the resource is not yet or no longer
+	// serving as intended.
+	Unhealthy Status = "Unhealthy"
+	// Unknown indicates that the health of the resource could not be
+	// assessed (e.g. because the resource does not exist).
+	Unknown Status = "Unknown"
+)
+
+// Result is the health of a target resource in a member cluster.
+type Result struct {
+	Status Status
+	// Why the resource is not healthy
+	Message string
+}
+
+// Checker assesses the health of target resources in member clusters.
+type Checker interface {
+	Check(clusterObj *unstructured.Unstructured) Result
+}
+
+// CheckerFunc is an adapter that allows a function to be used as a
+// Checker.
+type CheckerFunc func(clusterObj *unstructured.Unstructured) Result
+
+func (f CheckerFunc) Check(clusterObj *unstructured.Unstructured) Result {
+	return f(clusterObj)
+}
+
+var (
+	checkersLock sync.RWMutex
+	checkers     = map[schema.GroupKind]Checker{
+		{Group: "apps", Kind: "Deployment"}:       CheckerFunc(deploymentHealth),
+		{Group: "extensions", Kind: "Deployment"}: CheckerFunc(deploymentHealth),
+		{Group: "batch", Kind: "Job"}:             CheckerFunc(jobHealth),
+		{Group: "", Kind: "Service"}:              CheckerFunc(serviceHealth),
+	}
+)
+
+// Register sets the checker for target resources of the given group
+// and kind, replacing any checker previously registered.
+func Register(groupKind schema.GroupKind, checker Checker) {
+	checkersLock.Lock()
+	defer checkersLock.Unlock()
+	checkers[groupKind] = checker
+}
+
+// CheckerForType returns the checker for the target type of the given
+// type config.  The health check of the type config takes precedence
+// over a registered checker.  Nil is returned if the health of the
+// target type is not assessed.
+func CheckerForType(typeConfig typeconfig.Interface) (Checker, error) {
+	if healthCheck := typeConfig.GetHealthCheck(); healthCheck != nil {
+		return NewJSONPathChecker(healthCheck)
+	}
+	target := typeConfig.GetTarget()
+	checkersLock.RLock()
+	defer checkersLock.RUnlock()
+	return checkers[schema.GroupKind{Group: target.Group, Kind: target.Kind}], nil
+}
+
+// jsonPathChecker assesses health from the result of a JSONPath
+// expression.
+type jsonPathChecker struct {
+	expression    string
+	healthyValues map[string]bool
+}
+
+// NewJSONPathChecker returns a checker for the given health check.
+func NewJSONPathChecker(healthCheck *fedv1a1.HealthCheck) (Checker, error) {
+	if _, err := parseJSONPath(healthCheck.JSONPath); err != nil {
+		return nil, errors.Wrapf(err, "Invalid JSONPath expression %q", healthCheck.JSONPath)
+	}
+	c := &jsonPathChecker{
+		expression:    healthCheck.JSONPath,
+		healthyValues: make(map[string]bool),
+	}
+	for _, value := range healthCheck.HealthyValues {
+		c.healthyValues[value] = true
+	}
+	return c, nil
+}
+
+func (c *jsonPathChecker) Check(clusterObj *unstructured.Unstructured) Result {
+	// A parsed expression is not safe for concurrent use.
+	j, err := parseJSONPath(c.expression)
+	if err != nil {
+		return Result{Status: Unknown, Message: err.Error()}
+	}
+	results, err := j.FindResults(clusterObj.Object)
+	if err != nil {
+		return Result{Status: Unknown, Message: err.Error()}
+	}
+	values := []string{}
+	for _, result := range results {
+		for _, value := range result {
+			text := fmt.Sprint(value.Interface())
+			if c.healthyValues[text] {
+				return Result{Status: Healthy}
+			}
+			values = append(values, text)
+		}
+	}
+	if len(values) == 0 {
+		return Result{Status: Unhealthy, Message: fmt.Sprintf("%s has no value", c.expression)}
+	}
+	return Result{Status: Unhealthy, Message: fmt.Sprintf("%s is %q", c.expression, strings.Join(values, ","))}
+}
+
+func parseJSONPath(expression string) (*jsonpath.JSONPath, error) {
+	j := jsonpath.New("health").AllowMissingKeys(true)
+	if err := j.Parse(expression); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// deploymentHealth assesses whether the rollout of a deployment is
+// complete in the same way as `kubectl rollout status`.
+func deploymentHealth(clusterObj *unstructured.Unstructured) Result {
+	if result, ok := generationObserved(clusterObj); !ok {
+		return result
+	}
+	if condition := findCondition(clusterObj, "Progressing"); condition != nil && condition["reason"] == "ProgressDeadlineExceeded" {
+		return Result{Status: Unhealthy, Message: "Deployment exceeded its progress deadline"}
+	}
+	replicas, ok, _ := unstructured.NestedInt64(clusterObj.Object, "spec", "replicas")
+	if !ok {
+		replicas = 1
+	}
+	updatedReplicas, _, _ := unstructured.NestedInt64(clusterObj.Object, "status", "updatedReplicas")
+	statusReplicas, _, _ := unstructured.NestedInt64(clusterObj.Object, "status", "replicas")
+	availableReplicas, _, _ := unstructured.NestedInt64(clusterObj.Object, "status", "availableReplicas")
+	switch {
+	case updatedReplicas < replicas:
+		return Result{Status: Unhealthy, Message: fmt.Sprintf("%d of %d replicas have been updated", updatedReplicas, replicas)}
+	case statusReplicas > updatedReplicas:
+		return Result{Status: Unhealthy, Message: fmt.Sprintf("%d old replicas are pending termination", statusReplicas-updatedReplicas)}
+	case availableReplicas < updatedReplicas:
+		return Result{Status: Unhealthy, Message: fmt.Sprintf("%d of %d updated replicas are available", availableReplicas, updatedReplicas)}
+	}
+	return Result{Status: Healthy}
+}
+
+// jobHealth assesses whether a job has succeeded.
+func jobHealth(clusterObj *unstructured.Unstructured) Result {
+	if condition := findCondition(clusterObj, "Failed"); condition != nil && condition["status"] == "True" {
+		message, _ := condition["message"].(string)
+		if message == "" {
+			message, _ = condition["reason"].(string)
+		}
+		return Result{Status: Unhealthy, Message: fmt.Sprintf("Job failed: %s", message)}
+	}
+	if condition := findCondition(clusterObj, "Complete"); condition != nil && condition["status"] == "True" {
+		return Result{Status: Healthy}
+	}
+	return Result{Status: Unhealthy, Message: "Job has not completed"}
+}
+
+// serviceHealth assesses whether a service of type LoadBalancer has
+// been assigned an ingress point.  Services of other types are
+// healthy.
+func serviceHealth(clusterObj *unstructured.Unstructured) Result {
+	serviceType, _, _ := unstructured.NestedString(clusterObj.Object, "spec", "type")
+	if serviceType != "LoadBalancer" {
+		return Result{Status: Healthy}
+	}
+	ingress, _, _ := unstructured.NestedSlice(clusterObj.Object, "status", "loadBalancer", "ingress")
+	if len(ingress) == 0 {
+		return Result{Status: Unhealthy, Message: "Load balancer has no ingress points"}
+	}
+	return Result{Status: Healthy}
+}
+
+// generationObserved indicates whether the controller of the resource
+// has observed its latest generation.
+func generationObserved(clusterObj *unstructured.Unstructured) (Result, bool) {
+	observedGeneration, ok, _ := unstructured.NestedInt64(clusterObj.Object, "status", "observedGeneration")
+	if !ok || observedGeneration < clusterObj.GetGeneration() {
+		return Result{Status: Unhealthy, Message: "Latest generation has not been observed"}, false
+	}
+	return Result{}, true
+}
+
+// findCondition returns the condition of the given type from the
+// status of the resource, or nil if it is not present.
+func findCondition(clusterObj *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(clusterObj.Object, "status", "conditions")
+	for _, rawCondition := range conditions {
+		condition, ok := rawCondition.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
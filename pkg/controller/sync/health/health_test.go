@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestBuiltinCheckers(t *testing.T) {
+	newObj := func(generation int64, spec, status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec":   spec,
+				"status": status,
+			},
+		}
+		obj.SetGeneration(generation)
+		return obj
+	}
+	testCases := map[string]struct {
+		checker        CheckerFunc
+		obj            *unstructured.Unstructured
+		expectedStatus Status
+	}{
+		"Deployment rollout complete": {
+			checker: deploymentHealth,
+			obj: newObj(2, map[string]interface{}{"replicas": int64(2)}, map[string]interface{}{
+				"observedGeneration": int64(2),
+				"replicas":           int64(2),
+				"updatedReplicas":    int64(2),
+				"availableReplicas":  int64(2),
+			}),
+			expectedStatus: Healthy,
+		},
+		"Deployment generation not observed": {
+			checker: deploymentHealth,
+			obj: newObj(3, map[string]interface{}{"replicas": int64(2)}, map[string]interface{}{
+				"observedGeneration": int64(2),
+				"replicas":           int64(2),
+				"updatedReplicas":    int64(2),
+				"availableReplicas":  int64(2),
+			}),
+			expectedStatus: Unhealthy,
+		},
+		"Deployment with old replicas": {
+			checker: deploymentHealth,
+			obj: newObj(1, map[string]interface{}{"replicas": int64(2)}, map[string]interface{}{
+				"observedGeneration": int64(1),
+				"replicas":           int64(3),
+				"updatedReplicas":    int64(2),
+				"availableReplicas":  int64(2),
+			}),
+			expectedStatus: Unhealthy,
+		},
+		"Deployment exceeded progress deadline": {
+			checker: deploymentHealth,
+			obj: newObj(1, map[string]interface{}{"replicas": int64(1)}, map[string]interface{}{
+				"observedGeneration": int64(1),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				},
+			}),
+			expectedStatus: Unhealthy,
+		},
+		"Job succeeded": {
+			checker: jobHealth,
+			obj: newObj(1, nil, map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Complete", "status": "True"},
+				},
+			}),
+			expectedStatus: Healthy,
+		},
+		"Job failed": {
+			checker: jobHealth,
+			obj: newObj(1, nil, map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"},
+				},
+			}),
+			expectedStatus: Unhealthy,
+		},
+		"Job running": {
+			checker:        jobHealth,
+			obj:            newObj(1, nil, map[string]interface{}{"active": int64(1)}),
+			expectedStatus: Unhealthy,
+		},
+		"ClusterIP service": {
+			checker:        serviceHealth,
+			obj:            newObj(0, map[string]interface{}{"type": "ClusterIP"}, nil),
+			expectedStatus: Healthy,
+		},
+		"LoadBalancer service with ingress": {
+			checker: serviceHealth,
+			obj: newObj(0, map[string]interface{}{"type": "LoadBalancer"}, map[string]interface{}{
+				"loadBalancer": map[string]interface{}{
+					"ingress": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}},
+				},
+			}),
+			expectedStatus: Healthy,
+		},
+		"LoadBalancer service without ingress": {
+			checker:        serviceHealth,
+			obj:            newObj(0, map[string]interface{}{"type": "LoadBalancer"}, map[string]interface{}{}),
+			expectedStatus: Unhealthy,
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			result := testCase.checker.Check(testCase.obj)
+			if result.Status != testCase.expectedStatus {
+				t.Fatalf("Expected %s, got %s (%s)", testCase.expectedStatus, result.Status, result.Message)
+			}
+			if result.Status != Healthy && result.Message == "" {
+				t.Fatalf("Expected a message for status %s", result.Status)
+			}
+		})
+	}
+}
+
+func TestJSONPathChecker(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": "Ready",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Synced", "status": "True"},
+				},
+			},
+		},
+	}
+	testCases := map[string]struct {
+		healthCheck    fedv1a1.HealthCheck
+		expectedStatus Status
+		expectedError  bool
+	}{
+		"matching value": {
+			healthCheck:    fedv1a1.HealthCheck{JSONPath: "{.status.phase}", HealthyValues: []string{"Ready", "Succeeded"}},
+			expectedStatus: Healthy,
+		},
+		"non-matching value": {
+			healthCheck:    fedv1a1.HealthCheck{JSONPath: "{.status.phase}", HealthyValues: []string{"Succeeded"}},
+			expectedStatus: Unhealthy,
+		},
+		"filtered condition": {
+			healthCheck:    fedv1a1.HealthCheck{JSONPath: `{.status.conditions[?(@.type=="Synced")].status}`, HealthyValues: []string{"True"}},
+			expectedStatus: Healthy,
+		},
+		"missing field": {
+			healthCheck:    fedv1a1.HealthCheck{JSONPath: "{.status.missing}", HealthyValues: []string{"True"}},
+			expectedStatus: Unhealthy,
+		},
+		"invalid expression": {
+			healthCheck:   fedv1a1.HealthCheck{JSONPath: "{.status.phase", HealthyValues: []string{"Ready"}},
+			expectedError: true,
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			checker, err := NewJSONPathChecker(&testCase.healthCheck)
+			if testCase.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			result := checker.Check(obj)
+			if result.Status != testCase.expectedStatus {
+				t.Fatalf("Expected %s, got %s (%s)", testCase.expectedStatus, result.Status, result.Message)
+			}
+		})
+	}
+}
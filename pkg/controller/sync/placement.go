@@ -17,11 +17,19 @@ limitations under the License.
 package sync
 
 import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
 
@@ -61,6 +69,22 @@ func computeNamespacedPlacement(resource, namespace *unstructured.Unstructured,
 		return nil, err
 	}
 
+	// Spread constraints and failover placement for a federated
+	// namespace are resolved when the federated namespace is
+	// reconciled and the resulting clusters are recorded in its
+	// status.
+	namespacePlacement, err := util.UnmarshalGenericPlacement(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if namespacePlacement.Spec.Placement.SpreadConstraints != nil || namespacePlacement.Spec.Placement.Failover != nil {
+		spreadClusters, err := status.GetSelectedClusters(namespace)
+		if err != nil {
+			return nil, err
+		}
+		namespaceClusters = namespaceClusters.Intersection(sets.NewString(spreadClusters...))
+	}
+
 	// If both namespace and resource placement exist, the desired
 	// list of clusters is their intersection.
 	return resourceClusters.Intersection(namespaceClusters), nil
@@ -84,6 +108,15 @@ func selectedClusterNames(resource *unstructured.Unstructured, clusters []*fedv1
 	}
 
 	selectedNames := sets.String{}
+	if failover := placement.Spec.Placement.Failover; failover != nil {
+		// Preferred clusters are the candidates for failover
+		// placement.
+		for _, cluster := range failover.PreferredClusters {
+			selectedNames.Insert(cluster.Name)
+		}
+		return selectedNames, nil
+	}
+
 	clusterNames := placement.ClusterNames()
 	if len(clusterNames) > 0 {
 		// Explicit cluster names take precedence over a selector.
@@ -112,3 +145,160 @@ func getClusterNames(clusters []*fedv1a1.KubefedCluster) sets.String {
 	}
 	return clusterNames
 }
+
+// applyFailover selects clusters from the candidates in the order of
+// preference of the given failover placement.
+//
+// A previously selected cluster that is unavailable remains selected
+// until it has been unavailable for longer than the configured
+// duration, and recheckAfter indicates when that will be the case.
+// Clusters that were skipped due to being unavailable are returned as
+// replaced.
+func applyFailover(failover *fedv1a1.FailoverPlacement, candidates sets.String, clusters []*fedv1a1.KubefedCluster,
+	previousClusters []string, now time.Time) (selected, replaced sets.String, recheckAfter time.Duration) {
+
+	clusterCount := int(failover.ClusterCount)
+	if clusterCount <= 0 {
+		clusterCount = 1
+	}
+	var replaceAfter time.Duration
+	if failover.ReplaceUnavailableAfter != nil {
+		replaceAfter = failover.ReplaceUnavailableAfter.Duration
+	}
+	clusterMap := make(map[string]*fedv1a1.KubefedCluster)
+	for _, cluster := range clusters {
+		clusterMap[cluster.Name] = cluster
+	}
+	previous := sets.NewString(previousClusters...)
+
+	selected = sets.String{}
+	replaced = sets.String{}
+	for _, clusterRef := range failover.PreferredClusters {
+		if selected.Len() >= clusterCount {
+			break
+		}
+		clusterName := clusterRef.Name
+		cluster, ok := clusterMap[clusterName]
+		if !ok || !candidates.Has(clusterName) {
+			continue
+		}
+		if util.IsClusterReady(&cluster.Status) {
+			selected.Insert(clusterName)
+			continue
+		}
+		if previous.Has(clusterName) {
+			remaining := replaceAfter - unavailableDuration(cluster, now)
+			if remaining > 0 {
+				// Tolerate the unavailability of a selected cluster
+				// for the configured duration.
+				selected.Insert(clusterName)
+				if recheckAfter == 0 || remaining < recheckAfter {
+					recheckAfter = remaining
+				}
+				continue
+			}
+		}
+		replaced.Insert(clusterName)
+	}
+	return selected, replaced, recheckAfter
+}
+
+// unavailableDuration returns how long a cluster that is not ready
+// has been unavailable, as recorded by the transition time of the
+// condition indicating that the cluster is unavailable.
+func unavailableDuration(cluster *fedv1a1.KubefedCluster, now time.Time) time.Duration {
+	condition := util.ClusterUnavailableCondition(&cluster.Status)
+	if condition == nil || condition.LastTransitionTime.IsZero() {
+		// A cluster that has never reported its status is considered
+		// to have been unavailable indefinitely.
+		return time.Duration(math.MaxInt64)
+	}
+	return now.Sub(condition.LastTransitionTime.Time)
+}
+
+// applySpreadConstraints selects a subset of the candidate clusters
+// that satisfies the given constraints.
+//
+// Candidates are grouped into topology domains (a domain per cluster
+// if spreadBy is not set) and at most one cluster is selected from
+// each domain.  To ensure that placement is stable across
+// reconciliations, previously selected clusters and their domains are
+// preferred.  Otherwise domains and clusters are considered in name
+// order.
+func applySpreadConstraints(constraints *fedv1a1.SpreadConstraints, candidates sets.String,
+	clusters []*fedv1a1.KubefedCluster, previousClusters []string) (sets.String, error) {
+
+	previous := sets.NewString(previousClusters...)
+	domainClusters := make(map[string][]string)
+	for _, cluster := range clusters {
+		if !candidates.Has(cluster.Name) {
+			continue
+		}
+		domain, err := topologyDomain(constraints, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if domain == "" {
+			continue
+		}
+		domainClusters[domain] = append(domainClusters[domain], cluster.Name)
+	}
+
+	domains := []string{}
+	preferredDomains := sets.String{}
+	for domain, clusterNames := range domainClusters {
+		domains = append(domains, domain)
+		sort.Slice(clusterNames, func(i, j int) bool {
+			if previous.Has(clusterNames[i]) != previous.Has(clusterNames[j]) {
+				return previous.Has(clusterNames[i])
+			}
+			return clusterNames[i] < clusterNames[j]
+		})
+		if previous.Has(clusterNames[0]) {
+			preferredDomains.Insert(domain)
+		}
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if preferredDomains.Has(domains[i]) != preferredDomains.Has(domains[j]) {
+			return preferredDomains.Has(domains[i])
+		}
+		return domains[i] < domains[j]
+	})
+
+	selected := sets.String{}
+	for _, domain := range domains {
+		if constraints.MaxClusters > 0 && selected.Len() >= int(constraints.MaxClusters) {
+			break
+		}
+		selected.Insert(domainClusters[domain][0])
+	}
+
+	if selected.Len() < int(constraints.MinClusters) {
+		return nil, errors.Errorf("only %d of a minimum of %d clusters could be selected by spread constraints",
+			selected.Len(), constraints.MinClusters)
+	}
+	return selected, nil
+}
+
+// topologyDomain returns the topology domain of a cluster for the
+// given constraints.  An empty domain indicates that the cluster
+// cannot be selected.
+func topologyDomain(constraints *fedv1a1.SpreadConstraints, cluster *fedv1a1.KubefedCluster) (string, error) {
+	switch constraints.SpreadBy {
+	case "":
+		return cluster.Name, nil
+	case fedv1a1.SpreadByRegion:
+		return cluster.Status.Region, nil
+	case fedv1a1.SpreadByZone:
+		zones := append([]string{}, cluster.Status.Zones...)
+		sort.Strings(zones)
+		return strings.Join(zones, ","), nil
+	case fedv1a1.SpreadByLabel:
+		if constraints.LabelKey == "" {
+			return "", errors.New("labelKey must be provided to spread by label")
+		}
+		return cluster.Labels[constraints.LabelKey], nil
+	default:
+		return "", errors.Errorf("unknown spreadBy value %q", constraints.SpreadBy)
+	}
+}
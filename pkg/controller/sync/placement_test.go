@@ -19,11 +19,14 @@ package sync
 import (
 	"reflect"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"sigs.k8s.io/kubefed/pkg/apis/core/common"
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
@@ -98,3 +101,236 @@ func TestSelectedClusterNames(t *testing.T) {
 		})
 	}
 }
+
+func TestApplySpreadConstraints(t *testing.T) {
+	newCluster := func(name, region string, labels map[string]string) *fedv1a1.KubefedCluster {
+		return &fedv1a1.KubefedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Status: fedv1a1.KubefedClusterStatus{
+				Region: region,
+			},
+		}
+	}
+	clusters := []*fedv1a1.KubefedCluster{
+		newCluster("cluster4", "us", map[string]string{"tier": "b"}),
+		newCluster("cluster3", "eu", map[string]string{"tier": "b"}),
+		newCluster("cluster2", "eu", map[string]string{"tier": "a"}),
+		newCluster("cluster1", "", map[string]string{"tier": "a"}),
+	}
+	candidates := sets.NewString("cluster1", "cluster2", "cluster3", "cluster4")
+
+	testCases := map[string]struct {
+		constraints      fedv1a1.SpreadConstraints
+		candidates       sets.String
+		previousClusters []string
+		expectedNames    sets.String
+		expectedError    bool
+	}{
+		"max clusters selected in name order": {
+			constraints:   fedv1a1.SpreadConstraints{MaxClusters: 2},
+			expectedNames: sets.NewString("cluster1", "cluster2"),
+		},
+		"previous clusters preferred": {
+			constraints:      fedv1a1.SpreadConstraints{MaxClusters: 2},
+			previousClusters: []string{"cluster4", "cluster5"},
+			expectedNames:    sets.NewString("cluster1", "cluster4"),
+		},
+		"one cluster per region": {
+			constraints:   fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByRegion},
+			expectedNames: sets.NewString("cluster2", "cluster4"),
+		},
+		"previous cluster preferred within region": {
+			constraints:      fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByRegion, MaxClusters: 1},
+			previousClusters: []string{"cluster3"},
+			expectedNames:    sets.NewString("cluster3"),
+		},
+		"one cluster per label value": {
+			constraints:   fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByLabel, LabelKey: "tier"},
+			expectedNames: sets.NewString("cluster1", "cluster3"),
+		},
+		"only candidates selected": {
+			constraints:   fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByRegion},
+			candidates:    sets.NewString("cluster1", "cluster3"),
+			expectedNames: sets.NewString("cluster3"),
+		},
+		"fewer than min clusters": {
+			constraints:   fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByRegion, MinClusters: 3},
+			expectedError: true,
+		},
+		"label key missing": {
+			constraints:   fedv1a1.SpreadConstraints{SpreadBy: fedv1a1.SpreadByLabel},
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			testCandidates := candidates
+			if testCase.candidates != nil {
+				testCandidates = testCase.candidates
+			}
+			selectedNames, err := applySpreadConstraints(&testCase.constraints, testCandidates, clusters, testCase.previousClusters)
+			if testCase.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(selectedNames, testCase.expectedNames) {
+				t.Fatalf("Expected names %v, got %v", testCase.expectedNames, selectedNames)
+			}
+		})
+	}
+}
+
+func TestApplyFailover(t *testing.T) {
+	now := time.Now()
+	newCluster := func(name string, ready bool, unavailableFor time.Duration) *fedv1a1.KubefedCluster {
+		condition := fedv1a1.ClusterCondition{
+			Type:   common.ClusterReady,
+			Status: corev1.ConditionTrue,
+		}
+		if !ready {
+			condition.Status = corev1.ConditionFalse
+			condition.LastTransitionTime = metav1.NewTime(now.Add(-unavailableFor))
+		}
+		return &fedv1a1.KubefedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Status: fedv1a1.KubefedClusterStatus{
+				Conditions: []fedv1a1.ClusterCondition{condition},
+			},
+		}
+	}
+	// The conditions of health checks precede the Ready and Offline
+	// conditions and have transitioned more recently.
+	withConditions := func(name string, conditions ...fedv1a1.ClusterCondition) *fedv1a1.KubefedCluster {
+		return &fedv1a1.KubefedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Status: fedv1a1.KubefedClusterStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+	condition := func(conditionType common.ClusterConditionType, status corev1.ConditionStatus, transitionedAgo time.Duration) fedv1a1.ClusterCondition {
+		return fedv1a1.ClusterCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(now.Add(-transitionedAgo)),
+		}
+	}
+	failover := &fedv1a1.FailoverPlacement{
+		PreferredClusters: []fedv1a1.ClusterReference{
+			{Name: "cluster-a"},
+			{Name: "cluster-b"},
+			{Name: "cluster-c"},
+		},
+		ReplaceUnavailableAfter: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	candidates := sets.NewString("cluster-a", "cluster-b", "cluster-c")
+
+	testCases := map[string]struct {
+		clusters             []*fedv1a1.KubefedCluster
+		previousClusters     []string
+		expectedNames        sets.String
+		expectedReplaced     sets.String
+		expectedRecheckAfter time.Duration
+	}{
+		"most preferred available cluster selected": {
+			clusters: []*fedv1a1.KubefedCluster{
+				newCluster("cluster-a", true, 0),
+				newCluster("cluster-b", true, 0),
+			},
+			expectedNames:    sets.NewString("cluster-a"),
+			expectedReplaced: sets.NewString(),
+		},
+		"unavailable cluster that was not selected is skipped": {
+			clusters: []*fedv1a1.KubefedCluster{
+				newCluster("cluster-a", false, time.Minute),
+				newCluster("cluster-b", true, 0),
+			},
+			expectedNames:    sets.NewString("cluster-b"),
+			expectedReplaced: sets.NewString("cluster-a"),
+		},
+		"selected cluster tolerated while briefly unavailable": {
+			clusters: []*fedv1a1.KubefedCluster{
+				newCluster("cluster-a", false, time.Minute),
+				newCluster("cluster-b", true, 0),
+			},
+			previousClusters:     []string{"cluster-a"},
+			expectedNames:        sets.NewString("cluster-a"),
+			expectedReplaced:     sets.NewString(),
+			expectedRecheckAfter: 4 * time.Minute,
+		},
+		"selected cluster replaced after being unavailable too long": {
+			clusters: []*fedv1a1.KubefedCluster{
+				newCluster("cluster-a", false, 10*time.Minute),
+				newCluster("cluster-b", false, 10*time.Minute),
+				newCluster("cluster-c", true, 0),
+			},
+			previousClusters: []string{"cluster-a"},
+			expectedNames:    sets.NewString("cluster-c"),
+			expectedReplaced: sets.NewString("cluster-a", "cluster-b"),
+		},
+		"not ready cluster with health check conditions replaced": {
+			clusters: []*fedv1a1.KubefedCluster{
+				withConditions("cluster-a",
+					condition("NodesReady", corev1.ConditionFalse, time.Minute),
+					condition(common.ClusterReady, corev1.ConditionFalse, 10*time.Minute),
+					condition(common.ClusterOffline, corev1.ConditionFalse, time.Hour),
+					condition("Latency", corev1.ConditionTrue, time.Second),
+				),
+				newCluster("cluster-b", true, 0),
+			},
+			previousClusters: []string{"cluster-a"},
+			expectedNames:    sets.NewString("cluster-b"),
+			expectedReplaced: sets.NewString("cluster-a"),
+		},
+		"offline cluster with health check conditions tolerated": {
+			clusters: []*fedv1a1.KubefedCluster{
+				withConditions("cluster-a",
+					condition("NodesReady", corev1.ConditionUnknown, 10*time.Minute),
+					condition(common.ClusterOffline, corev1.ConditionTrue, time.Minute),
+				),
+				newCluster("cluster-b", true, 0),
+			},
+			previousClusters:     []string{"cluster-a"},
+			expectedNames:        sets.NewString("cluster-a"),
+			expectedReplaced:     sets.NewString(),
+			expectedRecheckAfter: 4 * time.Minute,
+		},
+		"fail back to recovered cluster": {
+			clusters: []*fedv1a1.KubefedCluster{
+				newCluster("cluster-a", true, 0),
+				newCluster("cluster-b", true, 0),
+			},
+			previousClusters: []string{"cluster-b"},
+			expectedNames:    sets.NewString("cluster-a"),
+			expectedReplaced: sets.NewString(),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			selectedNames, replaced, recheckAfter := applyFailover(failover, candidates, testCase.clusters, testCase.previousClusters, now)
+			if !reflect.DeepEqual(selectedNames, testCase.expectedNames) {
+				t.Fatalf("Expected names %v, got %v", testCase.expectedNames, selectedNames)
+			}
+			if !reflect.DeepEqual(replaced, testCase.expectedReplaced) {
+				t.Fatalf("Expected replaced %v, got %v", testCase.expectedReplaced, replaced)
+			}
+			if recheckAfter != testCase.expectedRecheckAfter {
+				t.Fatalf("Expected recheck after %v, got %v", testCase.expectedRecheckAfter, recheckAfter)
+			}
+		})
+	}
+}
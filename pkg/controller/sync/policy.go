@@ -0,0 +1,405 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+const (
+	propagationPolicyKind        = "PropagationPolicy"
+	clusterPropagationPolicyKind = "ClusterPropagationPolicy"
+	overridePolicyKind           = "OverridePolicy"
+	clusterOverridePolicyKind    = "ClusterOverridePolicy"
+)
+
+// policyAccessor resolves the propagation and override policies that
+// select a federated resource.
+//
+// Where more than one policy of a given type selects a federated
+// resource, the policy with the highest precedence is used:
+//
+//   - a namespaced policy takes precedence over a cluster-scoped policy
+//   - then the policy with the highest priority takes precedence
+//   - then the policy whose name sorts first takes precedence
+type policyAccessor struct {
+	// Stores of the namespaced policies in the target namespace.
+	propagationPolicyStore cache.Store
+	overridePolicyStore    cache.Store
+
+	// Stores of cluster-scoped policies and the namespaces their
+	// namespace selectors are matched against.  Will only be
+	// initialized if federation is deployed cluster-wide.
+	clusterPropagationPolicyStore cache.Store
+	clusterOverridePolicyStore    cache.Store
+	namespaceStore                cache.Store
+
+	// The shared informers the stores belong to.  Nil if the stores
+	// are populated directly.
+	informers *util.PolicyInformers
+
+	enqueueNamespace func(namespace string)
+	enqueueAll       func()
+}
+
+// newPolicyAccessor returns an accessor reading policies from the
+// shared policy informers of the given configuration.
+func newPolicyAccessor(controllerConfig *util.ControllerConfig, enqueueNamespace func(namespace string), enqueueAll func()) (*policyAccessor, error) {
+	informers := controllerConfig.PolicyInformers
+	if informers == nil {
+		return nil, errors.New("Policy informers have not been configured")
+	}
+	a := &policyAccessor{
+		propagationPolicyStore: informers.PropagationPolicies.GetStore(),
+		overridePolicyStore:    informers.OverridePolicies.GetStore(),
+		informers:              informers,
+		enqueueNamespace:       enqueueNamespace,
+		enqueueAll:             enqueueAll,
+	}
+	if informers.Namespaces != nil {
+		a.clusterPropagationPolicyStore = informers.ClusterPropagationPolicies.GetStore()
+		a.clusterOverridePolicyStore = informers.ClusterOverridePolicies.GetStore()
+		a.namespaceStore = informers.Namespaces.GetStore()
+	}
+	return a, nil
+}
+
+// Run registers handlers with the shared informers that enqueue the
+// federated resources affected by a change until the given channel
+// is closed.  The informers are run by their owner.
+func (a *policyAccessor) Run(stopChan <-chan struct{}) {
+	// Handlers cannot be removed from a shared informer, so a handler
+	// ignores changes once the controller has stopped.
+	whileRunning := func(triggerFunc func(pkgruntime.Object)) cache.ResourceEventHandler {
+		return util.NewTriggerOnAllChanges(func(obj pkgruntime.Object) {
+			select {
+			case <-stopChan:
+			default:
+				triggerFunc(obj)
+			}
+		})
+	}
+
+	// A change to a namespaced policy may affect any federated
+	// resource in its namespace.
+	namespacedPolicyEnqueue := whileRunning(func(obj pkgruntime.Object) {
+		a.enqueueNamespace(util.NewQualifiedName(obj).Namespace)
+	})
+	a.informers.PropagationPolicies.AddEventHandler(namespacedPolicyEnqueue)
+	a.informers.OverridePolicies.AddEventHandler(namespacedPolicyEnqueue)
+
+	if a.informers.Namespaces == nil {
+		return
+	}
+
+	// A change to a cluster-scoped policy may affect any federated
+	// resource.
+	clusterPolicyEnqueue := whileRunning(func(pkgruntime.Object) {
+		a.enqueueAll()
+	})
+	a.informers.ClusterPropagationPolicies.AddEventHandler(clusterPolicyEnqueue)
+	a.informers.ClusterOverridePolicies.AddEventHandler(clusterPolicyEnqueue)
+
+	// A change to the labels of a namespace may change whether the
+	// federated resources it contains are selected by a cluster-scoped
+	// policy.
+	a.informers.Namespaces.AddEventHandler(whileRunning(func(obj pkgruntime.Object) {
+		a.enqueueNamespace(util.NewQualifiedName(obj).Name)
+	}))
+}
+
+func (a *policyAccessor) HasSynced() bool {
+	return a.informers.HasSynced()
+}
+
+// propagationPolicyForResource returns the propagation policy with
+// the highest precedence that selects the given federated resource,
+// or nil if no policy selects the resource.
+func (a *policyAccessor) propagationPolicyForResource(kind string, resource *unstructured.Unstructured) (*resolvedPropagationPolicy, error) {
+	candidates := []policyCandidate{}
+	placements := []fedv1a1.PolicyPlacement{}
+	for _, obj := range a.propagationPolicyStore.List() {
+		policy := obj.(*fedv1a1.PropagationPolicy)
+		candidates = append(candidates, newPolicyCandidate(propagationPolicyKind, policy.ObjectMeta, policy.Spec.Priority, policy.Spec.ResourceSelector))
+		placements = append(placements, policy.Spec.Placement)
+	}
+	if a.clusterPropagationPolicyStore != nil {
+		for _, obj := range a.clusterPropagationPolicyStore.List() {
+			policy := obj.(*fedv1a1.ClusterPropagationPolicy)
+			candidates = append(candidates, newPolicyCandidate(clusterPropagationPolicyKind, policy.ObjectMeta, policy.Spec.Priority, policy.Spec.ResourceSelector))
+			placements = append(placements, policy.Spec.Placement)
+		}
+	}
+
+	namespaceLabels, err := a.namespaceLabels(resource)
+	if err != nil {
+		return nil, err
+	}
+	index := selectPolicy(candidates, kind, resource, namespaceLabels)
+	if index < 0 {
+		return nil, nil
+	}
+	return &resolvedPropagationPolicy{
+		reference: candidates[index].reference,
+		placement: placements[index],
+	}, nil
+}
+
+// overridePolicyForResource returns the override policy with the
+// highest precedence that selects the given federated resource, or
+// nil if no policy selects the resource.
+func (a *policyAccessor) overridePolicyForResource(kind string, resource *unstructured.Unstructured) (*resolvedOverridePolicy, error) {
+	candidates := []policyCandidate{}
+	overrides := [][]fedv1a1.PolicyOverride{}
+	for _, obj := range a.overridePolicyStore.List() {
+		policy := obj.(*fedv1a1.OverridePolicy)
+		candidates = append(candidates, newPolicyCandidate(overridePolicyKind, policy.ObjectMeta, policy.Spec.Priority, policy.Spec.ResourceSelector))
+		overrides = append(overrides, policy.Spec.Overrides)
+	}
+	if a.clusterOverridePolicyStore != nil {
+		for _, obj := range a.clusterOverridePolicyStore.List() {
+			policy := obj.(*fedv1a1.ClusterOverridePolicy)
+			candidates = append(candidates, newPolicyCandidate(clusterOverridePolicyKind, policy.ObjectMeta, policy.Spec.Priority, policy.Spec.ResourceSelector))
+			overrides = append(overrides, policy.Spec.Overrides)
+		}
+	}
+
+	namespaceLabels, err := a.namespaceLabels(resource)
+	if err != nil {
+		return nil, err
+	}
+	index := selectPolicy(candidates, kind, resource, namespaceLabels)
+	if index < 0 {
+		return nil, nil
+	}
+	return &resolvedOverridePolicy{
+		reference: candidates[index].reference,
+		overrides: overrides[index],
+	}, nil
+}
+
+// namespaceLabels returns the labels of the namespace containing the
+// given federated resource.  Nil will be returned for a cluster-scoped
+// resource or if cluster-scoped policies are not being considered.
+func (a *policyAccessor) namespaceLabels(resource *unstructured.Unstructured) (labels.Set, error) {
+	namespace := resource.GetNamespace()
+	if a.namespaceStore == nil || namespace == "" {
+		return nil, nil
+	}
+	obj, exists, err := a.namespaceStore.GetByKey(namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve namespace %q from cache", namespace)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return labels.Set(obj.(*corev1.Namespace).Labels), nil
+}
+
+type policyCandidate struct {
+	reference status.PolicyReference
+	priority  int32
+	selector  fedv1a1.PolicyResourceSelector
+}
+
+func newPolicyCandidate(kind string, objMeta metav1.ObjectMeta, priority int32, selector fedv1a1.PolicyResourceSelector) policyCandidate {
+	return policyCandidate{
+		reference: status.PolicyReference{
+			Kind:      kind,
+			Namespace: objMeta.Namespace,
+			Name:      objMeta.Name,
+		},
+		priority: priority,
+		selector: selector,
+	}
+}
+
+func (c policyCandidate) namespaced() bool {
+	return c.reference.Namespace != ""
+}
+
+// hasPrecedenceOver indicates whether the candidate takes precedence
+// over another candidate that selects the same federated resource.
+func (c policyCandidate) hasPrecedenceOver(other policyCandidate) bool {
+	if c.namespaced() != other.namespaced() {
+		return c.namespaced()
+	}
+	if c.priority != other.priority {
+		return c.priority > other.priority
+	}
+	return c.reference.Name < other.reference.Name
+}
+
+// selects indicates whether the candidate selects the given federated
+// resource.
+func (c policyCandidate) selects(kind string, resource *unstructured.Unstructured, namespaceLabels labels.Set) (bool, error) {
+	if len(c.selector.Kinds) > 0 && !sets.NewString(c.selector.Kinds...).Has(kind) {
+		return false, nil
+	}
+
+	if c.namespaced() {
+		// A namespaced policy can only select resources in its own
+		// namespace.
+		if c.reference.Namespace != resource.GetNamespace() {
+			return false, nil
+		}
+	} else if c.selector.NamespaceSelector != nil {
+		if namespaceLabels == nil {
+			return false, nil
+		}
+		selector, err := metav1.LabelSelectorAsSelector(c.selector.NamespaceSelector)
+		if err != nil {
+			return false, errors.Wrap(err, "Invalid namespace selector")
+		}
+		if !selector.Matches(namespaceLabels) {
+			return false, nil
+		}
+	}
+
+	if c.selector.LabelSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(c.selector.LabelSelector)
+	if err != nil {
+		return false, errors.Wrap(err, "Invalid label selector")
+	}
+	return selector.Matches(labels.Set(resource.GetLabels())), nil
+}
+
+// selectPolicy returns the index of the candidate with the highest
+// precedence that selects the given federated resource, or -1 if no
+// candidate selects the resource.  A candidate with an invalid
+// selector is ignored to avoid a single misconfigured policy
+// preventing the propagation of every resource.
+func selectPolicy(candidates []policyCandidate, kind string, resource *unstructured.Unstructured, namespaceLabels labels.Set) int {
+	selected := -1
+	for i, candidate := range candidates {
+		ok, err := candidate.selects(kind, resource, namespaceLabels)
+		if err != nil {
+			runtime.HandleError(errors.Wrapf(err, "Ignoring %s", candidate.reference))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if selected < 0 || candidate.hasPrecedenceOver(candidates[selected]) {
+			selected = i
+		}
+	}
+	if selected >= 0 {
+		klog.V(4).Infof("Resolved %s for %s %q", candidates[selected].reference, kind, util.NewQualifiedName(resource))
+	}
+	return selected
+}
+
+type resolvedPropagationPolicy struct {
+	reference status.PolicyReference
+	placement fedv1a1.PolicyPlacement
+}
+
+// applyTo returns a copy of the given federated resource with its
+// placement set to the placement of the policy.
+func (p *resolvedPropagationPolicy) applyTo(resource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	placement, err := pkgruntime.DefaultUnstructuredConverter.ToUnstructured(&p.placement)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading placement of %s", p.reference)
+	}
+	obj := resource.DeepCopy()
+	err = unstructured.SetNestedField(obj.Object, placement, util.SpecField, util.PlacementField)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error applying placement of %s", p.reference)
+	}
+	return obj, nil
+}
+
+type resolvedOverridePolicy struct {
+	reference status.PolicyReference
+	overrides []fedv1a1.PolicyOverride
+}
+
+// overridesForClusters resolves the overrides of the policy for the
+// given clusters.  The overrides are validated in the same way as the
+// overrides of a federated resource.
+func (p *resolvedOverridePolicy) overridesForClusters(clusters []*fedv1a1.KubefedCluster) (util.OverridesMap, error) {
+	overridesJSON, err := json.Marshal(p.overrides)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading overrides of %s", p.reference)
+	}
+	rawOverrides := []interface{}{}
+	if err := json.Unmarshal(overridesJSON, &rawOverrides); err != nil {
+		return nil, errors.Wrapf(err, "Error reading overrides of %s", p.reference)
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			util.SpecField: map[string]interface{}{
+				util.OverridesField: rawOverrides,
+			},
+		},
+	}
+	overridesMap, err := util.GetOverridesForClusters(obj, clusters)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading overrides of %s", p.reference)
+	}
+	return overridesMap, nil
+}
+
+// mergeOverrides combines the overrides of a policy with the
+// overrides of a federated resource.  For a given cluster, an
+// override defined by the resource replaces a policy override for the
+// same path.  Policy overrides are applied before resource overrides.
+func mergeOverrides(policyOverrides, resourceOverrides util.OverridesMap) util.OverridesMap {
+	merged := util.OverridesMap{}
+	for clusterName, overrides := range policyOverrides {
+		resourcePaths := sets.String{}
+		for _, override := range resourceOverrides[clusterName] {
+			resourcePaths.Insert(override.Path)
+		}
+		for _, override := range overrides {
+			if !resourcePaths.Has(override.Path) {
+				merged[clusterName] = append(merged[clusterName], override)
+			}
+		}
+	}
+	for clusterName, overrides := range resourceOverrides {
+		merged[clusterName] = append(merged[clusterName], overrides...)
+	}
+	return merged
+}
+
+// hasPlacement indicates whether the federated resource defines its
+// own placement.  Placement defined by a resource takes precedence
+// over placement defined by a propagation policy.
+func hasPlacement(resource *unstructured.Unstructured) bool {
+	_, ok, _ := unstructured.NestedFieldNoCopy(resource.Object, util.SpecField, util.PlacementField)
+	return ok
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func newCandidate(kind, namespace, name string, priority int32, selector fedv1a1.PolicyResourceSelector) policyCandidate {
+	objMeta := metav1.ObjectMeta{Namespace: namespace, Name: name}
+	return newPolicyCandidate(kind, objMeta, priority, selector)
+}
+
+func TestSelectPolicy(t *testing.T) {
+	resource := &unstructured.Unstructured{}
+	resource.SetNamespace("ns1")
+	resource.SetName("foo")
+	resource.SetLabels(map[string]string{"app": "foo"})
+	namespaceLabels := labels.Set{"env": "prod"}
+
+	appSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}
+
+	testCases := map[string]struct {
+		candidates    []policyCandidate
+		expectedIndex int
+	}{
+		"no candidates": {
+			expectedIndex: -1,
+		},
+		"kind not selected": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns1", "p1", 0, fedv1a1.PolicyResourceSelector{Kinds: []string{"FederatedSecret"}}),
+			},
+			expectedIndex: -1,
+		},
+		"namespaced policy in another namespace": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns2", "p1", 0, fedv1a1.PolicyResourceSelector{}),
+			},
+			expectedIndex: -1,
+		},
+		"labels not selected": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns1", "p1", 0, fedv1a1.PolicyResourceSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "bar"}},
+				}),
+			},
+			expectedIndex: -1,
+		},
+		"namespace labels not selected": {
+			candidates: []policyCandidate{
+				newCandidate(clusterPropagationPolicyKind, "", "p1", 0, fedv1a1.PolicyResourceSelector{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "test"}},
+				}),
+			},
+			expectedIndex: -1,
+		},
+		"namespaced policy takes precedence over cluster policy": {
+			candidates: []policyCandidate{
+				newCandidate(clusterPropagationPolicyKind, "", "p1", 10, fedv1a1.PolicyResourceSelector{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				}),
+				newCandidate(propagationPolicyKind, "ns1", "p2", 0, fedv1a1.PolicyResourceSelector{
+					Kinds:         []string{"FederatedDeployment"},
+					LabelSelector: appSelector,
+				}),
+			},
+			expectedIndex: 1,
+		},
+		"higher priority takes precedence": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns1", "p1", 1, fedv1a1.PolicyResourceSelector{}),
+				newCandidate(propagationPolicyKind, "ns1", "p2", 2, fedv1a1.PolicyResourceSelector{}),
+			},
+			expectedIndex: 1,
+		},
+		"lowest name takes precedence for equal priority": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns1", "p2", 0, fedv1a1.PolicyResourceSelector{}),
+				newCandidate(propagationPolicyKind, "ns1", "p1", 0, fedv1a1.PolicyResourceSelector{}),
+			},
+			expectedIndex: 1,
+		},
+		"invalid selector is ignored": {
+			candidates: []policyCandidate{
+				newCandidate(propagationPolicyKind, "ns1", "p1", 10, fedv1a1.PolicyResourceSelector{
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Bogus"}},
+					},
+				}),
+				newCandidate(propagationPolicyKind, "ns1", "p2", 0, fedv1a1.PolicyResourceSelector{}),
+			},
+			expectedIndex: 1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			index := selectPolicy(testCase.candidates, "FederatedDeployment", resource, namespaceLabels)
+			if index != testCase.expectedIndex {
+				t.Fatalf("Expected index %d, got %d", testCase.expectedIndex, index)
+			}
+		})
+	}
+}
+
+func TestResolvedPolicyOverrides(t *testing.T) {
+	clusters := []*fedv1a1.KubefedCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster1",
+				Labels: map[string]string{"region": "eu"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster2",
+			},
+		},
+	}
+	policy := &resolvedOverridePolicy{
+		overrides: []fedv1a1.PolicyOverride{
+			{
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+				ClusterOverrides: []pkgruntime.RawExtension{
+					{Raw: []byte(`{"path": "/spec/replicas", "value": 2}`)},
+					{Raw: []byte(`{"path": "/spec/paused", "value": true}`)},
+				},
+			},
+		},
+	}
+	policyOverrides, err := policy.overridesForClusters(clusters)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resourceOverrides := util.OverridesMap{
+		"cluster1": util.ClusterOverrides{{Path: "/spec/replicas", Value: int64(3)}},
+		"cluster2": util.ClusterOverrides{{Path: "/spec/replicas", Value: int64(1)}},
+	}
+	expected := util.OverridesMap{
+		"cluster1": util.ClusterOverrides{
+			{Path: "/spec/paused", Value: true},
+			{Path: "/spec/replicas", Value: int64(3)},
+		},
+		"cluster2": util.ClusterOverrides{{Path: "/spec/replicas", Value: int64(1)}},
+	}
+	merged := mergeOverrides(policyOverrides, resourceOverrides)
+	if !reflect.DeepEqual(expected, merged) {
+		t.Fatalf("Expected %v, got %v", expected, merged)
+	}
+
+	policy.overrides[0].ClusterOverrides = []pkgruntime.RawExtension{
+		{Raw: []byte(`{"path": "/metadata/name", "value": "foo"}`)},
+	}
+	if _, err := policy.overridesForClusters(clusters); err == nil {
+		t.Fatalf("Expected an error for an invalid path")
+	}
+}
+
+func TestApplyPropagationPolicy(t *testing.T) {
+	resource := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			util.SpecField: map[string]interface{}{},
+		},
+	}
+	if hasPlacement(resource) {
+		t.Fatalf("Expected resource to have no placement")
+	}
+	policy := &resolvedPropagationPolicy{
+		placement: fedv1a1.PolicyPlacement{
+			Clusters: []fedv1a1.ClusterReference{{Name: "cluster1"}},
+		},
+	}
+	obj, err := policy.applyTo(resource)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hasPlacement(resource) {
+		t.Fatalf("Expected the federated resource to be unchanged")
+	}
+	clusterNames, err := util.GetClusterNames(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual([]string{"cluster1"}, clusterNames) {
+		t.Fatalf("Expected placement of cluster1, got %v", clusterNames)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/kubefed/pkg/apis/core/typeconfig"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/dispatch"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/version"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// PreviewOperation is the operation the sync controller would perform
+// in a member cluster.
+type PreviewOperation string
+
+const (
+	PreviewCreate             PreviewOperation = "Create"
+	PreviewAdopt              PreviewOperation = "Adopt"
+	PreviewUpdate             PreviewOperation = "Update"
+	PreviewDelete             PreviewOperation = "Delete"
+	PreviewRemoveManagedLabel PreviewOperation = "RemoveManagedLabel"
+	PreviewAlreadyExists      PreviewOperation = "AlreadyExists"
+	PreviewNone               PreviewOperation = "None"
+	PreviewClusterNotReady    PreviewOperation = "ClusterNotReady"
+)
+
+// PreviewInput is the state the sync controller would read from its
+// informers when propagating a federated resource.
+type PreviewInput struct {
+	TypeConfig        typeconfig.Interface
+	FederatedResource *unstructured.Unstructured
+
+	// The federated namespace containing the federated resource, if
+	// the resource is namespaced and the federated namespace exists.
+	FederatedNamespace *unstructured.Unstructured
+	// The namespace in the host cluster, if the target is a namespace.
+	Namespace *unstructured.Unstructured
+
+	Clusters []*fedv1a1.KubefedCluster
+	// The target resource in each member cluster in which it
+	// exists, keyed by cluster name.
+	ClusterObjects map[string]*unstructured.Unstructured
+	// The status of the propagated version of the federated
+	// resource, if it has been propagated.  Without it, every
+	// managed resource is considered to require an update.
+	PropagatedVersion *fedv1a1.PropagatedVersionStatus
+
+	// Whether federation is limited to a single namespace
+	LimitedScope bool
+
+	// The adoption policy of types that do not specify one.  If not
+	// provided, existing resources are adopted.
+	DefaultAdoptionPolicy fedv1a1.AdoptionPolicy
+
+	// Policies to resolve for the federated resource.  Cluster-scoped
+	// policies are ignored if federation is limited to a single
+	// namespace.
+	PropagationPolicies        []fedv1a1.PropagationPolicy
+	ClusterPropagationPolicies []fedv1a1.ClusterPropagationPolicy
+	OverridePolicies           []fedv1a1.OverridePolicy
+	ClusterOverridePolicies    []fedv1a1.ClusterOverridePolicy
+	// The namespace containing the federated resource, used to match
+	// the namespace selectors of cluster-scoped policies.
+	ResourceNamespace *corev1.Namespace
+}
+
+// ClusterPreview describes the operation the sync controller would
+// perform in a member cluster.
+type ClusterPreview struct {
+	ClusterName string
+	Operation   PreviewOperation
+	// The target resource in the member cluster, or nil if it does
+	// not exist.
+	ClusterObject *unstructured.Unstructured
+	// The target resource that would be written to the member
+	// cluster for a create, adopt or update, or nil otherwise.
+	DesiredObject *unstructured.Unstructured
+	// The object that would be applied to the member cluster for an
+	// adopt or update if the type is propagated with server-side
+	// apply, or nil otherwise.
+	ApplyObject *unstructured.Unstructured
+}
+
+// Preview determines the operations the sync controller would
+// perform to propagate a federated resource to member clusters given
+// the provided state, without writing to the API.  As for the sync
+// controller, a managed resource requires an update if its version
+// differs from the propagated version for its cluster.  The rollout
+// strategy and drift policy of the resource are not considered, and
+// a resource that would be deleted after the grace period of the
+// DelayedDelete deletion policy is reported as deleted.
+func Preview(input *PreviewInput) ([]ClusterPreview, error) {
+	fedResource, err := newPreviewResource(input)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedClusterNames, err := fedResource.ComputePlacement(input.Clusters)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to compute placement")
+	}
+
+	// The versions are determined once placement has been computed
+	// since overrides may target clusters by label.
+	fedResource.versionMap = make(map[string]string)
+	if input.PropagatedVersion != nil {
+		fedResource.versionMap, err = version.VersionMap(fedResource, input.PropagatedVersion)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to determine propagated versions")
+		}
+	}
+
+	defaultAdoptionPolicy := input.DefaultAdoptionPolicy
+	if defaultAdoptionPolicy == "" {
+		defaultAdoptionPolicy = fedv1a1.AdoptionPolicyAlways
+	}
+	adoptionPolicy, err := fedResource.AdoptionPolicy(defaultAdoptionPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read adoption policy")
+	}
+
+	previews := []ClusterPreview{}
+	for _, cluster := range input.Clusters {
+		clusterName := cluster.Name
+		selectedCluster := selectedClusterNames.Has(clusterName)
+		clusterObj := input.ClusterObjects[clusterName]
+		preview := ClusterPreview{
+			ClusterName:   clusterName,
+			Operation:     PreviewNone,
+			ClusterObject: clusterObj,
+		}
+
+		if !util.IsClusterReady(&cluster.Status) {
+			if selectedCluster {
+				preview.Operation = PreviewClusterNotReady
+				previews = append(previews, preview)
+			}
+			continue
+		}
+
+		// Resources without the managed label are not visible to
+		// the sync controller.
+		managed := clusterObj != nil && util.HasManagedLabel(clusterObj)
+
+		if !selectedCluster {
+			if !managed || clusterObj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			deletionPolicy, _, err := fedResource.DeletionPolicy()
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to read deletion policy")
+			}
+			if fedResource.IsNamespaceInHostCluster(clusterObj) || util.IsAdopted(clusterObj) || deletionPolicy == fedv1a1.DeletionPolicyOrphan {
+				preview.Operation = PreviewRemoveManagedLabel
+			} else {
+				preview.Operation = PreviewDelete
+			}
+			previews = append(previews, preview)
+			continue
+		}
+
+		desiredObj, err := fedResource.ObjectForCluster(clusterName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to compute the resource for cluster %q", clusterName)
+		}
+		if clusterObj == nil {
+			preview.Operation = PreviewCreate
+			preview.DesiredObject = desiredObj
+			previews = append(previews, preview)
+			continue
+		}
+
+		serverSideApply := fedResource.ServerSideApply()
+		var applyObj *unstructured.Unstructured
+		if serverSideApply != nil {
+			applyObj, err = dispatch.ObjectForApply(desiredObj, fedResource.Object())
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to compute the resource to apply for cluster %q", clusterName)
+			}
+			util.RetainAdoptionAnnotations(applyObj, clusterObj)
+		}
+		err = dispatch.RetainClusterFields(fedResource.RetainFields(), desiredObj, clusterObj, fedResource.Object())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retain fields for cluster %q", clusterName)
+		}
+		if serverSideApply != nil {
+			dispatch.RetainUnownedMetadata(desiredObj, clusterObj)
+		}
+		if !managed {
+			if allowed, _ := util.AdoptionAllowed(adoptionPolicy, desiredObj, clusterObj); !allowed {
+				preview.Operation = PreviewAlreadyExists
+				previews = append(previews, preview)
+				continue
+			}
+			preview.Operation = PreviewAdopt
+			adoptedBy := fmt.Sprintf("%s %s", fedResource.FederatedKind(), fedResource.FederatedName())
+			adoptedAt := time.Now()
+			util.AddAdoptionAnnotations(desiredObj, adoptedAt, adoptedBy)
+			if applyObj != nil {
+				util.AddAdoptionAnnotations(applyObj, adoptedAt, adoptedBy)
+			}
+		} else {
+			recordedVersion, err := fedResource.VersionForCluster(clusterName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to determine the propagated version for cluster %q", clusterName)
+			}
+			if !util.ObjectNeedsUpdate(desiredObj, clusterObj, recordedVersion) {
+				previews = append(previews, preview)
+				continue
+			}
+			preview.Operation = PreviewUpdate
+		}
+		preview.DesiredObject = desiredObj
+		preview.ApplyObject = applyObj
+		previews = append(previews, preview)
+	}
+	sort.Slice(previews, func(i, j int) bool {
+		return previews[i].ClusterName < previews[j].ClusterName
+	})
+	return previews, nil
+}
+
+// newPreviewResource constructs a federated resource from the given
+// state in the same way as the resource accessor of the sync
+// controller constructs one from its informers.
+func newPreviewResource(input *PreviewInput) (*federatedResource, error) {
+	typeConfig := input.TypeConfig
+	resource := input.FederatedResource
+	kind := typeConfig.GetFederatedType().Kind
+	targetIsNamespace := typeConfig.GetTarget().Kind == util.NamespaceKind
+
+	federatedName := util.NewQualifiedName(resource)
+	targetName := federatedName
+	if targetIsNamespace {
+		targetName.Namespace = ""
+	}
+
+	policies, err := newPreviewPolicyAccessor(input)
+	if err != nil {
+		return nil, err
+	}
+	var propagationPolicy *resolvedPropagationPolicy
+	if !hasPlacement(resource) {
+		propagationPolicy, err = policies.propagationPolicyForResource(kind, resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+	overridePolicy, err := policies.overridePolicyForResource(kind, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedClusters, err := status.GetSelectedClusters(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &federatedResource{
+		limitedScope:      input.LimitedScope,
+		typeConfig:        typeConfig,
+		targetIsNamespace: targetIsNamespace,
+		targetName:        targetName,
+		federatedKind:     kind,
+		federatedName:     federatedName,
+		federatedResource: resource,
+		namespace:         input.Namespace,
+		fedNamespace:      input.FederatedNamespace,
+		propagationPolicy: propagationPolicy,
+		overridePolicy:    overridePolicy,
+		selectedClusters:  selectedClusters,
+		// Events are discarded
+		eventRecorder: &record.FakeRecorder{},
+	}, nil
+}
+
+// newPreviewPolicyAccessor returns a policy accessor whose stores are
+// populated with the policies of the given input.
+func newPreviewPolicyAccessor(input *PreviewInput) (*policyAccessor, error) {
+	a := &policyAccessor{
+		propagationPolicyStore: cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc),
+		overridePolicyStore:    cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc),
+	}
+	for i := range input.PropagationPolicies {
+		if err := a.propagationPolicyStore.Add(&input.PropagationPolicies[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range input.OverridePolicies {
+		if err := a.overridePolicyStore.Add(&input.OverridePolicies[i]); err != nil {
+			return nil, err
+		}
+	}
+	if input.LimitedScope {
+		return a, nil
+	}
+
+	a.clusterPropagationPolicyStore = cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)
+	a.clusterOverridePolicyStore = cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)
+	a.namespaceStore = cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)
+	for i := range input.ClusterPropagationPolicies {
+		if err := a.clusterPropagationPolicyStore.Add(&input.ClusterPropagationPolicies[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range input.ClusterOverridePolicies {
+		if err := a.clusterOverridePolicyStore.Add(&input.ClusterOverridePolicies[i]); err != nil {
+			return nil, err
+		}
+	}
+	if input.ResourceNamespace != nil {
+		if err := a.namespaceStore.Add(input.ResourceNamespace); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
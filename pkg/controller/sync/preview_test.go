@@ -0,0 +1,190 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubefed/pkg/apis/core/common"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func TestPreview(t *testing.T) {
+	typeConfig := &fedv1a1.FederatedTypeConfig{
+		Spec: fedv1a1.FederatedTypeConfigSpec{
+			Target: fedv1a1.APIResource{
+				Group:   "example.com",
+				Version: "v1",
+				Kind:    "Widget",
+			},
+			FederatedType: fedv1a1.APIResource{
+				Group:   "types.kubefed.k8s.io",
+				Version: "v1beta1",
+				Kind:    "FederatedWidget",
+			},
+		},
+	}
+	fedObj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "types.kubefed.k8s.io/v1beta1",
+			"kind":       "FederatedWidget",
+			"metadata": map[string]interface{}{
+				"name": "foo",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"size": "small",
+					},
+				},
+				"placement": map[string]interface{}{
+					"clusters": []interface{}{
+						map[string]interface{}{"name": "create"},
+						map[string]interface{}{"name": "adopt"},
+						map[string]interface{}{"name": "update"},
+						map[string]interface{}{"name": "unchanged"},
+						map[string]interface{}{"name": "unrecorded"},
+						map[string]interface{}{"name": "not-ready"},
+					},
+				},
+			},
+		},
+	}
+
+	newCluster := func(name string, ready bool) *fedv1a1.KubefedCluster {
+		condition := fedv1a1.ClusterCondition{
+			Type:   common.ClusterReady,
+			Status: corev1.ConditionTrue,
+		}
+		if !ready {
+			condition.Status = corev1.ConditionFalse
+		}
+		return &fedv1a1.KubefedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Status: fedv1a1.KubefedClusterStatus{
+				Conditions: []fedv1a1.ClusterCondition{condition},
+			},
+		}
+	}
+	newClusterObj := func(size string, managed bool) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name":            "foo",
+					"resourceVersion": "42",
+				},
+				"spec": map[string]interface{}{
+					"size":  size,
+					"color": "blue",
+				},
+			},
+		}
+		if managed {
+			util.AddManagedLabel(obj)
+		}
+		return obj
+	}
+
+	templateVersion, err := GetTemplateHash(fedObj.Object)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The version recorded for the cluster to update predates a change
+	// made in the cluster.  Without a recorded version, the fields of
+	// the resource that are not in the template would be removed by an
+	// update.
+	propagatedVersion := &fedv1a1.PropagatedVersionStatus{
+		TemplateVersion: templateVersion,
+		ClusterVersions: []fedv1a1.ClusterObjectVersion{
+			{ClusterName: "update", Version: "rv:41"},
+			{ClusterName: "unchanged", Version: "rv:42"},
+		},
+	}
+
+	adoptedClusterObj := newClusterObj("small", true)
+	util.AddAdoptionAnnotations(adoptedClusterObj, time.Now(), "FederatedWidget foo")
+
+	input := &PreviewInput{
+		TypeConfig:        typeConfig,
+		FederatedResource: fedObj,
+		Clusters: []*fedv1a1.KubefedCluster{
+			newCluster("create", true),
+			newCluster("adopt", true),
+			newCluster("update", true),
+			newCluster("unchanged", true),
+			newCluster("unrecorded", true),
+			newCluster("not-ready", false),
+			newCluster("delete", true),
+			newCluster("orphan-adopted", true),
+			newCluster("unselected", true),
+		},
+		ClusterObjects: map[string]*unstructured.Unstructured{
+			"adopt":          newClusterObj("small", false),
+			"update":         newClusterObj("large", true),
+			"unchanged":      newClusterObj("small", true),
+			"unrecorded":     newClusterObj("small", true),
+			"delete":         newClusterObj("small", true),
+			"orphan-adopted": adoptedClusterObj,
+			"unselected":     newClusterObj("small", false),
+		},
+		PropagatedVersion: propagatedVersion,
+	}
+
+	previews, err := Preview(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	operations := make(map[string]PreviewOperation)
+	for _, preview := range previews {
+		operations[preview.ClusterName] = preview.Operation
+		expectDesired := preview.Operation == PreviewCreate || preview.Operation == PreviewAdopt || preview.Operation == PreviewUpdate
+		if expectDesired != (preview.DesiredObject != nil) {
+			t.Errorf("Unexpected desired object for operation %q in cluster %q", preview.Operation, preview.ClusterName)
+		}
+	}
+	expectedOperations := map[string]PreviewOperation{
+		"adopt":          PreviewAdopt,
+		"create":         PreviewCreate,
+		"delete":         PreviewDelete,
+		"not-ready":      PreviewClusterNotReady,
+		"orphan-adopted": PreviewRemoveManagedLabel,
+		"unchanged":      PreviewNone,
+		"unrecorded":     PreviewUpdate,
+		"update":         PreviewUpdate,
+	}
+	if !reflect.DeepEqual(operations, expectedOperations) {
+		t.Fatalf("Expected operations %v, got %v", expectedOperations, operations)
+	}
+
+	// The input should not be modified.
+	size, _, _ := unstructured.NestedString(input.ClusterObjects["update"].Object, "spec", "size")
+	if size != "large" {
+		t.Fatalf("Expected the cluster object to be unmodified")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -34,6 +35,7 @@ import (
 	"sigs.k8s.io/kubefed/pkg/apis/core/typeconfig"
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/dispatch"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
 	"sigs.k8s.io/kubefed/pkg/controller/sync/version"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
@@ -49,6 +51,11 @@ type FederatedResource interface {
 	UpdateVersions(selectedClusters []string, versionMap map[string]string) error
 	DeleteVersions()
 	ComputePlacement(clusters []*fedv1a1.KubefedCluster) (selectedClusters sets.String, err error)
+	PlacementRecheckDelay() time.Duration
+	PlacementStatus() status.PlacementStatus
+	DeletionPolicy() (fedv1a1.DeletionPolicy, time.Duration, error)
+	AdoptionPolicy(defaultPolicy fedv1a1.AdoptionPolicy) (fedv1a1.AdoptionPolicy, error)
+	Dependencies() ([]util.Dependency, error)
 	IsNamespaceInHostCluster(clusterObj pkgruntime.Object) bool
 }
 
@@ -65,9 +72,17 @@ type federatedResource struct {
 	versionManager    *version.VersionManager
 	overridesMap      util.OverridesMap
 	versionMap        map[string]string
+	clusters          []*fedv1a1.KubefedCluster
 	namespace         *unstructured.Unstructured
 	fedNamespace      *unstructured.Unstructured
+	propagationPolicy *resolvedPropagationPolicy
+	overridePolicy    *resolvedOverridePolicy
+	selectedClusters  []string
 	eventRecorder     record.EventRecorder
+
+	// How long until placement should be recomputed to replace
+	// unavailable clusters.
+	placementRecheckDelay time.Duration
 }
 
 func (r *federatedResource) FederatedName() util.QualifiedName {
@@ -86,6 +101,14 @@ func (r *federatedResource) TargetKind() string {
 	return r.typeConfig.GetTarget().Kind
 }
 
+func (r *federatedResource) RetainFields() []fedv1a1.RetainField {
+	return r.typeConfig.GetRetainFields()
+}
+
+func (r *federatedResource) ServerSideApply() *fedv1a1.ServerSideApply {
+	return r.typeConfig.GetServerSideApply()
+}
+
 func (r *federatedResource) Object() *unstructured.Unstructured {
 	return r.federatedResource
 }
@@ -96,9 +119,55 @@ func (r *federatedResource) TemplateVersion() (string, error) {
 }
 
 func (r *federatedResource) OverrideVersion() (string, error) {
-	// TODO(marun) Consider hashing overrides per cluster to minimize
-	// unnecessary updates.
-	return GetOverrideHash(r.federatedResource)
+	hasSelectorOverrides, err := util.HasSelectorOverrides(r.federatedResource)
+	if err != nil {
+		return "", errors.Wrap(err, "Error reading cluster overrides")
+	}
+	if !hasSelectorOverrides && r.overridePolicy == nil {
+		return GetOverrideHash(r.federatedResource)
+	}
+
+	// Overrides targeting clusters by selector depend on the labels
+	// of member clusters, and overrides defined by a policy are not
+	// part of the federated resource. Hash the resolved overrides to
+	// ensure that a change in either will prompt an update.
+	overridesMap, err := r.computeOverridesMap()
+	if err != nil {
+		return "", err
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"overrides": overridesMap.ToUnstructuredSlice(),
+		},
+	}
+	return hashUnstructured(obj, "overrides")
+}
+
+func (r *federatedResource) ClusterOverrideVersions() (map[string]string, error) {
+	// The overrides map is computed rather than retrieved with
+	// overridesForCluster since the lock may already be held by
+	// VersionForCluster.
+	overridesMap, err := r.computeOverridesMap()
+	if err != nil {
+		return nil, err
+	}
+	overrideVersions := make(map[string]string)
+	for clusterName, clusterOverrides := range overridesMap {
+		if len(clusterOverrides) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"overrides": clusterOverrides,
+			},
+		}
+		overrideVersion, err := hashUnstructured(obj, "overrides")
+		if err != nil {
+			return nil, err
+		}
+		overrideVersions[clusterName] = overrideVersion
+	}
+	return overrideVersions, nil
 }
 
 func (r *federatedResource) VersionForCluster(clusterName string) (string, error) {
@@ -123,10 +192,113 @@ func (r *federatedResource) DeleteVersions() {
 }
 
 func (r *federatedResource) ComputePlacement(clusters []*fedv1a1.KubefedCluster) (sets.String, error) {
+	// Retain the clusters to allow overrides targeting clusters by
+	// label selector to be resolved.  Placement is always computed
+	// before operations are dispatched, so no locking is required.
+	r.clusters = clusters
+
+	resource := r.federatedResource
+	if r.propagationPolicy != nil {
+		var err error
+		resource, err = r.propagationPolicy.applyTo(resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var selectedClusters sets.String
+	var err error
 	if r.typeConfig.GetNamespaced() {
-		return computeNamespacedPlacement(r.federatedResource, r.fedNamespace, clusters, r.limitedScope)
+		selectedClusters, err = computeNamespacedPlacement(resource, r.fedNamespace, clusters, r.limitedScope)
+	} else {
+		selectedClusters, err = computePlacement(resource, clusters)
 	}
-	return computePlacement(r.federatedResource, clusters)
+	if err != nil {
+		return nil, err
+	}
+
+	placement, err := util.UnmarshalGenericPlacement(resource)
+	if err != nil {
+		return nil, err
+	}
+	constraints := placement.Spec.Placement.SpreadConstraints
+	failover := placement.Spec.Placement.Failover
+	switch {
+	case constraints != nil && failover != nil:
+		return nil, errors.New("spreadConstraints and failover placement cannot be combined")
+	case constraints != nil:
+		selectedClusters, err = applySpreadConstraints(constraints, selectedClusters, clusters, r.selectedClusters)
+		if err != nil {
+			return nil, err
+		}
+	case failover != nil:
+		var replacedClusters sets.String
+		selectedClusters, replacedClusters, r.placementRecheckDelay = applyFailover(
+			failover, selectedClusters, clusters, r.selectedClusters, time.Now())
+		r.recordFailoverEvents(sets.NewString(r.selectedClusters...), selectedClusters, replacedClusters)
+	default:
+		r.selectedClusters = nil
+		return selectedClusters, nil
+	}
+	r.selectedClusters = selectedClusters.List()
+	return selectedClusters, nil
+}
+
+func (r *federatedResource) PlacementRecheckDelay() time.Duration {
+	return r.placementRecheckDelay
+}
+
+// recordFailoverEvents records an event on the federated resource if
+// failover placement has replaced unavailable clusters or returned to
+// clusters that are available again.
+func (r *federatedResource) recordFailoverEvents(previous, selected, replaced sets.String) {
+	if previous.Len() == 0 {
+		return
+	}
+	added := selected.Difference(previous)
+	removed := previous.Difference(selected)
+	if removed.Len() == 0 {
+		return
+	}
+	if failedOver := removed.Intersection(replaced); failedOver.Len() > 0 {
+		r.RecordEvent("FailedOver", "Replaced unavailable cluster(s) %s with %s",
+			strings.Join(failedOver.List(), ", "), strings.Join(added.List(), ", "))
+		return
+	}
+	r.RecordEvent("FailedBack", "Returned to available cluster(s) %s from %s",
+		strings.Join(added.List(), ", "), strings.Join(removed.List(), ", "))
+}
+
+func (r *federatedResource) PlacementStatus() status.PlacementStatus {
+	placementStatus := status.PlacementStatus{
+		SelectedClusters: r.selectedClusters,
+	}
+	if r.propagationPolicy != nil {
+		placementStatus.PropagationPolicy = &r.propagationPolicy.reference
+	}
+	if r.overridePolicy != nil {
+		placementStatus.OverridePolicy = &r.overridePolicy.reference
+	}
+	return placementStatus
+}
+
+// DeletionPolicy returns the deletion policy of the resource, which
+// defaults to that of its type, and the grace period of the
+// DelayedDelete policy.
+func (r *federatedResource) DeletionPolicy() (fedv1a1.DeletionPolicy, time.Duration, error) {
+	return util.GetDeletionPolicy(r.federatedResource, r.typeConfig.GetDeletionPolicy(), r.typeConfig.GetDeletionGracePeriodSeconds())
+}
+
+// AdoptionPolicy returns the adoption policy of the resource, which
+// defaults to that of its type and then to the given policy.
+func (r *federatedResource) AdoptionPolicy(defaultPolicy fedv1a1.AdoptionPolicy) (fedv1a1.AdoptionPolicy, error) {
+	return util.GetAdoptionPolicy(r.federatedResource, r.typeConfig.GetAdoptionPolicy(), defaultPolicy)
+}
+
+// Dependencies returns the resources that must be propagated to a
+// member cluster before the resource is created there.
+func (r *federatedResource) Dependencies() ([]util.Dependency, error) {
+	return util.GetDependencies(r.federatedResource, r.TargetKind())
 }
 
 func (r *federatedResource) IsNamespaceInHostCluster(clusterObj pkgruntime.Object) bool {
@@ -182,12 +354,9 @@ func (r *federatedResource) ObjectForCluster(clusterName string) (*unstructured.
 	if err != nil {
 		return nil, err
 	}
-	if overrides != nil {
-		for path, value := range overrides {
-			pathEntries := strings.Split(path, ".")
-			if err := unstructured.SetNestedField(obj.Object, value, pathEntries...); err != nil {
-				return nil, err
-			}
+	if len(overrides) > 0 {
+		if err := util.ApplyJsonPatch(obj, overrides); err != nil {
+			return nil, err
 		}
 	}
 
@@ -208,19 +377,37 @@ func (r *federatedResource) RecordEvent(reason, messageFmt string, args ...inter
 	r.eventRecorder.Eventf(r.Object(), corev1.EventTypeNormal, reason, messageFmt, args...)
 }
 
-func (r *federatedResource) overridesForCluster(clusterName string) (util.ClusterOverridesMap, error) {
+func (r *federatedResource) overridesForCluster(clusterName string) (util.ClusterOverrides, error) {
 	r.Lock()
 	defer r.Unlock()
 	if r.overridesMap == nil {
-		overridesMap, err := util.GetOverrides(r.federatedResource)
+		overridesMap, err := r.computeOverridesMap()
 		if err != nil {
-			return nil, errors.Wrapf(err, "Error reading cluster overrides")
+			return nil, err
 		}
 		r.overridesMap = overridesMap
 	}
 	return r.overridesMap[clusterName], nil
 }
 
+// computeOverridesMap resolves the overrides of the federated
+// resource and its override policy (if any) for the clusters
+// provided to ComputePlacement.
+func (r *federatedResource) computeOverridesMap() (util.OverridesMap, error) {
+	overridesMap, err := util.GetOverridesForClusters(r.federatedResource, r.clusters)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading cluster overrides")
+	}
+	if r.overridePolicy == nil {
+		return overridesMap, nil
+	}
+	policyOverridesMap, err := r.overridePolicy.overridesForClusters(r.clusters)
+	if err != nil {
+		return nil, err
+	}
+	return mergeOverrides(policyOverridesMap, overridesMap), nil
+}
+
 func GetTemplateHash(fieldMap map[string]interface{}) (string, error) {
 	fields := []string{util.SpecField, util.TemplateField}
 	fieldMap, ok, err := unstructured.NestedMap(fieldMap, fields...)
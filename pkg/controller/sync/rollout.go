@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// rolloutCandidate is a cluster containing a resource managed by a
+// federated resource with a rollout strategy.
+type rolloutCandidate struct {
+	clusterName string
+	clusterObj  *unstructured.Unstructured
+	// Whether the resource in the cluster reflects the current
+	// template and overrides of the federated resource.
+	current bool
+	// Whether the resource in the cluster reports itself healthy.
+	healthy bool
+}
+
+// planRollout determines which of the candidate clusters that are not
+// current may be updated according to the given strategy.  Clusters
+// that were updated but are not yet healthy (if the strategy waits
+// for health) count against the maximum number of clusters that may
+// be updating at a time.
+func planRollout(strategy *util.GenericRolloutStrategy, paused bool, clusterCount int,
+	candidates []rolloutCandidate) (sets.String, *status.RolloutStatus) {
+
+	sortRolloutCandidates(strategy, candidates)
+
+	rolloutStatus := &status.RolloutStatus{Paused: paused}
+	canaries := sets.NewString(strategy.CanaryClusters...)
+	canariesComplete := true
+	pending := []rolloutCandidate{}
+	for _, candidate := range candidates {
+		switch {
+		case !candidate.current:
+			pending = append(pending, candidate)
+		case strategy.WaitForHealthy && !candidate.healthy:
+			rolloutStatus.UpdatingClusters = append(rolloutStatus.UpdatingClusters, candidate.clusterName)
+		default:
+			rolloutStatus.UpdatedClusters = append(rolloutStatus.UpdatedClusters, candidate.clusterName)
+			continue
+		}
+		if canaries.Has(candidate.clusterName) {
+			canariesComplete = false
+		}
+	}
+
+	budget := 0
+	if !paused {
+		budget = maxUpdatingClusters(strategy, clusterCount) - len(rolloutStatus.UpdatingClusters)
+	}
+	updateClusters := sets.String{}
+	for _, candidate := range pending {
+		// Only canary clusters may be updated until all canaries
+		// have been updated.
+		eligible := canariesComplete || canaries.Has(candidate.clusterName)
+		if budget > 0 && eligible {
+			budget--
+			updateClusters.Insert(candidate.clusterName)
+			rolloutStatus.UpdatingClusters = append(rolloutStatus.UpdatingClusters, candidate.clusterName)
+			continue
+		}
+		rolloutStatus.PendingClusters = append(rolloutStatus.PendingClusters, candidate.clusterName)
+	}
+	return updateClusters, rolloutStatus
+}
+
+// maxUpdatingClusters resolves the maximum number of clusters that may
+// be updating at a time.  At least one cluster is always allowed to
+// ensure progress.
+func maxUpdatingClusters(strategy *util.GenericRolloutStrategy, clusterCount int) int {
+	if strategy.MaxUpdating == nil {
+		return 1
+	}
+	maxUpdating, err := intstr.GetValueFromIntOrPercent(strategy.MaxUpdating, clusterCount, false)
+	if err != nil || maxUpdating < 1 {
+		return 1
+	}
+	return maxUpdating
+}
+
+// sortRolloutCandidates orders candidates by canary clusters, then by
+// the cluster order of the strategy, then by name.
+func sortRolloutCandidates(strategy *util.GenericRolloutStrategy, candidates []rolloutCandidate) {
+	rank := make(map[string]int)
+	for _, clusterName := range append(append([]string{}, strategy.CanaryClusters...), strategy.ClusterOrder...) {
+		if _, ok := rank[clusterName]; !ok {
+			rank[clusterName] = len(rank)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iRank, iRanked := rank[candidates[i].clusterName]
+		jRank, jRanked := rank[candidates[j].clusterName]
+		if iRanked != jRanked {
+			return iRanked
+		}
+		if iRanked {
+			return iRank < jRank
+		}
+		return candidates[i].clusterName < candidates[j].clusterName
+	})
+}
+
+// clusterObjectHealthy determines whether a resource in a member
+// cluster is healthy according to common status conventions: the
+// latest generation has been observed, and if the resource specifies
+// replicas, that many replicas are ready and updated.
+func clusterObjectHealthy(clusterObj *unstructured.Unstructured) bool {
+	observedGeneration, ok, _ := unstructured.NestedInt64(clusterObj.Object, util.StatusField, "observedGeneration")
+	if ok && observedGeneration < clusterObj.GetGeneration() {
+		return false
+	}
+	replicas, ok, _ := unstructured.NestedInt64(clusterObj.Object, util.SpecField, "replicas")
+	if !ok {
+		return true
+	}
+	readyReplicas, _, _ := unstructured.NestedInt64(clusterObj.Object, util.StatusField, "readyReplicas")
+	if readyReplicas < replicas {
+		return false
+	}
+	updatedReplicas, ok, _ := unstructured.NestedInt64(clusterObj.Object, util.StatusField, "updatedReplicas")
+	return !ok || updatedReplicas >= replicas
+}
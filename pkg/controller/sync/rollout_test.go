@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/kubefed/pkg/controller/sync/status"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func TestPlanRollout(t *testing.T) {
+	pending := func(name string) rolloutCandidate {
+		return rolloutCandidate{clusterName: name}
+	}
+	updating := func(name string) rolloutCandidate {
+		return rolloutCandidate{clusterName: name, current: true}
+	}
+	updated := func(name string) rolloutCandidate {
+		return rolloutCandidate{clusterName: name, current: true, healthy: true}
+	}
+	twoClusters := intstr.FromInt(2)
+	halfOfClusters := intstr.FromString("50%")
+
+	testCases := map[string]struct {
+		strategy         util.GenericRolloutStrategy
+		paused           bool
+		candidates       []rolloutCandidate
+		expectedClusters sets.String
+		expectedStatus   *status.RolloutStatus
+	}{
+		"one cluster at a time by default in name order": {
+			candidates:       []rolloutCandidate{pending("c3"), pending("c1"), pending("c2")},
+			expectedClusters: sets.NewString("c1"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatingClusters: []string{"c1"},
+				PendingClusters:  []string{"c2", "c3"},
+			},
+		},
+		"max updating as a number": {
+			strategy:         util.GenericRolloutStrategy{MaxUpdating: &twoClusters},
+			candidates:       []rolloutCandidate{pending("c1"), pending("c2"), pending("c3")},
+			expectedClusters: sets.NewString("c1", "c2"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatingClusters: []string{"c1", "c2"},
+				PendingClusters:  []string{"c3"},
+			},
+		},
+		"max updating as a percentage": {
+			strategy:         util.GenericRolloutStrategy{MaxUpdating: &halfOfClusters},
+			candidates:       []rolloutCandidate{updated("c1"), pending("c2"), pending("c3"), pending("c4")},
+			expectedClusters: sets.NewString("c2", "c3"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatedClusters:  []string{"c1"},
+				UpdatingClusters: []string{"c2", "c3"},
+				PendingClusters:  []string{"c4"},
+			},
+		},
+		"cluster order": {
+			strategy:         util.GenericRolloutStrategy{ClusterOrder: []string{"c3", "c2"}},
+			candidates:       []rolloutCandidate{pending("c1"), pending("c2"), pending("c3")},
+			expectedClusters: sets.NewString("c3"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatingClusters: []string{"c3"},
+				PendingClusters:  []string{"c2", "c1"},
+			},
+		},
+		"unhealthy clusters count as updating when waiting for health": {
+			strategy:         util.GenericRolloutStrategy{WaitForHealthy: true},
+			candidates:       []rolloutCandidate{updating("c1"), pending("c2")},
+			expectedClusters: sets.NewString(),
+			expectedStatus: &status.RolloutStatus{
+				UpdatingClusters: []string{"c1"},
+				PendingClusters:  []string{"c2"},
+			},
+		},
+		"health ignored when not waiting for health": {
+			candidates:       []rolloutCandidate{updating("c1"), pending("c2")},
+			expectedClusters: sets.NewString("c2"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatedClusters:  []string{"c1"},
+				UpdatingClusters: []string{"c2"},
+			},
+		},
+		"only canaries updated until canaries are healthy": {
+			strategy: util.GenericRolloutStrategy{
+				MaxUpdating:    &twoClusters,
+				CanaryClusters: []string{"c3"},
+				WaitForHealthy: true,
+			},
+			candidates:       []rolloutCandidate{pending("c1"), pending("c2"), updating("c3")},
+			expectedClusters: sets.NewString(),
+			expectedStatus: &status.RolloutStatus{
+				UpdatingClusters: []string{"c3"},
+				PendingClusters:  []string{"c1", "c2"},
+			},
+		},
+		"remaining clusters updated once canaries are healthy": {
+			strategy: util.GenericRolloutStrategy{
+				MaxUpdating:    &twoClusters,
+				CanaryClusters: []string{"c3"},
+				WaitForHealthy: true,
+			},
+			candidates:       []rolloutCandidate{pending("c1"), pending("c2"), updated("c3")},
+			expectedClusters: sets.NewString("c1", "c2"),
+			expectedStatus: &status.RolloutStatus{
+				UpdatedClusters:  []string{"c3"},
+				UpdatingClusters: []string{"c1", "c2"},
+			},
+		},
+		"paused": {
+			paused:           true,
+			candidates:       []rolloutCandidate{updated("c1"), pending("c2")},
+			expectedClusters: sets.NewString(),
+			expectedStatus: &status.RolloutStatus{
+				Paused:          true,
+				UpdatedClusters: []string{"c1"},
+				PendingClusters: []string{"c2"},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			updateClusters, rolloutStatus := planRollout(&testCase.strategy, testCase.paused, len(testCase.candidates), testCase.candidates)
+			if !reflect.DeepEqual(updateClusters, testCase.expectedClusters) {
+				t.Fatalf("Expected clusters %v, got %v", testCase.expectedClusters, updateClusters)
+			}
+			if !reflect.DeepEqual(rolloutStatus, testCase.expectedStatus) {
+				t.Fatalf("Expected status %#v, got %#v", testCase.expectedStatus, rolloutStatus)
+			}
+		})
+	}
+}
+
+func TestClusterObjectHealthy(t *testing.T) {
+	testCases := map[string]struct {
+		obj      map[string]interface{}
+		expected bool
+	}{
+		"no status": {
+			obj:      map[string]interface{}{},
+			expected: true,
+		},
+		"generation not observed": {
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"status":   map[string]interface{}{"observedGeneration": int64(1)},
+			},
+		},
+		"replicas not ready": {
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"readyReplicas": int64(2), "updatedReplicas": int64(3)},
+			},
+		},
+		"replicas not updated": {
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"readyReplicas": int64(3), "updatedReplicas": int64(1)},
+			},
+		},
+		"replicas ready and updated": {
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"spec":     map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2),
+					"readyReplicas":      int64(3),
+					"updatedReplicas":    int64(3),
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			healthy := clusterObjectHealthy(&unstructured.Unstructured{Object: testCase.obj})
+			if healthy != testCase.expected {
+				t.Fatalf("Expected healthy to be %v", testCase.expected)
+			}
+		})
+	}
+}
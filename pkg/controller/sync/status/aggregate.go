@@ -0,0 +1,263 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+// clusterValue is the value of a field of the target resource in a
+// member cluster.
+type clusterValue struct {
+	clusterName string
+	value       interface{}
+}
+
+// AggregateClusterStatus computes the aggregated status of a federated
+// resource with the given rules from the target resources in its
+// selected member clusters, keyed by cluster name.  A cluster in
+// which the field of a rule is absent or has a value of an unexpected
+// type does not contribute to the aggregated field, and an aggregated
+// field to which no cluster contributes is omitted.  Merged conditions
+// are the exception: a cluster that reports no conditions is
+// considered to report each of them as Unknown.
+func AggregateClusterStatus(rules []fedv1a1.StatusAggregationRule, clusterObjs map[string]*unstructured.Unstructured) (map[string]interface{}, error) {
+	clusterNames := []string{}
+	for clusterName := range clusterObjs {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+
+	aggregatedStatus := make(map[string]interface{})
+	for _, rule := range rules {
+		path, err := util.ParseJSONPointer(rule.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid path for aggregated field %q", rule.Field)
+		}
+		values := []clusterValue{}
+		for _, clusterName := range clusterNames {
+			value, found, err := unstructured.NestedFieldNoCopy(clusterObjs[clusterName].Object, path...)
+			if (!found || value == nil) && rule.Operation == fedv1a1.StatusAggregationMergeConditions {
+				// A cluster that reports no conditions does not
+				// report any of the merged conditions.
+				value, found, err = []interface{}{}, true, nil
+			}
+			if err != nil || !found || value == nil {
+				continue
+			}
+			values = append(values, clusterValue{clusterName: clusterName, value: value})
+		}
+		value, ok, err := aggregateValues(rule.Operation, values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to aggregate field %q", rule.Field)
+		}
+		if ok {
+			aggregatedStatus[rule.Field] = value
+		}
+	}
+	return aggregatedStatus, nil
+}
+
+// aggregateValues combines the given values with the given operation,
+// and indicates whether any value contributed to the result.
+func aggregateValues(operation fedv1a1.StatusAggregationOperation, values []clusterValue) (interface{}, bool, error) {
+	switch operation {
+	case fedv1a1.StatusAggregationSum, fedv1a1.StatusAggregationMin, fedv1a1.StatusAggregationMax:
+		value, ok := aggregateNumbers(operation, values)
+		return value, ok, nil
+	case fedv1a1.StatusAggregationAll, fedv1a1.StatusAggregationAny:
+		value, ok := aggregateBooleans(operation, values)
+		return value, ok, nil
+	case fedv1a1.StatusAggregationAppend:
+		value, ok := appendLists(values)
+		return value, ok, nil
+	case fedv1a1.StatusAggregationMergeConditions:
+		value, ok := mergeConditions(values)
+		return value, ok, nil
+	}
+	return nil, false, errors.Errorf("unknown operation %q", operation)
+}
+
+// aggregateNumbers returns the sum, minimum or maximum of the numeric
+// values.  The result is an integer unless a value is not.
+func aggregateNumbers(operation fedv1a1.StatusAggregationOperation, values []clusterValue) (interface{}, bool) {
+	var intResult int64
+	var floatResult float64
+	allInts := true
+	found := false
+	for _, v := range values {
+		var intValue int64
+		var floatValue float64
+		switch number := v.value.(type) {
+		case int64:
+			intValue, floatValue = number, float64(number)
+		case int32:
+			intValue, floatValue = int64(number), float64(number)
+		case int:
+			intValue, floatValue = int64(number), float64(number)
+		case float64:
+			allInts = false
+			floatValue = number
+		default:
+			continue
+		}
+		switch {
+		case !found:
+			intResult, floatResult = intValue, floatValue
+		case operation == fedv1a1.StatusAggregationSum:
+			intResult += intValue
+			floatResult += floatValue
+		case operation == fedv1a1.StatusAggregationMin && floatValue < floatResult,
+			operation == fedv1a1.StatusAggregationMax && floatValue > floatResult:
+			intResult, floatResult = intValue, floatValue
+		}
+		found = true
+	}
+	if !found {
+		return nil, false
+	}
+	if allInts {
+		return intResult, true
+	}
+	return floatResult, true
+}
+
+// aggregateBooleans indicates whether all or any of the boolean
+// values are true.
+func aggregateBooleans(operation fedv1a1.StatusAggregationOperation, values []clusterValue) (bool, bool) {
+	result := operation == fedv1a1.StatusAggregationAll
+	found := false
+	for _, v := range values {
+		value, ok := v.value.(bool)
+		if !ok {
+			continue
+		}
+		found = true
+		if operation == fedv1a1.StatusAggregationAll {
+			result = result && value
+		} else {
+			result = result || value
+		}
+	}
+	return result, found
+}
+
+// appendLists concatenates the list values in the order of the names
+// of their clusters.
+func appendLists(values []clusterValue) ([]interface{}, bool) {
+	result := []interface{}{}
+	for _, v := range values {
+		items, ok := v.value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range items {
+			result = append(result, runtime.DeepCopyJSONValue(item))
+		}
+	}
+	return result, len(result) > 0
+}
+
+// mergeConditions merges lists of conditions by type.  A cluster that
+// does not report a condition is considered to report it as Unknown.
+// A merged condition is True if the condition is True in every
+// cluster, False if it is False in any cluster, and Unknown otherwise.
+// The reason and message of a merged condition that is not True
+// identify the clusters in which the condition is not True.
+func mergeConditions(values []clusterValue) ([]interface{}, bool) {
+	conditionTypes := []string{}
+	clusterNames := []string{}
+	// Conditions of each condition type keyed by cluster name
+	clusterConditions := make(map[string]map[string]map[string]interface{})
+	for _, v := range values {
+		conditions, ok := v.value.([]interface{})
+		if !ok {
+			continue
+		}
+		clusterNames = append(clusterNames, v.clusterName)
+		for _, rawCondition := range conditions {
+			condition, ok := rawCondition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditionType, ok := condition["type"].(string)
+			if !ok {
+				continue
+			}
+			if _, ok := clusterConditions[conditionType]; !ok {
+				conditionTypes = append(conditionTypes, conditionType)
+				clusterConditions[conditionType] = make(map[string]map[string]interface{})
+			}
+			clusterConditions[conditionType][v.clusterName] = condition
+		}
+	}
+
+	result := []interface{}{}
+	for _, conditionType := range conditionTypes {
+		status := "True"
+		var reason string
+		messages := []string{}
+		for _, clusterName := range clusterNames {
+			condition, ok := clusterConditions[conditionType][clusterName]
+			if !ok {
+				condition = map[string]interface{}{
+					"status":  "Unknown",
+					"message": "The condition is not reported",
+				}
+			}
+			conditionStatus, _ := condition["status"].(string)
+			if conditionStatus == "True" {
+				continue
+			}
+			if conditionStatus == "False" {
+				status = "False"
+			} else if status == "True" {
+				status = "Unknown"
+			}
+			if reason == "" {
+				reason, _ = condition["reason"].(string)
+			}
+			message, _ := condition["message"].(string)
+			if message == "" {
+				message = conditionStatus
+			}
+			messages = append(messages, fmt.Sprintf("%s: %s", clusterName, message))
+		}
+		merged := map[string]interface{}{
+			"type":   conditionType,
+			"status": status,
+		}
+		if status != "True" {
+			if reason != "" {
+				merged["reason"] = reason
+			}
+			merged["message"] = strings.Join(messages, "; ")
+		}
+		result = append(result, merged)
+	}
+	return result, len(result) > 0
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestAggregateClusterStatus(t *testing.T) {
+	newClusterObj := func(status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"status": status,
+			},
+		}
+	}
+	clusterObjs := map[string]*unstructured.Unstructured{
+		"c1": newClusterObj(map[string]interface{}{
+			"readyReplicas": int64(2),
+			"ratio":         float64(0.5),
+			"paused":        true,
+			"ingress":       []interface{}{map[string]interface{}{"ip": "10.0.0.1"}},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "True"},
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		}),
+		"c2": newClusterObj(map[string]interface{}{
+			"readyReplicas": int64(3),
+			"ratio":         int64(1),
+			"paused":        false,
+			"ingress":       []interface{}{map[string]interface{}{"ip": "10.0.0.2"}},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "False", "reason": "MinimumReplicasUnavailable", "message": "Deployment does not have minimum availability."},
+				map[string]interface{}{"type": "Progressing", "status": "Unknown"},
+			},
+		}),
+		"c3": newClusterObj(map[string]interface{}{
+			"readyReplicas": "invalid",
+		}),
+	}
+
+	testCases := map[string]struct {
+		rule          fedv1a1.StatusAggregationRule
+		expectedValue interface{}
+		expectedError bool
+	}{
+		"integers are summed": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/readyReplicas", Operation: fedv1a1.StatusAggregationSum},
+			expectedValue: int64(5),
+		},
+		"minimum of integers": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/readyReplicas", Operation: fedv1a1.StatusAggregationMin},
+			expectedValue: int64(2),
+		},
+		"maximum of mixed numbers is a float": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/ratio", Operation: fedv1a1.StatusAggregationMax},
+			expectedValue: float64(1),
+		},
+		"all booleans true": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/paused", Operation: fedv1a1.StatusAggregationAll},
+			expectedValue: false,
+		},
+		"any boolean true": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/paused", Operation: fedv1a1.StatusAggregationAny},
+			expectedValue: true,
+		},
+		"lists are appended in cluster order": {
+			rule: fedv1a1.StatusAggregationRule{Path: "/status/ingress", Operation: fedv1a1.StatusAggregationAppend},
+			expectedValue: []interface{}{
+				map[string]interface{}{"ip": "10.0.0.1"},
+				map[string]interface{}{"ip": "10.0.0.2"},
+			},
+		},
+		"conditions are merged by type and unreported conditions are unknown": {
+			rule: fedv1a1.StatusAggregationRule{Path: "/status/conditions", Operation: fedv1a1.StatusAggregationMergeConditions},
+			expectedValue: []interface{}{
+				map[string]interface{}{
+					"type":    "Available",
+					"status":  "False",
+					"reason":  "MinimumReplicasUnavailable",
+					"message": "c2: Deployment does not have minimum availability.; c3: The condition is not reported",
+				},
+				map[string]interface{}{
+					"type":    "Progressing",
+					"status":  "Unknown",
+					"message": "c2: Unknown; c3: The condition is not reported",
+				},
+				map[string]interface{}{
+					"type":    "Ready",
+					"status":  "Unknown",
+					"message": "c2: The condition is not reported; c3: The condition is not reported",
+				},
+			},
+		},
+		"absent field is omitted": {
+			rule: fedv1a1.StatusAggregationRule{Path: "/status/missing", Operation: fedv1a1.StatusAggregationSum},
+		},
+		"invalid path": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "status", Operation: fedv1a1.StatusAggregationSum},
+			expectedError: true,
+		},
+		"unknown operation": {
+			rule:          fedv1a1.StatusAggregationRule{Path: "/status/readyReplicas", Operation: "Average"},
+			expectedError: true,
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			testCase.rule.Field = "field"
+			aggregatedStatus, err := AggregateClusterStatus([]fedv1a1.StatusAggregationRule{testCase.rule}, clusterObjs)
+			if testCase.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			value, ok := aggregatedStatus["field"]
+			if testCase.expectedValue == nil {
+				if ok {
+					t.Fatalf("Expected the field to be omitted, got %v", value)
+				}
+				return
+			}
+			if !reflect.DeepEqual(value, testCase.expectedValue) {
+				t.Fatalf("Expected %v, got %v", testCase.expectedValue, value)
+			}
+		})
+	}
+}
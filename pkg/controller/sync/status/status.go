@@ -17,7 +17,10 @@ limitations under the License.
 package status
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -25,7 +28,9 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	"sigs.k8s.io/kubefed/pkg/controller/sync/health"
 	"sigs.k8s.io/kubefed/pkg/controller/util"
 )
 
@@ -54,6 +59,7 @@ const (
 	FieldRetentionFailed   PropagationStatus = "FieldRetentionFailed"
 	VersionRetrievalFailed PropagationStatus = "VersionRetrievalFailed"
 	ClientRetrievalFailed  PropagationStatus = "ClientRetrievalFailed"
+	ApplyConflict          PropagationStatus = "ApplyConflict"
 
 	// Operation timeout errors
 	CreationTimedOut     PropagationStatus = "CreationTimedOut"
@@ -61,17 +67,54 @@ const (
 	DeletionTimedOut     PropagationStatus = "DeletionTimedOut"
 	LabelRemovalTimedOut PropagationStatus = "LabelRemovalTimedOut"
 
+	// Rollout status
+	RolloutPending PropagationStatus = "RolloutPending"
+
+	// Drift status
+	Drifted PropagationStatus = "Drifted"
+
+	// Deletion status
+	DeletionPending PropagationStatus = "DeletionPending"
+
+	// Dependency status
+	WaitingForDependencies    PropagationStatus = "WaitingForDependencies"
+	DependencyRetrievalFailed PropagationStatus = "DependencyRetrievalFailed"
+
 	AggregateSuccess       AggregateReason = ""
 	ClusterRetrievalFailed AggregateReason = "ClusterRetrievalFailed"
 	ComputePlacementFailed AggregateReason = "ComputePlacementFailed"
 	CheckClusters          AggregateReason = "CheckClusters"
+	RolloutStrategyInvalid AggregateReason = "RolloutStrategyInvalid"
+	RolloutInProgress      AggregateReason = "RolloutInProgress"
+	RolloutPaused          AggregateReason = "RolloutPaused"
+	DeletionPolicyInvalid  AggregateReason = "DeletionPolicyInvalid"
+	AdoptionPolicyInvalid  AggregateReason = "AdoptionPolicyInvalid"
+	DependenciesInvalid    AggregateReason = "DependenciesInvalid"
+
+	// Health reasons
+	ClustersUnhealthy AggregateReason = "ClustersUnhealthy"
+	HealthUnknown     AggregateReason = "HealthUnknown"
 
 	PropagationConditionType ConditionType = "Propagation"
+	HealthyConditionType     ConditionType = "Healthy"
 )
 
 type GenericClusterStatus struct {
 	Name   string            `json:"name"`
 	Status PropagationStatus `json:"status,omitempty"`
+	// The error encountered by the last operation in the cluster
+	Message string `json:"message,omitempty"`
+	// Last time the status transitioned from one value to another
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	// The version of the resource in the cluster last observed
+	RemoteResourceVersion string `json:"remoteResourceVersion,omitempty"`
+	RemoteGeneration      int64  `json:"remoteGeneration,omitempty"`
+	// The number of consecutive attempts to propagate to the cluster
+	// that have failed
+	RetryCount int32 `json:"retryCount,omitempty"`
+	// Health of the resource in the cluster, if assessed
+	Health        health.Status `json:"health,omitempty"`
+	HealthMessage string        `json:"healthMessage,omitempty"`
 }
 
 type GenericCondition struct {
@@ -90,9 +133,66 @@ type GenericCondition struct {
 	Reason AggregateReason `json:"reason,omitempty"`
 }
 
+// PolicyReference identifies a propagation or override policy.
+type PolicyReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r PolicyReference) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %q", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s \"%s/%s\"", r.Kind, r.Namespace, r.Name)
+}
+
+// RolloutStatus records the progress of rolling out an update across
+// member clusters.
+type RolloutStatus struct {
+	Paused           bool     `json:"paused,omitempty"`
+	UpdatedClusters  []string `json:"updatedClusters,omitempty"`
+	UpdatingClusters []string `json:"updatingClusters,omitempty"`
+	PendingClusters  []string `json:"pendingClusters,omitempty"`
+}
+
+// InProgress indicates whether an update is still being rolled out.
+func (s *RolloutStatus) InProgress() bool {
+	return s != nil && (len(s.UpdatingClusters) > 0 || len(s.PendingClusters) > 0)
+}
+
+// PlacementStatus records how the placement and overrides of a
+// federated resource were determined.
+type PlacementStatus struct {
+	// The policies that were resolved for the resource.
+	PropagationPolicy *PolicyReference
+	OverridePolicy    *PolicyReference
+
+	// The clusters selected by spread constraints.
+	SelectedClusters []string
+}
+
+// PendingDeletion records when the resource in a cluster that is no
+// longer selected will be deleted according to the DelayedDelete
+// deletion policy.
+type PendingDeletion struct {
+	Cluster     string `json:"cluster"`
+	DeleteAfter string `json:"deleteAfter"`
+}
+
 type GenericPropagationStatus struct {
-	Conditions []*GenericCondition    `json:"conditions,omitempty"`
-	Clusters   []GenericClusterStatus `json:"clusters,omitempty"`
+	// The generation of the federated resource the status reflects
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	Conditions        []*GenericCondition    `json:"conditions,omitempty"`
+	Clusters          []GenericClusterStatus `json:"clusters,omitempty"`
+	PropagationPolicy *PolicyReference       `json:"propagationPolicy,omitempty"`
+	OverridePolicy    *PolicyReference       `json:"overridePolicy,omitempty"`
+	SelectedClusters  []string               `json:"selectedClusters,omitempty"`
+	Rollout           *RolloutStatus         `json:"rollout,omitempty"`
+	PendingDeletions  []PendingDeletion      `json:"pendingDeletions,omitempty"`
+	// Summary of the status of the resource in selected clusters
+	AggregatedStatus map[string]interface{} `json:"aggregatedStatus,omitempty"`
 }
 
 type GenericFederatedStatus struct {
@@ -104,45 +204,141 @@ type GenericFederatedStatus struct {
 
 type PropagationStatusMap map[string]PropagationStatus
 
-// SetPropagationStatus sets the conditions and clusters fields of the
-// federated resource's object map from the provided reason and
-// cluster status map.
-func SetPropagationStatus(fedObject *unstructured.Unstructured, reason AggregateReason, statusMap PropagationStatusMap) error {
+// ClusterDetail records the outcome of the last operation in a member
+// cluster.
+type ClusterDetail struct {
+	// The error encountered, if any
+	Message string
+	// The version of the resource in the cluster, if known
+	RemoteResourceVersion string
+	RemoteGeneration      int64
+}
+
+type ClusterDetailMap map[string]ClusterDetail
+
+// ResourceStatus records the status of the target resources in the
+// selected member clusters.
+type ResourceStatus struct {
+	// Summary of the status of the target resources
+	Aggregated map[string]interface{}
+	// Health of the target resource in each cluster, keyed by cluster
+	// name, or nil if the health of the target type is not assessed.
+	Health map[string]health.Result
+}
+
+// SetPropagationStatus sets the conditions, clusters, placement,
+// rollout, pending deletion and aggregated status fields of the
+// federated resource's object map from the provided reason, cluster
+// status and detail maps, placement status, rollout status, deletion
+// times of clusters pending deletion and status of the target
+// resources.  The aggregated status and health last recorded are
+// retained if no resource status is provided.  The returned boolean
+// indicates whether the status changed other than in the probe times
+// of its conditions or the remote resource versions of clusters.
+func SetPropagationStatus(fedObject *unstructured.Unstructured, reason AggregateReason, statusMap PropagationStatusMap,
+	detailMap ClusterDetailMap, placementStatus PlacementStatus, rolloutStatus *RolloutStatus,
+	pendingDeletions map[string]time.Time, resourceStatus *ResourceStatus) (bool, error) {
 	status := &GenericFederatedStatus{}
 	err := util.UnstructuredToInterface(fedObject, status)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to unmarshall to generic status")
+		return false, errors.Wrapf(err, "Failed to unmarshall to generic status")
 	}
 	if status.Status == nil {
 		status.Status = &GenericPropagationStatus{}
 	}
 	propStatus := status.Status
+	previousStatus, err := propStatus.withoutVolatileFields()
+	if err != nil {
+		return false, err
+	}
 
 	// Identify whether one or more clusters could not be reconciled
-	// successfully.
+	// successfully.  Clusters waiting for an update to be rolled out,
+	// for deletion or for dependencies to be propagated are not
+	// considered to have failed.
 	if reason == AggregateSuccess && statusMap != nil {
 		for _, value := range statusMap {
-			if value != ClusterPropagationOK {
+			if propagationFailed(value) {
 				reason = CheckClusters
 				break
 			}
 		}
 	}
+	if reason == AggregateSuccess && rolloutStatus.InProgress() {
+		reason = RolloutInProgress
+		if rolloutStatus.Paused {
+			reason = RolloutPaused
+		}
+	}
+	propStatus.ObservedGeneration = fedObject.GetGeneration()
 	propStatus.setPropagationCondition(reason)
-	propStatus.setClusterStatus(statusMap)
+	previousHealth := propStatus.clusterHealth()
+	propStatus.setClusterStatus(statusMap, detailMap)
+	propStatus.PropagationPolicy = placementStatus.PropagationPolicy
+	propStatus.OverridePolicy = placementStatus.OverridePolicy
+	propStatus.SelectedClusters = placementStatus.SelectedClusters
+	propStatus.Rollout = rolloutStatus
+	propStatus.setPendingDeletions(pendingDeletions)
+	if resourceStatus != nil {
+		propStatus.AggregatedStatus = resourceStatus.Aggregated
+		propStatus.setHealth(resourceStatus.Health)
+	} else {
+		propStatus.setClusterHealth(previousHealth)
+	}
+
+	currentStatus, err := propStatus.withoutVolatileFields()
+	if err != nil {
+		return false, err
+	}
 
 	statusJSON, err := json.Marshal(status)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to marshall generic status to json")
+		return false, errors.Wrapf(err, "Failed to marshall generic status to json")
 	}
 	statusObj := &unstructured.Unstructured{}
 	err = statusObj.UnmarshalJSON(statusJSON)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to marshall generic status json to unstructured")
+		return false, errors.Wrapf(err, "Failed to marshall generic status json to unstructured")
 	}
 	fedObject.Object[util.StatusField] = statusObj.Object[util.StatusField]
 
-	return nil
+	return !bytes.Equal(previousStatus, currentStatus), nil
+}
+
+// propagationFailed indicates whether propagation to a cluster with
+// the given status failed.
+func propagationFailed(value PropagationStatus) bool {
+	switch value {
+	case ClusterPropagationOK, RolloutPending, DeletionPending, WaitingForDependencies:
+		return false
+	}
+	return true
+}
+
+// withoutVolatileFields returns the json representation of the
+// status with the probe times of its conditions and the remote
+// resource versions of clusters omitted, for determining whether the
+// status has changed.  A remote resource version changes whenever a
+// member cluster updates the object (e.g. to write its status), and
+// writing the status for every such change would cause churn against
+// the host cluster.
+func (s *GenericPropagationStatus) withoutVolatileFields() ([]byte, error) {
+	statusJSON, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to marshall generic status to json")
+	}
+	statusCopy := &GenericPropagationStatus{}
+	err = json.Unmarshal(statusJSON, statusCopy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshall generic status json")
+	}
+	for _, condition := range statusCopy.Conditions {
+		condition.LastProbeTime = ""
+	}
+	for i := range statusCopy.Clusters {
+		statusCopy.Clusters[i].RemoteResourceVersion = ""
+	}
+	return json.Marshal(statusCopy)
 }
 
 // setPropagationCondition ensures that the Propagation condition is
@@ -156,13 +352,18 @@ func (s *GenericPropagationStatus) setPropagationCondition(reason AggregateReaso
 	} else {
 		newStatus = apiv1.ConditionFalse
 	}
+	s.setCondition(PropagationConditionType, newStatus, reason)
+}
 
+// setCondition ensures that the condition of the given type is
+// updated to reflect the given status and reason.
+func (s *GenericPropagationStatus) setCondition(conditionType ConditionType, newStatus apiv1.ConditionStatus, reason AggregateReason) {
 	if s.Conditions == nil {
 		s.Conditions = []*GenericCondition{}
 	}
 	var propCondition *GenericCondition
 	for _, condition := range s.Conditions {
-		if condition.Type == PropagationConditionType {
+		if condition.Type == conditionType {
 			propCondition = condition
 			break
 		}
@@ -171,7 +372,7 @@ func (s *GenericPropagationStatus) setPropagationCondition(reason AggregateReaso
 	newCondition := propCondition == nil
 	if newCondition {
 		propCondition = &GenericCondition{
-			Type: PropagationConditionType,
+			Type: conditionType,
 		}
 		s.Conditions = append(s.Conditions, propCondition)
 	}
@@ -190,14 +391,209 @@ func (s *GenericPropagationStatus) setPropagationCondition(reason AggregateReaso
 
 }
 
+// removeCondition removes the condition of the given type.
+func (s *GenericPropagationStatus) removeCondition(conditionType ConditionType) {
+	conditions := []*GenericCondition{}
+	for _, condition := range s.Conditions {
+		if condition.Type != conditionType {
+			conditions = append(conditions, condition)
+		}
+	}
+	s.Conditions = conditions
+}
+
+// setHealth records the health of the target resource in each cluster
+// and ensures that the Healthy condition reflects the health of the
+// resource across clusters.  The condition is True if the resource is
+// healthy in every cluster, False if it is unhealthy in any cluster
+// and Unknown otherwise.  The condition is removed if health is not
+// assessed.
+func (s *GenericPropagationStatus) setHealth(clusterHealth map[string]health.Result) {
+	if clusterHealth == nil {
+		s.setClusterHealth(nil)
+		s.removeCondition(HealthyConditionType)
+		return
+	}
+	s.setClusterHealth(clusterHealth)
+
+	newStatus := apiv1.ConditionTrue
+	reason := AggregateSuccess
+	for _, result := range clusterHealth {
+		if result.Status == health.Unhealthy {
+			newStatus = apiv1.ConditionFalse
+			reason = ClustersUnhealthy
+			break
+		}
+		if result.Status != health.Healthy {
+			newStatus = apiv1.ConditionUnknown
+			reason = HealthUnknown
+		}
+	}
+	s.setCondition(HealthyConditionType, newStatus, reason)
+}
+
+// clusterHealth returns the health recorded for each cluster, keyed
+// by cluster name.
+func (s *GenericPropagationStatus) clusterHealth() map[string]health.Result {
+	clusterHealth := make(map[string]health.Result)
+	for _, cluster := range s.Clusters {
+		if cluster.Health != "" {
+			clusterHealth[cluster.Name] = health.Result{Status: cluster.Health, Message: cluster.HealthMessage}
+		}
+	}
+	return clusterHealth
+}
+
+// setClusterHealth records the given health for the clusters in the
+// cluster status slice.  Clusters are not added to the slice since
+// the slice reflects propagation.
+func (s *GenericPropagationStatus) setClusterHealth(clusterHealth map[string]health.Result) {
+	for i := range s.Clusters {
+		result := clusterHealth[s.Clusters[i].Name]
+		s.Clusters[i].Health = result.Status
+		s.Clusters[i].HealthMessage = result.Message
+	}
+}
+
 // setClusterStatus sets the cluster status slice from a propagation
-// status map.
-func (s *GenericPropagationStatus) setClusterStatus(statusMap PropagationStatusMap) {
+// status map and a detail map.  The entry last recorded for a cluster
+// is retained if its status is unchanged and no detail is provided
+// (e.g. for clusters propagated to by another replica of the
+// controller manager).
+func (s *GenericPropagationStatus) setClusterStatus(statusMap PropagationStatusMap, detailMap ClusterDetailMap) {
+	previousClusters := make(map[string]GenericClusterStatus)
+	for _, cluster := range s.Clusters {
+		previousClusters[cluster.Name] = cluster
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+
 	s.Clusters = []GenericClusterStatus{}
 	for clusterName, status := range statusMap {
-		s.Clusters = append(s.Clusters, GenericClusterStatus{
-			Name:   clusterName,
-			Status: status,
+		previous, hasPrevious := previousClusters[clusterName]
+		detail, hasDetail := detailMap[clusterName]
+		if hasPrevious && previous.Status == status && !hasDetail {
+			s.Clusters = append(s.Clusters, previous)
+			continue
+		}
+		cluster := GenericClusterStatus{
+			Name:                  clusterName,
+			Status:                status,
+			Message:               detail.Message,
+			LastTransitionTime:    now,
+			RemoteResourceVersion: detail.RemoteResourceVersion,
+			RemoteGeneration:      detail.RemoteGeneration,
+		}
+		if hasPrevious {
+			if previous.Status == status {
+				cluster.LastTransitionTime = previous.LastTransitionTime
+			}
+			// The version last observed remains current until the
+			// resource is observed again.
+			if detail.RemoteResourceVersion == "" {
+				cluster.RemoteResourceVersion = previous.RemoteResourceVersion
+				cluster.RemoteGeneration = previous.RemoteGeneration
+			}
+		}
+		if propagationFailed(status) {
+			cluster.RetryCount = 1
+			if hasPrevious && propagationFailed(previous.Status) {
+				cluster.RetryCount = previous.RetryCount + 1
+			}
+		}
+		s.Clusters = append(s.Clusters, cluster)
+	}
+	sort.Slice(s.Clusters, func(i, j int) bool {
+		return s.Clusters[i].Name < s.Clusters[j].Name
+	})
+}
+
+// setPendingDeletions sets the pending deletion slice from a map of
+// cluster names to deletion times.
+func (s *GenericPropagationStatus) setPendingDeletions(pendingDeletions map[string]time.Time) {
+	s.PendingDeletions = nil
+	for clusterName, deleteAfter := range pendingDeletions {
+		s.PendingDeletions = append(s.PendingDeletions, PendingDeletion{
+			Cluster:     clusterName,
+			DeleteAfter: deleteAfter.UTC().Format(time.RFC3339),
 		})
 	}
+	sort.Slice(s.PendingDeletions, func(i, j int) bool {
+		return s.PendingDeletions[i].Cluster < s.PendingDeletions[j].Cluster
+	})
+}
+
+// GetClusterStatus returns the status of each cluster that was last
+// recorded in the status of the federated resource, keyed by cluster
+// name.
+func GetClusterStatus(fedObject *unstructured.Unstructured) (PropagationStatusMap, error) {
+	status := &GenericFederatedStatus{}
+	err := util.UnstructuredToInterface(fedObject, status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshall to generic status")
+	}
+	statusMap := make(PropagationStatusMap)
+	if status.Status == nil {
+		return statusMap, nil
+	}
+	for _, clusterStatus := range status.Status.Clusters {
+		statusMap[clusterStatus.Name] = clusterStatus.Status
+	}
+	return statusMap, nil
+}
+
+// GetPendingDeletions returns the deletion times of clusters pending
+// deletion that were last recorded in the status of the federated
+// resource, keyed by cluster name.
+func GetPendingDeletions(fedObject *unstructured.Unstructured) (map[string]time.Time, error) {
+	status := &GenericFederatedStatus{}
+	err := util.UnstructuredToInterface(fedObject, status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshall to generic status")
+	}
+	pendingDeletions := make(map[string]time.Time)
+	if status.Status == nil {
+		return pendingDeletions, nil
+	}
+	for _, pendingDeletion := range status.Status.PendingDeletions {
+		deleteAfter, err := time.Parse(time.RFC3339, pendingDeletion.DeleteAfter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse the deletion time of cluster %q", pendingDeletion.Cluster)
+		}
+		pendingDeletions[pendingDeletion.Cluster] = deleteAfter
+	}
+	return pendingDeletions, nil
+}
+
+// GetSelectedClusters returns the clusters selected by spread
+// constraints that were last recorded in the status of the federated
+// resource.
+func GetSelectedClusters(fedObject *unstructured.Unstructured) ([]string, error) {
+	selectedClusters, _, err := unstructured.NestedStringSlice(fedObject.Object, util.StatusField, "selectedClusters")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve selected clusters from status")
+	}
+	return selectedClusters, nil
+}
+
+// GetPropagatedClusters returns the names of the clusters to which the
+// federated resource was last reported to have been propagated.  A
+// cluster waiting for an update to be rolled out or whose resource has
+// drifted is considered propagated since the resource exists there.
+func GetPropagatedClusters(fedObject *unstructured.Unstructured) (sets.String, error) {
+	status := &GenericFederatedStatus{}
+	err := util.UnstructuredToInterface(fedObject, status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshall to generic status")
+	}
+	propagatedClusters := sets.NewString()
+	if status.Status == nil {
+		return propagatedClusters, nil
+	}
+	for _, cluster := range status.Status.Clusters {
+		switch cluster.Status {
+		case ClusterPropagationOK, RolloutPending, Drifted:
+			propagatedClusters.Insert(cluster.Name)
+		}
+	}
+	return propagatedClusters, nil
 }
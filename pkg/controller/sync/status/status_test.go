@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+func TestSetPropagationStatus(t *testing.T) {
+	fedObject := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	fedObject.SetAPIVersion("types.kubefed.k8s.io/v1beta1")
+	fedObject.SetKind("FederatedDeployment")
+	fedObject.SetGeneration(2)
+
+	setStatus := func(statusMap PropagationStatusMap, detailMap ClusterDetailMap) bool {
+		changed, err := SetPropagationStatus(fedObject, AggregateSuccess, statusMap, detailMap, PlacementStatus{}, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return changed
+	}
+	clusterStatus := func(clusterName string) GenericClusterStatus {
+		status := &GenericFederatedStatus{}
+		if err := util.UnstructuredToInterface(fedObject, status); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, cluster := range status.Status.Clusters {
+			if cluster.Name == clusterName {
+				return cluster
+			}
+		}
+		t.Fatalf("Expected status for cluster %q", clusterName)
+		return GenericClusterStatus{}
+	}
+
+	statusMap := PropagationStatusMap{"c1": ClusterPropagationOK, "c2": UpdateFailed}
+	detailMap := ClusterDetailMap{
+		"c1": {RemoteResourceVersion: "10", RemoteGeneration: 1},
+		"c2": {Message: "forbidden", RemoteResourceVersion: "20", RemoteGeneration: 3},
+	}
+	if !setStatus(statusMap, detailMap) {
+		t.Fatalf("Expected the initial status to be a change")
+	}
+	observedGeneration, _, _ := unstructured.NestedInt64(fedObject.Object, "status", "observedGeneration")
+	if observedGeneration != 2 {
+		t.Fatalf("Expected observed generation 2, got %d", observedGeneration)
+	}
+	c1 := clusterStatus("c1")
+	if c1.RemoteResourceVersion != "10" || c1.RemoteGeneration != 1 || c1.RetryCount != 0 || c1.LastTransitionTime == "" {
+		t.Fatalf("Unexpected status for c1: %#v", c1)
+	}
+	c2 := clusterStatus("c2")
+	if c2.Message != "forbidden" || c2.RetryCount != 1 {
+		t.Fatalf("Unexpected status for c2: %#v", c2)
+	}
+
+	// An unchanged status is not a change even though the probe time
+	// of the propagation condition is updated.
+	if setStatus(statusMap, ClusterDetailMap{"c1": detailMap["c1"]}) {
+		t.Fatalf("Expected an unchanged status not to be a change")
+	}
+	if c2 := clusterStatus("c2"); c2.Message != "forbidden" || c2.RetryCount != 1 {
+		t.Fatalf("Expected the status for c2 without detail to be retained, got %#v", c2)
+	}
+
+	// A change in only the remote resource version of a cluster is
+	// not a change.
+	if setStatus(statusMap, ClusterDetailMap{"c1": {RemoteResourceVersion: "11", RemoteGeneration: 1}}) {
+		t.Fatalf("Expected a change in remote resource version not to be a change")
+	}
+
+	// Another failure increments the retry count.
+	if !setStatus(statusMap, detailMap) {
+		t.Fatalf("Expected a retry to be a change")
+	}
+	if c2 := clusterStatus("c2"); c2.RetryCount != 2 {
+		t.Fatalf("Expected retry count 2 for c2, got %d", c2.RetryCount)
+	}
+
+	// Success clears the message and retry count and retains the
+	// version last observed.
+	statusMap["c2"] = ClusterPropagationOK
+	if !setStatus(statusMap, ClusterDetailMap{"c2": {}}) {
+		t.Fatalf("Expected success to be a change")
+	}
+	c2 = clusterStatus("c2")
+	if c2.Message != "" || c2.RetryCount != 0 || c2.RemoteResourceVersion != "20" || c2.RemoteGeneration != 3 {
+		t.Fatalf("Unexpected status for c2: %#v", c2)
+	}
+}
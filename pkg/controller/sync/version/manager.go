@@ -46,7 +46,14 @@ type VersionedResource interface {
 	FederatedName() util.QualifiedName
 	Object() *unstructured.Unstructured
 	TemplateVersion() (string, error)
+	// OverrideVersion returns a version of the overrides for all
+	// clusters.  It is only used to migrate propagated versions
+	// recorded before override versions were tracked per cluster.
 	OverrideVersion() (string, error)
+	// ClusterOverrideVersions returns the version of the overrides
+	// for each cluster, keyed by cluster name.  Clusters without
+	// overrides are omitted.
+	ClusterOverrideVersions() (map[string]string, error)
 }
 
 type VersionManager struct {
@@ -66,6 +73,10 @@ type VersionManager struct {
 	versions map[string]pkgruntime.Object
 
 	client generic.Client
+
+	// Indicates whether the manager records versions for the named
+	// cluster, if versions are shared with other managers.
+	ownsCluster func(clusterName string) bool
 }
 
 func NewVersionManager(client generic.Client, namespaced bool, federatedKind, targetKind, namespace string) *VersionManager {
@@ -81,6 +92,15 @@ func NewVersionManager(client generic.Client, namespaced bool, federatedKind, ta
 	return v
 }
 
+// ShareClusters configures the manager to share propagated versions
+// with managers that record the versions of other clusters.  The
+// manager only records versions for the clusters for which the given
+// function returns true, and the versions of other clusters are
+// retained from the API when a write conflicts.
+func (m *VersionManager) ShareClusters(ownsCluster func(clusterName string) bool) {
+	m.ownsCluster = ownsCluster
+}
+
 // Sync retrieves propagated versions from the api and loads it into
 // memory.
 func (m *VersionManager) Sync(stopChan <-chan struct{}) {
@@ -105,31 +125,33 @@ func (m *VersionManager) HasSynced() bool {
 // Get retrieves a mapping of cluster names to versions for the given
 // versioned resource.
 func (m *VersionManager) Get(resource VersionedResource) (map[string]string, error) {
-	versionMap := make(map[string]string)
-
 	qualifiedName := m.versionQualifiedName(resource.FederatedName())
 	key := qualifiedName.String()
 	m.RLock()
 	obj, ok := m.versions[key]
 	m.RUnlock()
 	if !ok {
-		return versionMap, nil
+		return make(map[string]string), nil
 	}
-	status := m.adapter.GetStatus(obj)
+	return VersionMap(resource, m.adapter.GetStatus(obj))
+}
+
+// VersionMap returns a mapping of cluster names to the versions in
+// the given propagated version status that are still valid for the
+// given versioned resource.
+func VersionMap(resource VersionedResource, status *fedv1a1.PropagatedVersionStatus) (map[string]string, error) {
+	versionMap := make(map[string]string)
 
 	templateVersion, err := resource.TemplateVersion()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to determine template version")
 	}
-	overrideVersion, err := resource.OverrideVersion()
+	clusterVersions, err := validClusterVersions(resource, status, templateVersion)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to determine override version")
+		return nil, err
 	}
-	if templateVersion == status.TemplateVersion &&
-		overrideVersion == status.OverrideVersion {
-		for _, versions := range status.ClusterVersions {
-			versionMap[versions.ClusterName] = versions.Version
-		}
+	for _, versions := range clusterVersions {
+		versionMap[versions.ClusterName] = versions.Version
 	}
 
 	return versionMap, nil
@@ -144,9 +166,9 @@ func (m *VersionManager) Update(resource VersionedResource,
 	if err != nil {
 		return errors.Wrap(err, "Failed to determine template version")
 	}
-	overrideVersion, err := resource.OverrideVersion()
+	overrideVersions, err := resource.ClusterOverrideVersions()
 	if err != nil {
-		return errors.Wrap(err, "Failed to determine override version")
+		return errors.Wrap(err, "Failed to determine override versions")
 	}
 	qualifiedName := m.versionQualifiedName(resource.FederatedName())
 	key := qualifiedName.String()
@@ -159,18 +181,25 @@ func (m *VersionManager) Update(resource VersionedResource,
 	var clusterVersions []fedv1a1.ClusterObjectVersion
 	if ok {
 		oldStatus = m.adapter.GetStatus(obj)
-		// The existing versions are still valid if the template and override versions match.
-		if oldStatus.TemplateVersion == templateVersion && oldStatus.OverrideVersion == overrideVersion {
-			clusterVersions = oldStatus.ClusterVersions
+		clusterVersions, err = validClusterVersions(resource, oldStatus, templateVersion)
+		if err != nil {
+			m.Unlock()
+			return err
 		}
 		clusterVersions = updateClusterVersions(clusterVersions, versionMap, selectedClusters)
 	} else {
 		clusterVersions = VersionMapToClusterVersions(versionMap)
 	}
+	// The versions are current for the overrides of each cluster.
+	for i := range clusterVersions {
+		clusterVersions[i].OverrideVersion = overrideVersions[clusterVersions[i].ClusterName]
+	}
 
+	// The override version for all clusters is not recorded so
+	// that propagated versions written by earlier versions of the
+	// manager will have been migrated.
 	status := &fedv1a1.PropagatedVersionStatus{
 		TemplateVersion: templateVersion,
-		OverrideVersion: overrideVersion,
 		ClusterVersions: clusterVersions,
 	}
 
@@ -286,7 +315,11 @@ func (m *VersionManager) writeVersion(obj pkgruntime.Object, qualifiedName util.
 		if refreshVersion {
 			// Version was written to the API by another process after the last manager write.
 			var err error
-			resourceVersion, err = m.getResourceVersionFromAPI(qualifiedName)
+			if m.ownsCluster != nil {
+				resourceVersion, err = m.mergeVersionFromAPI(obj, qualifiedName)
+			} else {
+				resourceVersion, err = m.getResourceVersionFromAPI(qualifiedName)
+			}
 			if err != nil {
 				runtime.HandleError(errors.Wrapf(err, "Failed to refresh the resourceVersion for %s %q from the API", adapterType, key))
 				return false, nil
@@ -395,6 +428,26 @@ func (m *VersionManager) getResourceVersionFromAPI(qualifiedName util.QualifiedN
 	return getResourceVersion(obj)
 }
 
+// mergeVersionFromAPI updates the given propagated version with the
+// versions recorded in the API for clusters not owned by the manager,
+// and returns the resourceVersion of the propagated version in the API.
+func (m *VersionManager) mergeVersionFromAPI(obj pkgruntime.Object, qualifiedName util.QualifiedName) (string, error) {
+	klog.V(4).Infof("Retrieving %s %q from the API to merge versions of shared clusters", m.adapter.TypeName(), qualifiedName)
+	apiObj := m.adapter.NewObject()
+	err := m.client.Get(context.TODO(), apiObj, qualifiedName.Namespace, qualifiedName.Name)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	status := mergeClusterVersions(m.adapter.GetStatus(obj), m.adapter.GetStatus(apiObj), m.ownsCluster)
+	m.Lock()
+	m.adapter.SetStatus(obj, status)
+	m.Unlock()
+	return getResourceVersion(apiObj)
+}
+
 func getResourceVersion(obj pkgruntime.Object) (string, error) {
 	metaAccessor, err := meta.Accessor(obj)
 	if err != nil {
@@ -422,6 +475,44 @@ func ownerReferenceForUnstructured(obj *unstructured.Unstructured) metav1.OwnerR
 	}
 }
 
+// validClusterVersions returns the cluster versions of the given
+// status that are still valid for the template and overrides of the
+// given resource.  Versions are invalidated by a change to the
+// template or to the overrides of the cluster they are for.
+func validClusterVersions(resource VersionedResource, status *fedv1a1.PropagatedVersionStatus, templateVersion string) ([]fedv1a1.ClusterObjectVersion, error) {
+	if status.TemplateVersion != templateVersion {
+		return nil, nil
+	}
+
+	// A propagated version written before override versions were
+	// tracked per cluster records a single version for the overrides
+	// of all clusters, and its cluster versions remain valid only if
+	// that version matches.
+	legacy := len(status.OverrideVersion) > 0
+	if legacy {
+		overrideVersion, err := resource.OverrideVersion()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to determine override version")
+		}
+		if overrideVersion != status.OverrideVersion {
+			return nil, nil
+		}
+		return status.ClusterVersions, nil
+	}
+
+	overrideVersions, err := resource.ClusterOverrideVersions()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to determine override versions")
+	}
+	clusterVersions := []fedv1a1.ClusterObjectVersion{}
+	for _, clusterVersion := range status.ClusterVersions {
+		if clusterVersion.OverrideVersion == overrideVersions[clusterVersion.ClusterName] {
+			clusterVersions = append(clusterVersions, clusterVersion)
+		}
+	}
+	return clusterVersions, nil
+}
+
 func updateClusterVersions(oldVersions []fedv1a1.ClusterObjectVersion,
 	newVersions map[string]string, selectedClusters []string) []fedv1a1.ClusterObjectVersion {
 
@@ -439,6 +530,31 @@ func updateClusterVersions(oldVersions []fedv1a1.ClusterObjectVersion,
 	return VersionMapToClusterVersions(newVersions)
 }
 
+// mergeClusterVersions returns the given status with the versions of
+// clusters not owned by the caller replaced by those of the status
+// recorded in the API.  Versions recorded in the API for a different
+// template are not retained since they are no longer valid.
+func mergeClusterVersions(status, apiStatus *fedv1a1.PropagatedVersionStatus,
+	ownsCluster func(clusterName string) bool) *fedv1a1.PropagatedVersionStatus {
+
+	merged := status.DeepCopy()
+	merged.ClusterVersions = []fedv1a1.ClusterObjectVersion{}
+	for _, clusterVersion := range status.ClusterVersions {
+		if ownsCluster(clusterVersion.ClusterName) {
+			merged.ClusterVersions = append(merged.ClusterVersions, clusterVersion)
+		}
+	}
+	if apiStatus.TemplateVersion == status.TemplateVersion {
+		for _, clusterVersion := range apiStatus.ClusterVersions {
+			if !ownsCluster(clusterVersion.ClusterName) {
+				merged.ClusterVersions = append(merged.ClusterVersions, clusterVersion)
+			}
+		}
+	}
+	util.SortClusterVersions(merged.ClusterVersions)
+	return merged
+}
+
 func VersionMapToClusterVersions(versionMap map[string]string) []fedv1a1.ClusterObjectVersion {
 	clusterVersions := []fedv1a1.ClusterObjectVersion{}
 	for clusterName, version := range versionMap {
@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/kubefed/pkg/controller/util"
+)
+
+type fakeVersionedResource struct {
+	templateVersion  string
+	overrideVersion  string
+	overrideVersions map[string]string
+}
+
+func (r *fakeVersionedResource) FederatedName() util.QualifiedName {
+	return util.QualifiedName{Namespace: "ns", Name: "foo"}
+}
+
+func (r *fakeVersionedResource) Object() *unstructured.Unstructured {
+	return &unstructured.Unstructured{}
+}
+
+func (r *fakeVersionedResource) TemplateVersion() (string, error) {
+	return r.templateVersion, nil
+}
+
+func (r *fakeVersionedResource) OverrideVersion() (string, error) {
+	return r.overrideVersion, nil
+}
+
+func (r *fakeVersionedResource) ClusterOverrideVersions() (map[string]string, error) {
+	return r.overrideVersions, nil
+}
+
+func TestVersionManagerGet(t *testing.T) {
+	resource := &fakeVersionedResource{
+		templateVersion: "t1",
+		overrideVersion: "all1",
+		overrideVersions: map[string]string{
+			"cluster1": "o1",
+		},
+	}
+
+	testCases := map[string]struct {
+		status   fedv1a1.PropagatedVersionStatus
+		expected map[string]string
+	}{
+		"versions valid for current template and overrides": {
+			status: fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t1",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "cluster1", Version: "v1", OverrideVersion: "o1"},
+					{ClusterName: "cluster2", Version: "v2"},
+				},
+			},
+			expected: map[string]string{"cluster1": "v1", "cluster2": "v2"},
+		},
+		"only the version of a cluster with changed overrides is invalid": {
+			status: fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t1",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "cluster1", Version: "v1", OverrideVersion: "o0"},
+					{ClusterName: "cluster2", Version: "v2"},
+				},
+			},
+			expected: map[string]string{"cluster2": "v2"},
+		},
+		"all versions invalid for changed template": {
+			status: fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t0",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "cluster1", Version: "v1", OverrideVersion: "o1"},
+					{ClusterName: "cluster2", Version: "v2"},
+				},
+			},
+			expected: map[string]string{},
+		},
+		"legacy versions valid for unchanged overrides": {
+			status: fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t1",
+				OverrideVersion: "all1",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "cluster1", Version: "v1"},
+					{ClusterName: "cluster2", Version: "v2"},
+				},
+			},
+			expected: map[string]string{"cluster1": "v1", "cluster2": "v2"},
+		},
+		"legacy versions invalid for changed overrides": {
+			status: fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t1",
+				OverrideVersion: "all0",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "cluster1", Version: "v1"},
+					{ClusterName: "cluster2", Version: "v2"},
+				},
+			},
+			expected: map[string]string{},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			m := NewVersionManager(nil, true, "FederatedWidget", "Widget", "ns")
+			qualifiedName := m.versionQualifiedName(resource.FederatedName())
+			status := testCase.status
+			m.versions[qualifiedName.String()] = m.adapter.NewVersion(qualifiedName, metav1.OwnerReference{}, &status)
+
+			versionMap, err := m.Get(resource)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(versionMap, testCase.expected) {
+				t.Fatalf("Expected versions %v, got %v", testCase.expected, versionMap)
+			}
+		})
+	}
+}
+
+func TestMergeClusterVersions(t *testing.T) {
+	ownsCluster := func(clusterName string) bool {
+		return clusterName == "c1"
+	}
+	status := &fedv1a1.PropagatedVersionStatus{
+		TemplateVersion: "t1",
+		ClusterVersions: []fedv1a1.ClusterObjectVersion{
+			{ClusterName: "c1", Version: "new1"},
+			{ClusterName: "c2", Version: "stale2"},
+		},
+	}
+	testCases := map[string]struct {
+		apiStatus        *fedv1a1.PropagatedVersionStatus
+		expectedVersions []fedv1a1.ClusterObjectVersion
+	}{
+		"versions of other clusters are retained from the API": {
+			apiStatus: &fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t1",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "c1", Version: "old1"},
+					{ClusterName: "c2", Version: "current2"},
+					{ClusterName: "c3", Version: "current3"},
+				},
+			},
+			expectedVersions: []fedv1a1.ClusterObjectVersion{
+				{ClusterName: "c1", Version: "new1"},
+				{ClusterName: "c2", Version: "current2"},
+				{ClusterName: "c3", Version: "current3"},
+			},
+		},
+		"versions for a different template are not retained": {
+			apiStatus: &fedv1a1.PropagatedVersionStatus{
+				TemplateVersion: "t0",
+				ClusterVersions: []fedv1a1.ClusterObjectVersion{
+					{ClusterName: "c2", Version: "current2"},
+				},
+			},
+			expectedVersions: []fedv1a1.ClusterObjectVersion{
+				{ClusterName: "c1", Version: "new1"},
+			},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			merged := mergeClusterVersions(status, testCase.apiStatus, ownsCluster)
+			if merged.TemplateVersion != status.TemplateVersion {
+				t.Fatalf("Expected template version %q, got %q", status.TemplateVersion, merged.TemplateVersion)
+			}
+			if !reflect.DeepEqual(merged.ClusterVersions, testCase.expectedVersions) {
+				t.Fatalf("Expected cluster versions %v, got %v", testCase.expectedVersions, merged.ClusterVersions)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// If this annotation is present on a federated resource, it
+	// overrides the adoption policy of the federated type.
+	AdoptionPolicyAnnotation = "kubefed.k8s.io/adoption-policy"
+
+	// A resource in a member cluster with this label set to "true"
+	// may be adopted under the IfLabelled adoption policy.
+	AdoptableLabel = "kubefed.k8s.io/adoptable"
+
+	// These annotations record when a resource in a member cluster
+	// was adopted and by which federated resource.
+	AdoptedAtAnnotation = "kubefed.k8s.io/adopted-at"
+	AdoptedByAnnotation = "kubefed.k8s.io/adopted-by"
+)
+
+// GetAdoptionPolicy returns the adoption policy of a federated
+// resource from its annotation, defaulting to the given policy of its
+// type and then to the given default policy.
+func GetAdoptionPolicy(obj *unstructured.Unstructured, typePolicy, defaultPolicy fedv1a1.AdoptionPolicy) (fedv1a1.AdoptionPolicy, error) {
+	policy := fedv1a1.AdoptionPolicy(obj.GetAnnotations()[AdoptionPolicyAnnotation])
+	if policy == "" {
+		policy = typePolicy
+	}
+	if policy == "" {
+		policy = defaultPolicy
+	}
+	switch policy {
+	case fedv1a1.AdoptionPolicyAlways, fedv1a1.AdoptionPolicyNever,
+		fedv1a1.AdoptionPolicyIfIdentical, fedv1a1.AdoptionPolicyIfLabelled:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid adoption policy %q", policy)
+}
+
+// AdoptionAllowed indicates whether the given adoption policy allows
+// the adoption of the given resource in a member cluster.  The
+// desired object is the resource that would be propagated to the
+// cluster, with fields retained from the cluster object.  If adoption
+// is not allowed, the reason is returned.
+func AdoptionAllowed(policy fedv1a1.AdoptionPolicy, desiredObj, clusterObj *unstructured.Unstructured) (bool, string) {
+	switch policy {
+	case fedv1a1.AdoptionPolicyAlways:
+		return true, ""
+	case fedv1a1.AdoptionPolicyIfLabelled:
+		if clusterObj.GetLabels()[AdoptableLabel] == "true" {
+			return true, ""
+		}
+		return false, fmt.Sprintf("the resource does not have the label %s=true", AdoptableLabel)
+	case fedv1a1.AdoptionPolicyIfIdentical:
+		// The managed label is added on adoption and does not
+		// indicate a difference.
+		labeledObj := clusterObj.DeepCopy()
+		AddManagedLabel(labeledObj)
+		driftedFields := DriftedFields(desiredObj, labeledObj)
+		if len(driftedFields) == 0 {
+			return true, ""
+		}
+		return false, fmt.Sprintf("the resource differs from the desired state in fields %v", driftedFields)
+	}
+	return false, fmt.Sprintf("the adoption policy is %s", policy)
+}
+
+// AddAdoptionAnnotations records on the given object that it was
+// adopted at the given time by the named federated resource.
+func AddAdoptionAnnotations(obj *unstructured.Unstructured, adoptedAt time.Time, adoptedBy string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[AdoptedAtAnnotation] = adoptedAt.UTC().Format(time.RFC3339)
+	annotations[AdoptedByAnnotation] = adoptedBy
+	obj.SetAnnotations(annotations)
+}
+
+// IsAdopted indicates whether the given resource in a member cluster
+// was adopted rather than created by kubefed.
+func IsAdopted(obj *unstructured.Unstructured) bool {
+	return len(obj.GetAnnotations()[AdoptedByAnnotation]) > 0
+}
+
+// RetainAdoptionAnnotations copies the adoption annotations of the
+// cluster object, if any, to the desired object.
+func RetainAdoptionAnnotations(desiredObj, clusterObj *unstructured.Unstructured) {
+	clusterAnnotations := clusterObj.GetAnnotations()
+	if len(clusterAnnotations[AdoptedAtAnnotation]) == 0 && len(clusterAnnotations[AdoptedByAnnotation]) == 0 {
+		return
+	}
+	annotations := desiredObj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	for _, key := range []string{AdoptedAtAnnotation, AdoptedByAnnotation} {
+		if value, ok := clusterAnnotations[key]; ok {
+			annotations[key] = value
+		}
+	}
+	desiredObj.SetAnnotations(annotations)
+}
+
+// RemoveAdoptionAnnotations ensures that the given object does not
+// have the adoption annotations.
+func RemoveAdoptionAnnotations(obj *unstructured.Unstructured) {
+	annotations := obj.GetAnnotations()
+	_, adoptedAt := annotations[AdoptedAtAnnotation]
+	_, adoptedBy := annotations[AdoptedByAnnotation]
+	if !adoptedAt && !adoptedBy {
+		return
+	}
+	delete(annotations, AdoptedAtAnnotation)
+	delete(annotations, AdoptedByAnnotation)
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestGetAdoptionPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		annotation     string
+		typePolicy     fedv1a1.AdoptionPolicy
+		expectedPolicy fedv1a1.AdoptionPolicy
+		expectedErr    bool
+	}{
+		"defaults to the default policy": {
+			expectedPolicy: fedv1a1.AdoptionPolicyNever,
+		},
+		"defaults to the policy of the type": {
+			typePolicy:     fedv1a1.AdoptionPolicyIfLabelled,
+			expectedPolicy: fedv1a1.AdoptionPolicyIfLabelled,
+		},
+		"annotation overrides the policy of the type": {
+			annotation:     "IfIdentical",
+			typePolicy:     fedv1a1.AdoptionPolicyIfLabelled,
+			expectedPolicy: fedv1a1.AdoptionPolicyIfIdentical,
+		},
+		"invalid policy": {
+			annotation:  "Sometimes",
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if len(testCase.annotation) > 0 {
+				obj.SetAnnotations(map[string]string{
+					AdoptionPolicyAnnotation: testCase.annotation,
+				})
+			}
+			policy, err := GetAdoptionPolicy(obj, testCase.typePolicy, fedv1a1.AdoptionPolicyNever)
+			if testCase.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if policy != testCase.expectedPolicy {
+				t.Fatalf("Expected policy %q, got %q", testCase.expectedPolicy, policy)
+			}
+		})
+	}
+}
+
+func TestAdoptionAllowed(t *testing.T) {
+	newObj := func(size string, labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name": "foo",
+				},
+				"spec": map[string]interface{}{
+					"size": size,
+				},
+			},
+		}
+		obj.SetLabels(labels)
+		return obj
+	}
+	desiredObj := newObj("small", map[string]string{
+		ManagedByFederationLabelKey: ManagedByFederationLabelValue,
+	})
+
+	testCases := map[string]struct {
+		policy          fedv1a1.AdoptionPolicy
+		clusterObj      *unstructured.Unstructured
+		expectedAllowed bool
+	}{
+		"always": {
+			policy:          fedv1a1.AdoptionPolicyAlways,
+			clusterObj:      newObj("large", nil),
+			expectedAllowed: true,
+		},
+		"never": {
+			policy:     fedv1a1.AdoptionPolicyNever,
+			clusterObj: newObj("small", nil),
+		},
+		"labelled": {
+			policy:          fedv1a1.AdoptionPolicyIfLabelled,
+			clusterObj:      newObj("large", map[string]string{AdoptableLabel: "true"}),
+			expectedAllowed: true,
+		},
+		"not labelled": {
+			policy:     fedv1a1.AdoptionPolicyIfLabelled,
+			clusterObj: newObj("small", nil),
+		},
+		"identical": {
+			policy:          fedv1a1.AdoptionPolicyIfIdentical,
+			clusterObj:      newObj("small", nil),
+			expectedAllowed: true,
+		},
+		"not identical": {
+			policy:     fedv1a1.AdoptionPolicyIfIdentical,
+			clusterObj: newObj("large", nil),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			allowed, reason := AdoptionAllowed(testCase.policy, desiredObj, testCase.clusterObj)
+			if allowed != testCase.expectedAllowed {
+				t.Fatalf("Expected allowed to be %v, got %v", testCase.expectedAllowed, allowed)
+			}
+			if !allowed && len(reason) == 0 {
+				t.Fatalf("Expected a reason for refusing adoption")
+			}
+		})
+	}
+}
+
+func TestAdoptionAnnotations(t *testing.T) {
+	clusterObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	clusterObj.SetAnnotations(map[string]string{"foo": "bar"})
+	adoptedAt := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	if IsAdopted(clusterObj) {
+		t.Fatalf("Expected the resource not to be adopted")
+	}
+	AddAdoptionAnnotations(clusterObj, adoptedAt, "FederatedWidget ns/foo")
+	if !IsAdopted(clusterObj) {
+		t.Fatalf("Expected the resource to be adopted")
+	}
+
+	annotations := clusterObj.GetAnnotations()
+	if annotations[AdoptedAtAnnotation] != "2019-06-01T12:00:00Z" {
+		t.Fatalf("Unexpected %s annotation: %q", AdoptedAtAnnotation, annotations[AdoptedAtAnnotation])
+	}
+	if annotations[AdoptedByAnnotation] != "FederatedWidget ns/foo" {
+		t.Fatalf("Unexpected %s annotation: %q", AdoptedByAnnotation, annotations[AdoptedByAnnotation])
+	}
+
+	desiredObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	RetainAdoptionAnnotations(desiredObj, clusterObj)
+	retained := desiredObj.GetAnnotations()
+	if retained[AdoptedAtAnnotation] != annotations[AdoptedAtAnnotation] || retained[AdoptedByAnnotation] != annotations[AdoptedByAnnotation] {
+		t.Fatalf("Expected adoption annotations to be retained, got %v", retained)
+	}
+	if _, ok := retained["foo"]; ok {
+		t.Fatalf("Expected only adoption annotations to be retained")
+	}
+
+	RemoveAdoptionAnnotations(clusterObj)
+	if IsAdopted(clusterObj) {
+		t.Fatalf("Expected the resource not to be adopted once the annotations are removed")
+	}
+	remaining := clusterObj.GetAnnotations()
+	if len(remaining) != 1 || remaining["foo"] != "bar" {
+		t.Fatalf("Expected only the adoption annotations to be removed, got %v", remaining)
+	}
+}
@@ -18,14 +18,20 @@ package util
 
 import (
 	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
 	apiv1 "k8s.io/api/core/v1"
 	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	"sigs.k8s.io/kubefed/pkg/client/generic"
@@ -49,14 +55,35 @@ const (
 	DefaultClusterHealthCheckSuccessThreshold = 1
 	DefaultClusterHealthCheckTimeout          = 3
 
+	DefaultSyncUpdateTimeout  = 30 * time.Second
+	DefaultSyncReconcileDelay = 3 * time.Second
+
+	DefaultShardLeaseDuration = 15 * time.Second
+	DefaultShardRenewPeriod   = 5 * time.Second
+
+	DefaultCredentialRotationPeriod  = 30 * 24 * time.Hour
+	DefaultCredentialRevocationDelay = 5 * time.Minute
+
 	KubefedConfigName = "kubefed"
+
+	// Keys of the secret holding the credentials of a member cluster.
+	TokenSecretKey             = "token"
+	CASecretKey                = "ca.crt"
+	ClientCertificateSecretKey = "tls.crt"
+	ClientKeySecretKey         = "tls.key"
 )
 
 // BuildClusterConfig returns a restclient.Config that can be used to configure
 // a client for the given KubefedCluster or an error. The client is used to
-// access kubernetes secrets in the kubefed namespace.
-func BuildClusterConfig(fedCluster *fedv1a1.KubefedCluster, client generic.Client, fedNamespace string) (*restclient.Config, error) {
+// access kubernetes secrets in the kubefed namespace.  An exec plugin
+// configured for the cluster is only used if its command is one of
+// the allowed exec commands.
+func BuildClusterConfig(fedCluster *fedv1a1.KubefedCluster, client generic.Client, fedNamespace string,
+	allowedExecCommands []string) (*restclient.Config, error) {
 	clusterName := fedCluster.Name
+	if fedCluster.IsPullMode() {
+		return nil, errors.Errorf("Cluster %s is in pull mode and cannot be accessed by the control plane", clusterName)
+	}
 
 	apiEndpoint := fedCluster.Spec.APIEndpoint
 	// TODO(marun) Remove when validation ensures a non-empty value.
@@ -74,29 +101,171 @@ func BuildClusterConfig(fedCluster *fedv1a1.KubefedCluster, client generic.Clien
 		return nil, err
 	}
 
-	tokenKey := "token"
-	token, tokenFound := secret.Data[tokenKey]
-	if !tokenFound {
-		return nil, errors.Errorf("The secret for cluster %s is missing a value for %q", clusterName, tokenKey)
-	}
-	caKey := "ca.crt"
-	ca, caFound := secret.Data[caKey]
-	if !caFound {
-		return nil, errors.Errorf("The secret for cluster %s is missing a value for %q", clusterName, caKey)
-	}
+	return buildClusterConfigForSecret(fedCluster, secret, allowedExecCommands)
+}
+
+// buildClusterConfigForSecret returns a restclient.Config for the
+// given KubefedCluster with the credentials of the given secret.
+func buildClusterConfigForSecret(fedCluster *fedv1a1.KubefedCluster, secret *apiv1.Secret,
+	allowedExecCommands []string) (*restclient.Config, error) {
+	clusterName := fedCluster.Name
+	spec := fedCluster.Spec
 
-	clusterConfig, err := clientcmd.BuildConfigFromFlags(apiEndpoint, "")
+	clusterConfig, err := clientcmd.BuildConfigFromFlags(spec.APIEndpoint, "")
 	if err != nil {
 		return nil, err
 	}
-	clusterConfig.CAData = ca
-	clusterConfig.BearerToken = string(token)
+
+	token, tokenFound := secret.Data[TokenSecretKey]
+	if tokenFound {
+		clusterConfig.BearerToken = string(token)
+	}
+	cert, certFound := secret.Data[ClientCertificateSecretKey]
+	key, keyFound := secret.Data[ClientKeySecretKey]
+	if certFound != keyFound {
+		return nil, errors.Errorf("The secret for cluster %s must have values for both %q and %q to authenticate with a client certificate",
+			clusterName, ClientCertificateSecretKey, ClientKeySecretKey)
+	}
+	if certFound {
+		clusterConfig.CertData = cert
+		clusterConfig.KeyData = key
+	}
+
+	if spec.Exec != nil {
+		// The plugin runs with the privileges of the control plane, so
+		// only commands allowed by the KubefedConfig can be run.
+		if !execCommandAllowed(spec.Exec.Command, allowedExecCommands) {
+			return nil, errors.Errorf("The exec plugin command %q of cluster %s is not allowed by the KubefedConfig",
+				spec.Exec.Command, clusterName)
+		}
+		clusterConfig.ExecProvider = &clientcmdapi.ExecConfig{
+			Command:    spec.Exec.Command,
+			Args:       spec.Exec.Args,
+			APIVersion: spec.Exec.APIVersion,
+		}
+		for _, envVar := range spec.Exec.Env {
+			clusterConfig.ExecProvider.Env = append(clusterConfig.ExecProvider.Env, clientcmdapi.ExecEnvVar{
+				Name:  envVar.Name,
+				Value: envVar.Value,
+			})
+		}
+	} else if !tokenFound && !certFound {
+		return nil, errors.Errorf("The secret for cluster %s is missing a value for %q or values for %q and %q, and no exec plugin is configured",
+			clusterName, TokenSecretKey, ClientCertificateSecretKey, ClientKeySecretKey)
+	}
+
+	// A ca bundle cannot be combined with skipping verification.
+	if spec.InsecureSkipTLSVerify {
+		clusterConfig.Insecure = true
+	} else {
+		clusterConfig.CAData = secret.Data[CASecretKey]
+	}
+	clusterConfig.ServerName = spec.TLSServerName
+
+	if spec.ProxyURL != "" {
+		proxyURL, err := url.Parse(spec.ProxyURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "The proxy url of cluster %s is invalid", clusterName)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, errors.Errorf("The proxy url of cluster %s has unsupported scheme %q", clusterName, proxyURL.Scheme)
+		}
+		clusterConfig.WrapTransport = proxyTransportWrapper(proxyURL)
+	}
+
 	clusterConfig.QPS = KubeAPIQPS
+	if spec.QPS != nil {
+		clusterConfig.QPS = float32(*spec.QPS)
+	}
 	clusterConfig.Burst = KubeAPIBurst
+	if spec.Burst != nil {
+		clusterConfig.Burst = int(*spec.Burst)
+	}
 
 	return clusterConfig, nil
 }
 
+// execCommandAllowed indicates whether the given exec plugin command
+// is one of the allowed commands.
+func execCommandAllowed(command string, allowedCommands []string) bool {
+	for _, allowedCommand := range allowedCommands {
+		if command == allowedCommand {
+			return true
+		}
+	}
+	return false
+}
+
+// proxiedTransportKey identifies a transport that sends requests
+// through a proxy by the url of the proxy and the transport it was
+// copied from.
+type proxiedTransportKey struct {
+	proxyURL  string
+	transport *http.Transport
+}
+
+// proxiedTransports caches the transports that send requests through
+// a proxy so that the clients built for a cluster share a connection
+// pool rather than each opening their own.
+var proxiedTransports = struct {
+	sync.Mutex
+	transports map[proxiedTransportKey]*http.Transport
+}{transports: make(map[proxiedTransportKey]*http.Transport)}
+
+// proxyTransportWrapper returns a function that makes a transport send
+// requests through the given proxy.  The transport is copied since
+// transports are shared between clients with the same tls
+// configuration, and the copy is reused for all clients wrapping the
+// same transport.
+func proxyTransportWrapper(proxyURL *url.URL) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			return rt
+		}
+		key := proxiedTransportKey{proxyURL: proxyURL.String(), transport: transport}
+
+		proxiedTransports.Lock()
+		defer proxiedTransports.Unlock()
+		if proxiedTransport, ok := proxiedTransports.transports[key]; ok {
+			return proxiedTransport
+		}
+		proxiedTransport := newProxiedTransport(transport, proxyURL)
+		proxiedTransports.transports[key] = proxiedTransport
+		return proxiedTransport
+	}
+}
+
+// newProxiedTransport returns a copy of the given transport that sends
+// requests through the given proxy.  The fields are copied explicitly
+// rather than copying the struct, which holds the connection pool of
+// the transport, and http2 is configured anew for the copy.
+func newProxiedTransport(transport *http.Transport, proxyURL *url.URL) *http.Transport {
+	var tlsConfig *tls.Config
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	return utilnet.SetTransportDefaults(&http.Transport{
+		Proxy:                  http.ProxyURL(proxyURL),
+		DialContext:            transport.DialContext,
+		Dial:                   transport.Dial,
+		DialTLS:                transport.DialTLS,
+		TLSClientConfig:        tlsConfig,
+		TLSHandshakeTimeout:    transport.TLSHandshakeTimeout,
+		DisableKeepAlives:      transport.DisableKeepAlives,
+		DisableCompression:     transport.DisableCompression,
+		MaxIdleConns:           transport.MaxIdleConns,
+		MaxIdleConnsPerHost:    transport.MaxIdleConnsPerHost,
+		IdleConnTimeout:        transport.IdleConnTimeout,
+		ResponseHeaderTimeout:  transport.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  transport.ExpectContinueTimeout,
+		ProxyConnectHeader:     transport.ProxyConnectHeader,
+		MaxResponseHeaderBytes: transport.MaxResponseHeaderBytes,
+	})
+}
+
 // IsPrimaryCluster checks if the caller is working with objects for the
 // primary cluster by checking if the UIDs match for both ObjectMetas passed
 // in.
@@ -0,0 +1,224 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"net/http"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restclient "k8s.io/client-go/rest"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestBuildClusterConfigForSecret(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	testCases := map[string]struct {
+		spec                fedv1a1.KubefedClusterSpec
+		data                map[string][]byte
+		allowedExecCommands []string
+		expectedErr         bool
+		check               func(t *testing.T, config *restclient.Config)
+	}{
+		"token": {
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+				CASecretKey:    []byte("ca"),
+			},
+			check: func(t *testing.T, config *restclient.Config) {
+				if config.BearerToken != "token" || string(config.CAData) != "ca" {
+					t.Errorf("Expected token and ca bundle of the secret, got %q and %q", config.BearerToken, config.CAData)
+				}
+				if config.QPS != KubeAPIQPS || config.Burst != KubeAPIBurst {
+					t.Errorf("Expected default qps and burst, got %v and %d", config.QPS, config.Burst)
+				}
+			},
+		},
+		"client certificate without ca bundle": {
+			data: map[string][]byte{
+				ClientCertificateSecretKey: []byte("cert"),
+				ClientKeySecretKey:         []byte("key"),
+			},
+			check: func(t *testing.T, config *restclient.Config) {
+				if string(config.CertData) != "cert" || string(config.KeyData) != "key" {
+					t.Errorf("Expected client certificate of the secret, got %q and %q", config.CertData, config.KeyData)
+				}
+				if config.BearerToken != "" || config.CAData != nil {
+					t.Errorf("Expected no token or ca bundle")
+				}
+			},
+		},
+		"client certificate without key": {
+			data: map[string][]byte{
+				ClientCertificateSecretKey: []byte("cert"),
+			},
+			expectedErr: true,
+		},
+		"no credentials": {
+			data: map[string][]byte{
+				CASecretKey: []byte("ca"),
+			},
+			expectedErr: true,
+		},
+		"exec plugin": {
+			spec: fedv1a1.KubefedClusterSpec{
+				Exec: &fedv1a1.ExecCredentialConfig{
+					Command:    "get-token",
+					Args:       []string{"--cluster", "foo"},
+					Env:        []fedv1a1.ExecEnvVar{{Name: "REGION", Value: "us-east1"}},
+					APIVersion: "client.authentication.k8s.io/v1beta1",
+				},
+			},
+			data: map[string][]byte{
+				CASecretKey: []byte("ca"),
+			},
+			allowedExecCommands: []string{"aws", "get-token"},
+			check: func(t *testing.T, config *restclient.Config) {
+				exec := config.ExecProvider
+				if exec == nil || exec.Command != "get-token" || len(exec.Args) != 2 ||
+					len(exec.Env) != 1 || exec.Env[0].Value != "us-east1" ||
+					exec.APIVersion != "client.authentication.k8s.io/v1beta1" {
+					t.Errorf("Expected the exec plugin of the cluster, got %#v", exec)
+				}
+			},
+		},
+		"exec plugin disabled": {
+			spec: fedv1a1.KubefedClusterSpec{
+				Exec: &fedv1a1.ExecCredentialConfig{
+					Command: "get-token",
+				},
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+			},
+			expectedErr: true,
+		},
+		"exec plugin command not allowed": {
+			spec: fedv1a1.KubefedClusterSpec{
+				Exec: &fedv1a1.ExecCredentialConfig{
+					Command: "/bin/sh",
+					Args:    []string{"-c", "get-token"},
+				},
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+			},
+			allowedExecCommands: []string{"get-token"},
+			expectedErr:         true,
+		},
+		"tls options": {
+			spec: fedv1a1.KubefedClusterSpec{
+				TLSServerName:         "api.foo",
+				InsecureSkipTLSVerify: true,
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+				CASecretKey:    []byte("ca"),
+			},
+			check: func(t *testing.T, config *restclient.Config) {
+				if config.ServerName != "api.foo" || !config.Insecure {
+					t.Errorf("Expected server name and insecure to be set, got %q and %v", config.ServerName, config.Insecure)
+				}
+				if config.CAData != nil {
+					t.Errorf("Expected no ca bundle when verification is skipped")
+				}
+			},
+		},
+		"rate limits": {
+			spec: fedv1a1.KubefedClusterSpec{
+				QPS:   int32Ptr(50),
+				Burst: int32Ptr(100),
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+			},
+			check: func(t *testing.T, config *restclient.Config) {
+				if config.QPS != 50 || config.Burst != 100 {
+					t.Errorf("Expected qps 50 and burst 100, got %v and %d", config.QPS, config.Burst)
+				}
+			},
+		},
+		"proxy": {
+			spec: fedv1a1.KubefedClusterSpec{
+				ProxyURL: "socks5://proxy:1080",
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+			},
+			check: func(t *testing.T, config *restclient.Config) {
+				if config.WrapTransport == nil {
+					t.Fatalf("Expected the transport to be wrapped")
+				}
+				base := &http.Transport{}
+				transport, ok := config.WrapTransport(base).(*http.Transport)
+				if !ok {
+					t.Fatalf("Expected an http transport")
+				}
+				if transport == base {
+					t.Fatalf("Expected the shared transport to be copied")
+				}
+				if config.WrapTransport(base) != transport {
+					t.Errorf("Expected the proxied transport to be reused")
+				}
+				if config.WrapTransport(&http.Transport{}) == transport {
+					t.Errorf("Expected a different transport to be copied separately")
+				}
+				req, _ := http.NewRequest("GET", "https://api.foo", nil)
+				proxyURL, err := transport.Proxy(req)
+				if err != nil || proxyURL.String() != "socks5://proxy:1080" {
+					t.Errorf("Expected requests to use the proxy, got %v, %v", proxyURL, err)
+				}
+			},
+		},
+		"proxy with unsupported scheme": {
+			spec: fedv1a1.KubefedClusterSpec{
+				ProxyURL: "ftp://proxy",
+			},
+			data: map[string][]byte{
+				TokenSecretKey: []byte("token"),
+			},
+			expectedErr: true,
+		},
+	}
+	for testName, tc := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			tc.spec.APIEndpoint = "https://foo:6443"
+			cluster := &fedv1a1.KubefedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       tc.spec,
+			}
+			secret := &apiv1.Secret{Data: tc.data}
+			config, err := buildClusterConfigForSecret(cluster, secret, tc.allowedExecCommands)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if config.Host != "https://foo:6443" {
+				t.Errorf("Expected host %q, got %q", "https://foo:6443", config.Host)
+			}
+			tc.check(t, config)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sync"
+)
+
+// ClusterOperationLimiter limits the number of operations running
+// concurrently in each member cluster.  A single limiter is shared by
+// the sync controllers of every federated type so that the limit
+// applies to a cluster as a whole.
+type ClusterOperationLimiter struct {
+	sync.Mutex
+	maxOperations int
+	slots         map[string]chan struct{}
+}
+
+// NewClusterOperationLimiter returns a limiter allowing at most
+// maxOperations to run concurrently in each member cluster, or nil if
+// maxOperations is not positive.
+func NewClusterOperationLimiter(maxOperations int) *ClusterOperationLimiter {
+	if maxOperations <= 0 {
+		return nil
+	}
+	return &ClusterOperationLimiter{
+		maxOperations: maxOperations,
+		slots:         make(map[string]chan struct{}),
+	}
+}
+
+// ClusterSlots returns the channel bounding the operations running in
+// the named cluster.  An operation sends to the channel before it
+// runs and receives from it once it has completed.
+func (l *ClusterOperationLimiter) ClusterSlots(clusterName string) chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+	slots, ok := l.slots[clusterName]
+	if !ok {
+		slots = make(chan struct{}, l.maxOperations)
+		l.slots[clusterName] = slots
+	}
+	return slots
+}
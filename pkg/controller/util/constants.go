@@ -31,6 +31,10 @@ const (
 
 	ServiceAccountKind = "ServiceAccount"
 
+	// The field manager of resources written to member clusters
+	// with server-side apply.
+	FieldManager = "kubefed"
+
 	// The following fields are used to interact with unstructured
 	// resources.
 
@@ -58,6 +62,7 @@ const (
 	OverridesField        = "overrides"
 	ClusterNameField      = "clusterName"
 	ClusterOverridesField = "clusterOverrides"
+	OpField               = "op"
 	PathField             = "path"
 	ValueField            = "value"
 
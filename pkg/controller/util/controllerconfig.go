@@ -21,6 +21,8 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	restclient "k8s.io/client-go/rest"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 )
 
 // LeaderElectionConfiguration defines the configuration of leader election
@@ -60,6 +62,23 @@ type ClusterHealthCheckConfig struct {
 	FailureThreshold int
 	SuccessThreshold int
 	TimeoutSeconds   int
+	Checks           []fedv1a1.ClusterHealthCheck
+	ReadyGates       []string
+}
+
+// ShardMembershipConfig defines the configurable parameters of the
+// shard leases of controller manager replicas.
+type ShardMembershipConfig struct {
+	LeaseDuration time.Duration
+	RenewPeriod   time.Duration
+}
+
+// CredentialRotationConfig defines the configurable parameters of the
+// rotation of the service account tokens of member clusters.
+type CredentialRotationConfig struct {
+	Enabled         bool
+	Period          time.Duration
+	RevocationDelay time.Duration
 }
 
 // ControllerConfig defines the configuration common to federation
@@ -71,6 +90,26 @@ type ControllerConfig struct {
 	ClusterUnavailableDelay time.Duration
 	MinimizeLatency         bool
 	SkipAdoptingResources   bool
+	SyncConfig              SyncControllerConfig
+	Sharding                ShardingConfig
+	// The commands that the exec plugins of member clusters are
+	// allowed to run.  Exec plugins are disabled if empty.
+	AllowedExecCommands []string
+	// Informers for the policies read by the sync controllers of
+	// every federated type.
+	PolicyInformers *PolicyInformers
+	// Limits the operations the sync controllers of every federated
+	// type dispatch concurrently to each member cluster.  Nil if
+	// there is no limit.
+	ClusterOperationLimiter *ClusterOperationLimiter
+}
+
+// SyncControllerConfig defines the configurable parameters of the
+// sync controller.
+type SyncControllerConfig struct {
+	UpdateTimeout           time.Duration
+	ReconcileDelay          time.Duration
+	MaxConcurrentDispatches int
 }
 
 func (c *ControllerConfig) LimitedScope() bool {
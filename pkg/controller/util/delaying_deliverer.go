@@ -20,6 +20,8 @@ package util
 import (
 	"container/heap"
 	"time"
+
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 const (
@@ -79,10 +81,15 @@ type DelayingDeliverer struct {
 	updateChannel chan *DelayingDelivererItem
 	// To stop the main goroutine.
 	stopChannel chan struct{}
+	// Name under which metrics are recorded. Metrics are not
+	// recorded if empty.
+	name string
 }
 
-func NewDelayingDeliverer() *DelayingDeliverer {
-	return NewDelayingDelivererWithChannel(make(chan *DelayingDelivererItem, 100))
+func NewDelayingDeliverer(name string) *DelayingDeliverer {
+	d := NewDelayingDelivererWithChannel(make(chan *DelayingDelivererItem, 100))
+	d.name = name
+	return d
 }
 
 func NewDelayingDelivererWithChannel(targetChannel chan *DelayingDelivererItem) *DelayingDeliverer {
@@ -112,6 +119,7 @@ func (d *DelayingDeliverer) run() {
 	for {
 		now := time.Now()
 		d.deliver(now)
+		d.recordPendingItems()
 
 		nextWakeUp := now.Add(time.Hour)
 		if d.heap.Len() > 0 {
@@ -138,6 +146,12 @@ func (d *DelayingDeliverer) run() {
 	}
 }
 
+func (d *DelayingDeliverer) recordPendingItems() {
+	if len(d.name) > 0 {
+		metrics.DelivererPendingItems(d.name, d.heap.Len())
+	}
+}
+
 // Starts the DelayingDeliverer.
 func (d *DelayingDeliverer) Start() {
 	go d.run()
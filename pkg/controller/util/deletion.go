@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+const (
+	DeletionPolicyField             = "deletionPolicy"
+	DeletionGracePeriodSecondsField = "deletionGracePeriodSeconds"
+
+	// The grace period of the DelayedDelete deletion policy if
+	// neither the federated resource nor its type configure one.
+	DefaultDeletionGracePeriod = 5 * time.Minute
+)
+
+// GetDeletionPolicy returns the deletion policy of a federated
+// resource, defaulting to the given policy of its type and then to
+// Delete.  The grace period of the DelayedDelete policy is returned
+// along with the policy.
+func GetDeletionPolicy(obj *unstructured.Unstructured, typePolicy fedv1a1.DeletionPolicy, typeGracePeriodSeconds *int64) (fedv1a1.DeletionPolicy, time.Duration, error) {
+	policy, _, err := unstructured.NestedString(obj.Object, SpecField, DeletionPolicyField)
+	if err != nil {
+		return "", 0, err
+	}
+	if policy == "" {
+		policy = string(typePolicy)
+	}
+	switch fedv1a1.DeletionPolicy(policy) {
+	case "", fedv1a1.DeletionPolicyDelete:
+		return fedv1a1.DeletionPolicyDelete, 0, nil
+	case fedv1a1.DeletionPolicyOrphan:
+		return fedv1a1.DeletionPolicyOrphan, 0, nil
+	case fedv1a1.DeletionPolicyDelayedDelete:
+	default:
+		return "", 0, fmt.Errorf("invalid deletion policy %q", policy)
+	}
+
+	gracePeriod := DefaultDeletionGracePeriod
+	if typeGracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*typeGracePeriodSeconds) * time.Second
+	}
+	seconds, ok, err := unstructured.NestedInt64(obj.Object, SpecField, DeletionGracePeriodSecondsField)
+	if err != nil {
+		return "", 0, err
+	}
+	if ok {
+		gracePeriod = time.Duration(seconds) * time.Second
+	}
+	if gracePeriod < 0 {
+		return "", 0, fmt.Errorf("invalid deletion grace period %v", gracePeriod)
+	}
+	return fedv1a1.DeletionPolicyDelayedDelete, gracePeriod, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func TestGetDeletionPolicy(t *testing.T) {
+	typeGracePeriodSeconds := int64(60)
+
+	testCases := map[string]struct {
+		spec                   map[string]interface{}
+		typePolicy             fedv1a1.DeletionPolicy
+		typeGracePeriodSeconds *int64
+		expectedPolicy         fedv1a1.DeletionPolicy
+		expectedGracePeriod    time.Duration
+		expectedErr            bool
+	}{
+		"defaults to Delete": {
+			expectedPolicy: fedv1a1.DeletionPolicyDelete,
+		},
+		"defaults to the policy of the type": {
+			typePolicy:     fedv1a1.DeletionPolicyOrphan,
+			expectedPolicy: fedv1a1.DeletionPolicyOrphan,
+		},
+		"policy of the resource overrides the policy of the type": {
+			spec: map[string]interface{}{
+				DeletionPolicyField: "Delete",
+			},
+			typePolicy:     fedv1a1.DeletionPolicyOrphan,
+			expectedPolicy: fedv1a1.DeletionPolicyDelete,
+		},
+		"delayed delete defaults to the default grace period": {
+			spec: map[string]interface{}{
+				DeletionPolicyField: "DelayedDelete",
+			},
+			expectedPolicy:      fedv1a1.DeletionPolicyDelayedDelete,
+			expectedGracePeriod: DefaultDeletionGracePeriod,
+		},
+		"delayed delete defaults to the grace period of the type": {
+			spec: map[string]interface{}{
+				DeletionPolicyField: "DelayedDelete",
+			},
+			typeGracePeriodSeconds: &typeGracePeriodSeconds,
+			expectedPolicy:         fedv1a1.DeletionPolicyDelayedDelete,
+			expectedGracePeriod:    time.Minute,
+		},
+		"delayed delete with the grace period of the resource": {
+			spec: map[string]interface{}{
+				DeletionPolicyField:             "DelayedDelete",
+				DeletionGracePeriodSecondsField: int64(3600),
+			},
+			typeGracePeriodSeconds: &typeGracePeriodSeconds,
+			expectedPolicy:         fedv1a1.DeletionPolicyDelayedDelete,
+			expectedGracePeriod:    time.Hour,
+		},
+		"invalid policy": {
+			spec: map[string]interface{}{
+				DeletionPolicyField: "Archive",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if testCase.spec != nil {
+				obj.Object[SpecField] = testCase.spec
+			}
+			policy, gracePeriod, err := GetDeletionPolicy(obj, testCase.typePolicy, testCase.typeGracePeriodSeconds)
+			if testCase.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if policy != testCase.expectedPolicy {
+				t.Fatalf("Expected policy %q, got %q", testCase.expectedPolicy, policy)
+			}
+			if gracePeriod != testCase.expectedGracePeriod {
+				t.Fatalf("Expected grace period %v, got %v", testCase.expectedGracePeriod, gracePeriod)
+			}
+		})
+	}
+}
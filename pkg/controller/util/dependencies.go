@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	DependsOnField = "dependsOn"
+)
+
+// Dependency identifies a resource that must be propagated to a
+// member cluster before a federated resource is created there.  The
+// group and kind are those of the target type of the federated
+// resource that propagates the dependency.
+type Dependency struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Whether the dependency was inferred rather than declared.
+	// Inferred dependencies that are not federated are ignored.
+	Inferred bool `json:"-"`
+}
+
+func (d Dependency) String() string {
+	kind := d.Kind
+	if d.Group != "" {
+		kind = fmt.Sprintf("%s.%s", d.Kind, d.Group)
+	}
+	name := QualifiedName{Namespace: d.Namespace, Name: d.Name}
+	return fmt.Sprintf("%s %q", kind, name)
+}
+
+// GetDependencies returns the dependencies of a federated resource
+// with the given target kind.  Dependencies declared in
+// spec.dependsOn are returned first, followed by the dependencies
+// inferred from the containing namespace and from the references of
+// a pod template to config maps, secrets and service accounts.
+func GetDependencies(obj *unstructured.Unstructured, targetKind string) ([]Dependency, error) {
+	namespace := obj.GetNamespace()
+	dependencies := []Dependency{}
+	seen := make(map[Dependency]bool)
+	add := func(dependency Dependency) {
+		key := dependency
+		key.Inferred = false
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		dependencies = append(dependencies, dependency)
+	}
+
+	declared, ok, err := unstructured.NestedSlice(obj.Object, SpecField, DependsOnField)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for i, rawDependency := range declared {
+			dependency := Dependency{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(asMap(rawDependency), &dependency); err != nil {
+				return nil, errors.Wrapf(err, "invalid dependency %d", i)
+			}
+			if dependency.Kind == "" || dependency.Name == "" {
+				return nil, errors.Errorf("dependency %d must specify a kind and name", i)
+			}
+			if dependency.Namespace == "" {
+				dependency.Namespace = namespace
+			}
+			add(dependency)
+		}
+	}
+
+	if namespace != "" && targetKind != NamespaceKind {
+		add(Dependency{Kind: NamespaceKind, Name: namespace, Inferred: true})
+	}
+
+	podSpec, err := templatePodSpec(obj, targetKind)
+	if err != nil || podSpec == nil {
+		return dependencies, err
+	}
+	for _, dependency := range podSpecDependencies(podSpec) {
+		dependency.Namespace = namespace
+		dependency.Inferred = true
+		add(dependency)
+	}
+	return dependencies, nil
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// templatePodSpec returns the pod spec of the template of a federated
+// resource whose target is a pod or contains a pod template, or nil
+// if there is no pod spec.
+func templatePodSpec(obj *unstructured.Unstructured, targetKind string) (*corev1.PodSpec, error) {
+	path := []string{SpecField, TemplateField, SpecField}
+	switch targetKind {
+	case "Pod":
+	case "CronJob":
+		path = append(path, "jobTemplate", SpecField, TemplateField, SpecField)
+	default:
+		path = append(path, TemplateField, SpecField)
+	}
+	rawPodSpec, ok, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !ok {
+		return nil, err
+	}
+	podSpec := &corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawPodSpec, podSpec); err != nil {
+		return nil, errors.Wrap(err, "invalid pod template")
+	}
+	return podSpec, nil
+}
+
+// podSpecDependencies returns the config maps, secrets and service
+// account referenced by a pod spec.  Optional references are ignored.
+func podSpecDependencies(podSpec *corev1.PodSpec) []Dependency {
+	dependencies := []Dependency{}
+	configMap := func(name string, optional *bool) {
+		if name != "" && (optional == nil || !*optional) {
+			dependencies = append(dependencies, Dependency{Kind: "ConfigMap", Name: name})
+		}
+	}
+	secret := func(name string, optional *bool) {
+		if name != "" && (optional == nil || !*optional) {
+			dependencies = append(dependencies, Dependency{Kind: "Secret", Name: name})
+		}
+	}
+
+	if podSpec.ServiceAccountName != "" {
+		dependencies = append(dependencies, Dependency{Kind: "ServiceAccount", Name: podSpec.ServiceAccountName})
+	}
+	for _, pullSecret := range podSpec.ImagePullSecrets {
+		secret(pullSecret.Name, nil)
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			configMap(volume.ConfigMap.Name, volume.ConfigMap.Optional)
+		}
+		if volume.Secret != nil {
+			secret(volume.Secret.SecretName, volume.Secret.Optional)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					configMap(source.ConfigMap.Name, source.ConfigMap.Optional)
+				}
+				if source.Secret != nil {
+					secret(source.Secret.Name, source.Secret.Optional)
+				}
+			}
+		}
+	}
+	containers := append([]corev1.Container{}, podSpec.InitContainers...)
+	containers = append(containers, podSpec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMap(envFrom.ConfigMapRef.Name, envFrom.ConfigMapRef.Optional)
+			}
+			if envFrom.SecretRef != nil {
+				secret(envFrom.SecretRef.Name, envFrom.SecretRef.Optional)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configMap(ref.Name, ref.Optional)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				secret(ref.Name, ref.Optional)
+			}
+		}
+	}
+	return dependencies
+}
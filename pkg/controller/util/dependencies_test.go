@@ -0,0 +1,187 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetDependencies(t *testing.T) {
+	podSpec := map[string]interface{}{
+		"serviceAccountName": "app",
+		"volumes": []interface{}{
+			map[string]interface{}{
+				"name": "config",
+				"configMap": map[string]interface{}{
+					"name": "app-config",
+				},
+			},
+			map[string]interface{}{
+				"name": "optional",
+				"secret": map[string]interface{}{
+					"secretName": "optional-secret",
+					"optional":   true,
+				},
+			},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name": "app",
+				"envFrom": []interface{}{
+					map[string]interface{}{
+						"secretRef": map[string]interface{}{
+							"name": "app-secret",
+						},
+					},
+				},
+				"env": []interface{}{
+					map[string]interface{}{
+						"name": "CONFIG",
+						"valueFrom": map[string]interface{}{
+							"configMapKeyRef": map[string]interface{}{
+								"name": "app-config",
+								"key":  "config",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	inferred := []Dependency{
+		{Kind: "Namespace", Name: "ns", Inferred: true},
+		{Kind: "ServiceAccount", Namespace: "ns", Name: "app", Inferred: true},
+		{Kind: "ConfigMap", Namespace: "ns", Name: "app-config", Inferred: true},
+		{Kind: "Secret", Namespace: "ns", Name: "app-secret", Inferred: true},
+	}
+
+	testCases := map[string]struct {
+		targetKind           string
+		spec                 map[string]interface{}
+		expectedDependencies []Dependency
+		expectedErr          bool
+	}{
+		"namespace is inferred": {
+			targetKind: "ConfigMap",
+			spec: map[string]interface{}{
+				"template": map[string]interface{}{
+					"data": map[string]interface{}{},
+				},
+			},
+			expectedDependencies: []Dependency{
+				{Kind: "Namespace", Name: "ns", Inferred: true},
+			},
+		},
+		"references of a deployment pod template are inferred": {
+			targetKind: "Deployment",
+			spec: map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": podSpec,
+						},
+					},
+				},
+			},
+			expectedDependencies: inferred,
+		},
+		"references of a cron job pod template are inferred": {
+			targetKind: "CronJob",
+			spec: map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"jobTemplate": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"template": map[string]interface{}{
+									"spec": podSpec,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedDependencies: inferred,
+		},
+		"declared dependencies precede inferred dependencies": {
+			targetKind: "Pod",
+			spec: map[string]interface{}{
+				DependsOnField: []interface{}{
+					map[string]interface{}{
+						"group": "example.com",
+						"kind":  "Widget",
+						"name":  "foo",
+					},
+					map[string]interface{}{
+						"kind": "ConfigMap",
+						"name": "app-config",
+					},
+				},
+				"template": map[string]interface{}{
+					"spec": podSpec,
+				},
+			},
+			expectedDependencies: []Dependency{
+				{Group: "example.com", Kind: "Widget", Namespace: "ns", Name: "foo"},
+				{Kind: "ConfigMap", Namespace: "ns", Name: "app-config"},
+				{Kind: "Namespace", Name: "ns", Inferred: true},
+				{Kind: "ServiceAccount", Namespace: "ns", Name: "app", Inferred: true},
+				{Kind: "Secret", Namespace: "ns", Name: "app-secret", Inferred: true},
+			},
+		},
+		"declared dependency without a name": {
+			targetKind: "ConfigMap",
+			spec: map[string]interface{}{
+				DependsOnField: []interface{}{
+					map[string]interface{}{
+						"kind": "Secret",
+					},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "foo",
+						"namespace": "ns",
+					},
+					"spec": testCase.spec,
+				},
+			}
+			dependencies, err := GetDependencies(obj, testCase.targetKind)
+			if testCase.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(dependencies, testCase.expectedDependencies) {
+				t.Fatalf("Expected dependencies %v, got %v", testCase.expectedDependencies, dependencies)
+			}
+		})
+	}
+}
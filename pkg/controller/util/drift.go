@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type DriftPolicy string
+
+const (
+	DriftPolicyField = "driftPolicy"
+
+	// DriftPolicyOverwrite indicates that drift of a managed resource
+	// in a member cluster is reported and then overwritten with the
+	// desired state.
+	DriftPolicyOverwrite DriftPolicy = "Overwrite"
+	// DriftPolicyReport indicates that drift of a managed resource in
+	// a member cluster is reported but not overwritten.
+	DriftPolicyReport DriftPolicy = "Report"
+)
+
+// GetDriftPolicy returns the drift policy of a federated resource,
+// defaulting to Overwrite.
+func GetDriftPolicy(obj *unstructured.Unstructured) (DriftPolicy, error) {
+	policy, _, err := unstructured.NestedString(obj.Object, SpecField, DriftPolicyField)
+	if err != nil {
+		return "", err
+	}
+	switch DriftPolicy(policy) {
+	case "", DriftPolicyOverwrite:
+		return DriftPolicyOverwrite, nil
+	case DriftPolicyReport:
+		return DriftPolicyReport, nil
+	}
+	return "", fmt.Errorf("invalid drift policy %q", policy)
+}
+
+// DriftedFields returns the sorted JSON pointers of the fields of the
+// desired object whose values differ in the cluster object. Fields
+// that are only set in the cluster object are ignored since they
+// cannot be distinguished from fields defaulted by the member
+// cluster.  Likewise, only the labels and annotations of the desired
+// object are compared.  The data of Secrets in pull-mode clusters is
+// not compared.
+func DriftedFields(desiredObj, clusterObj *unstructured.Unstructured) []string {
+	fields := []string{}
+	for _, field := range []string{"labels", "annotations"} {
+		desired, _, _ := unstructured.NestedStringMap(desiredObj.Object, MetadataField, field)
+		actual, _, _ := unstructured.NestedStringMap(clusterObj.Object, MetadataField, field)
+		for key, value := range desired {
+			if actualValue, ok := actual[key]; !ok || actualValue != value {
+				fields = append(fields, fmt.Sprintf("/%s/%s/%s", MetadataField, field, escapeJSONPointer(key)))
+			}
+		}
+	}
+	pulledSecret := IsPulledSecret(clusterObj)
+	for key, desired := range desiredObj.Object {
+		switch key {
+		case "apiVersion", "kind", MetadataField, StatusField:
+			continue
+		}
+		if pulledSecret && (key == "data" || key == "stringData") {
+			// The data of Secrets is not reported by the agent
+			// of a pull-mode cluster.
+			continue
+		}
+		fields = appendDriftedFields(fields, "/"+escapeJSONPointer(key), desired, clusterObj.Object[key])
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func appendDriftedFields(fields []string, path string, desired, actual interface{}) []string {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			return append(fields, path)
+		}
+		for key, value := range desiredValue {
+			fields = appendDriftedFields(fields, path+"/"+escapeJSONPointer(key), value, actualValue[key])
+		}
+		return fields
+	case []interface{}:
+		actualValue, ok := actual.([]interface{})
+		if !ok || len(actualValue) != len(desiredValue) {
+			return append(fields, path)
+		}
+		for i, value := range desiredValue {
+			fields = appendDriftedFields(fields, fmt.Sprintf("%s/%d", path, i), value, actualValue[i])
+		}
+		return fields
+	}
+	if !scalarsEqual(desired, actual) {
+		return append(fields, path)
+	}
+	return fields
+}
+
+// scalarsEqual compares scalar values, treating numbers of different
+// types as equal if their values are equal.
+func scalarsEqual(a, b interface{}) bool {
+	aNumber, aIsNumber := toFloat64(a)
+	bNumber, bIsNumber := toFloat64(b)
+	if aIsNumber && bIsNumber {
+		return aNumber == bNumber
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int64:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// ParseJSONPointer splits a JSON pointer to a field into its
+// unescaped segments.
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || len(pointer) == 1 {
+		return nil, errors.Errorf("path must be a JSON pointer to a field")
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		if len(segment) == 0 {
+			return nil, errors.Errorf("path must not contain empty segments")
+		}
+		segments[i] = strings.Replace(strings.Replace(segment, "~1", "/", -1), "~0", "~", -1)
+	}
+	return segments, nil
+}
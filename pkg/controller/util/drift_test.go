@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDriftedFields(t *testing.T) {
+	desiredObj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":   "foo",
+				"labels": map[string]interface{}{"app": "foo"},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(2),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":  "app",
+								"image": "app:1.0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		mutate   func(obj *unstructured.Unstructured)
+		expected []string
+	}{
+		"defaulted fields are ignored": {
+			mutate: func(obj *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(obj.Object, "RollingUpdate", "spec", "strategy", "type")
+				_ = unstructured.SetNestedField(obj.Object, int64(2), "status", "replicas")
+				obj.SetResourceVersion("42")
+			},
+			expected: []string{},
+		},
+		"numbers of different types are equal": {
+			mutate: func(obj *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(obj.Object, float64(2), "spec", "replicas")
+			},
+			expected: []string{},
+		},
+		"changed fields are reported": {
+			mutate: func(obj *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(obj.Object, int64(3), "spec", "replicas")
+				containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+				containers[0].(map[string]interface{})["image"] = "app:2.0"
+				_ = unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+			},
+			expected: []string{"/spec/replicas", "/spec/template/spec/containers/0/image"},
+		},
+		"added list items are reported": {
+			mutate: func(obj *unstructured.Unstructured) {
+				containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+				containers = append(containers, map[string]interface{}{"name": "sidecar"})
+				_ = unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+			},
+			expected: []string{"/spec/template/spec/containers"},
+		},
+		"labels and annotations added in the cluster are ignored": {
+			mutate: func(obj *unstructured.Unstructured) {
+				obj.SetLabels(map[string]string{"app": "foo", "debug": "true"})
+				obj.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "3"})
+			},
+			expected: []string{},
+		},
+		"changed labels are reported": {
+			mutate: func(obj *unstructured.Unstructured) {
+				obj.SetLabels(map[string]string{"app": "bar"})
+			},
+			expected: []string{"/metadata/labels/app"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			clusterObj := desiredObj.DeepCopy()
+			testCase.mutate(clusterObj)
+			fields := DriftedFields(desiredObj, clusterObj)
+			if !reflect.DeepEqual(testCase.expected, fields) {
+				t.Fatalf("Expected %v, got %v", testCase.expected, fields)
+			}
+		})
+	}
+}
+
+func TestGetDriftPolicy(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			SpecField: map[string]interface{}{},
+		},
+	}
+	policy, err := GetDriftPolicy(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if policy != DriftPolicyOverwrite {
+		t.Fatalf("Expected the default policy to be %q, got %q", DriftPolicyOverwrite, policy)
+	}
+
+	_ = unstructured.SetNestedField(obj.Object, string(DriftPolicyReport), SpecField, DriftPolicyField)
+	policy, err = GetDriftPolicy(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if policy != DriftPolicyReport {
+		t.Fatalf("Expected policy %q, got %q", DriftPolicyReport, policy)
+	}
+
+	_ = unstructured.SetNestedField(obj.Object, "Ignore", SpecField, DriftPolicyField)
+	if _, err := GetDriftPolicy(obj); err == nil {
+		t.Fatalf("Expected an error for an invalid policy")
+	}
+}
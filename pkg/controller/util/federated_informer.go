@@ -17,6 +17,7 @@ limitations under the License.
 package util
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -78,6 +79,10 @@ type FederationView interface {
 	// GetClientForCluster returns a client for the cluster, if present.
 	GetClientForCluster(clusterName string) (ResourceClient, error)
 
+	// GetClientForClusterWithContext returns a client for the
+	// cluster, if present, whose requests are cancelled when the
+	// given context is done.
+	GetClientForClusterWithContext(ctx context.Context, clusterName string) (ResourceClient, error)
 	// GetUnreadyClusters returns a list of all clusters that are not ready yet.
 	GetUnreadyClusters() ([]*fedv1a1.KubefedCluster, error)
 
@@ -116,7 +121,7 @@ type FederatedInformer interface {
 type FederatedInformerForTestOnly interface {
 	FederatedInformer
 
-	SetClientFactory(func(*fedv1a1.KubefedCluster) (ResourceClient, error))
+	SetClientFactory(func(context.Context, *fedv1a1.KubefedCluster) (ResourceClient, error))
 }
 
 // A function that should be used to create an informer on the target object. Store should use
@@ -133,6 +138,10 @@ type ClusterLifecycleHandlerFuncs struct {
 	// Fired when the cluster becomes unavailable. The second arg contains data that was present
 	// in the cluster before deletion.
 	ClusterUnavailable func(*fedv1a1.KubefedCluster, []interface{})
+	// Fired when the labels of the cluster change without the
+	// cluster becoming available or unavailable.  The first arg is
+	// the cluster before the change.
+	ClusterLabelsChanged func(*fedv1a1.KubefedCluster, *fedv1a1.KubefedCluster)
 }
 
 // Builds a FederatedInformer for the given federation client and factory.
@@ -149,8 +158,20 @@ func NewFederatedInformer(
 
 	federatedInformer := &federatedInformerImpl{
 		targetInformerFactory: targetInformerFactory,
-		clientFactory: func(cluster *fedv1a1.KubefedCluster) (ResourceClient, error) {
-			config, err := BuildClusterConfig(cluster, client, config.KubefedNamespace)
+		clientFactory: func(ctx context.Context, cluster *fedv1a1.KubefedCluster) (ResourceClient, error) {
+			// Resources of a pull-mode cluster are written to and
+			// read from the host cluster, where they are accessed by
+			// the agent running in the member cluster.
+			if cluster.IsPullMode() {
+				hostConfig := restclient.CopyConfig(config.KubeConfig)
+				restclient.AddUserAgent(hostConfig, userAgentName)
+				if ctx.Done() != nil {
+					hostConfig.WrapTransport = WrapTransportWithContext(ctx, hostConfig.WrapTransport)
+				}
+				return NewPullResourceClient(hostConfig, config.KubefedNamespace, cluster.Name, apiResource)
+			}
+
+			config, err := BuildClusterConfig(cluster, client, config.KubefedNamespace, config.AllowedExecCommands)
 			if err != nil {
 				return nil, err
 			}
@@ -159,6 +180,9 @@ func NewFederatedInformer(
 			}
 
 			restclient.AddUserAgent(config, userAgentName)
+			if ctx.Done() != nil {
+				config.WrapTransport = WrapTransportWithContext(ctx, config.WrapTransport)
+			}
 			return NewResourceClient(config, apiResource)
 		},
 		targetInformers: make(map[string]informer),
@@ -237,6 +261,9 @@ func NewFederatedInformer(
 					}
 				} else {
 					klog.V(7).Infof("Cluster %v not updated to %v as ready status and specs are identical", oldCluster, curCluster)
+					if clusterLifecycle.ClusterLabelsChanged != nil && !reflect.DeepEqual(oldCluster.Labels, curCluster.Labels) {
+						clusterLifecycle.ClusterLabelsChanged(oldCluster, curCluster)
+					}
 				}
 			},
 		},
@@ -255,6 +282,29 @@ func IsClusterReady(clusterStatus *fedv1a1.KubefedClusterStatus) bool {
 	return false
 }
 
+// ClusterUnavailableCondition returns the condition of the given status
+// that indicates the cluster is unavailable: the Ready condition if it
+// is not True or, for a cluster without a Ready condition, the Offline
+// condition if it is True.  Nil is returned if neither applies.
+func ClusterUnavailableCondition(clusterStatus *fedv1a1.KubefedClusterStatus) *fedv1a1.ClusterCondition {
+	var offlineCondition *fedv1a1.ClusterCondition
+	for i := range clusterStatus.Conditions {
+		condition := &clusterStatus.Conditions[i]
+		switch condition.Type {
+		case fedcommon.ClusterReady:
+			if condition.Status == apiv1.ConditionTrue {
+				return nil
+			}
+			return condition
+		case fedcommon.ClusterOffline:
+			if condition.Status == apiv1.ConditionTrue {
+				offlineCondition = condition
+			}
+		}
+	}
+	return offlineCondition
+}
+
 type informer struct {
 	controller cache.Controller
 	store      cache.Store
@@ -274,7 +324,7 @@ type federatedInformerImpl struct {
 	targetInformers map[string]informer
 
 	// A function to build clients.
-	clientFactory func(*fedv1a1.KubefedCluster) (ResourceClient, error)
+	clientFactory func(context.Context, *fedv1a1.KubefedCluster) (ResourceClient, error)
 
 	// Namespace from which to source KubefedCluster resources
 	fedNamespace string
@@ -312,7 +362,7 @@ func (f *federatedInformerImpl) Start() {
 	go f.clusterInformer.controller.Run(f.clusterInformer.stopChan)
 }
 
-func (f *federatedInformerImpl) SetClientFactory(clientFactory func(*fedv1a1.KubefedCluster) (ResourceClient, error)) {
+func (f *federatedInformerImpl) SetClientFactory(clientFactory func(context.Context, *fedv1a1.KubefedCluster) (ResourceClient, error)) {
 	f.Lock()
 	defer f.Unlock()
 
@@ -321,17 +371,23 @@ func (f *federatedInformerImpl) SetClientFactory(clientFactory func(*fedv1a1.Kub
 
 // GetClientForCluster returns a client for the cluster, if present.
 func (f *federatedInformerImpl) GetClientForCluster(clusterName string) (ResourceClient, error) {
+	return f.GetClientForClusterWithContext(context.Background(), clusterName)
+}
+
+// GetClientForClusterWithContext returns a client for the cluster, if
+// present, whose requests are cancelled when the context is done.
+func (f *federatedInformerImpl) GetClientForClusterWithContext(ctx context.Context, clusterName string) (ResourceClient, error) {
 	f.Lock()
 	defer f.Unlock()
-	return f.getClientForClusterUnlocked(clusterName)
+	return f.getClientForClusterUnlocked(ctx, clusterName)
 }
 
-func (f *federatedInformerImpl) getClientForClusterUnlocked(clusterName string) (ResourceClient, error) {
+func (f *federatedInformerImpl) getClientForClusterUnlocked(ctx context.Context, clusterName string) (ResourceClient, error) {
 	// No locking needed. Will happen in f.GetCluster.
 	klog.V(4).Infof("Getting clientset for cluster %q", clusterName)
 	if cluster, found, err := f.getReadyClusterUnlocked(clusterName); found && err == nil {
 		klog.V(4).Infof("Got clientset for cluster %q", clusterName)
-		return f.clientFactory(cluster)
+		return f.clientFactory(ctx, cluster)
 	} else {
 		if err != nil {
 			return nil, err
@@ -421,7 +477,7 @@ func (f *federatedInformerImpl) addCluster(cluster *fedv1a1.KubefedCluster) {
 	f.Lock()
 	defer f.Unlock()
 	name := cluster.Name
-	if client, err := f.getClientForClusterUnlocked(name); err == nil {
+	if client, err := f.getClientForClusterUnlocked(context.Background(), name); err == nil {
 		store, controller := f.targetInformerFactory(cluster, client)
 		targetInformer := informer{
 			controller: controller,
@@ -37,50 +37,63 @@ func NewGenericInformer(config *rest.Config, namespace string, obj pkgruntime.Ob
 }
 
 func NewGenericInformerWithEventHandler(config *rest.Config, namespace string, obj pkgruntime.Object, resyncPeriod time.Duration, resourceEventHandlerFuncs *cache.ResourceEventHandlerFuncs) (cache.Store, cache.Controller, error) {
-	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	listWatch, err := newGenericListWatch(config, namespace, obj)
 	if err != nil {
 		return nil, nil, err
 	}
+	store, controller := cache.NewInformer(listWatch, obj, resyncPeriod, resourceEventHandlerFuncs)
+	return store, controller, nil
+}
+
+// NewGenericSharedInformer returns an informer for the given type to
+// which any number of event handlers can be added.
+func NewGenericSharedInformer(config *rest.Config, namespace string, obj pkgruntime.Object, resyncPeriod time.Duration) (cache.SharedInformer, error) {
+	listWatch, err := newGenericListWatch(config, namespace, obj)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewSharedInformer(listWatch, obj, resyncPeriod), nil
+}
+
+func newGenericListWatch(config *rest.Config, namespace string, obj pkgruntime.Object) (*cache.ListWatch, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	if err != nil {
+		return nil, err
+	}
 
 	mapper, err := apiutil.NewDiscoveryRESTMapper(config)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Could not create RESTMapper from config")
+		return nil, errors.Wrap(err, "Could not create RESTMapper from config")
 	}
 
 	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	client, err := apiutil.RESTClientForGVK(gvk, config, scheme.Codecs)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
 	listObj, err := scheme.Scheme.New(listGVK)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	store, controller := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(opts metav1.ListOptions) (pkgruntime.Object, error) {
-				res := listObj.DeepCopyObject()
-				isNamespaceScoped := namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
-				err := client.Get().NamespaceIfScoped(namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, scheme.ParameterCodec).Do().Into(res)
-				return res, err
-			},
-			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
-				// Watch needs to be set to true separately
-				opts.Watch = true
-				isNamespaceScoped := namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
-				return client.Get().NamespaceIfScoped(namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, scheme.ParameterCodec).Watch()
-			},
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (pkgruntime.Object, error) {
+			res := listObj.DeepCopyObject()
+			isNamespaceScoped := namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
+			err := client.Get().NamespaceIfScoped(namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, scheme.ParameterCodec).Do().Into(res)
+			return res, err
 		},
-		obj,
-		resyncPeriod,
-		resourceEventHandlerFuncs,
-	)
-	return store, controller, nil
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			// Watch needs to be set to true separately
+			opts.Watch = true
+			isNamespaceScoped := namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
+			return client.Get().NamespaceIfScoped(namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, scheme.ParameterCodec).Watch()
+		},
+	}, nil
 }
@@ -18,22 +18,42 @@ package util
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/sets"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 )
 
+// ClusterOverride describes a single JSON Patch (RFC 6902) operation
+// to apply to the template of a federated resource for a given
+// cluster.  The path should be a JSON Pointer (RFC 6901)
+// (e.g. /spec/template/spec/containers/0/image).  A dotted field path
+// (e.g. spec.replicas) is deprecated but still accepted and converted
+// to the equivalent JSON Pointer.
 type ClusterOverride struct {
+	Op    string      `json:"op,omitempty"`
 	Path  string      `json:"path"`
-	Value interface{} `json:"value"`
+	Value interface{} `json:"value,omitempty"`
 }
 
+// GenericOverrideItem defines the overrides for either a single
+// named cluster or the clusters matching a label selector.  Exactly
+// one of ClusterName or ClusterSelector must be provided.
 type GenericOverrideItem struct {
-	ClusterName      string            `json:"clusterName"`
-	ClusterOverrides []ClusterOverride `json:"clusterOverrides,omitempty"`
+	ClusterName      string                `json:"clusterName,omitempty"`
+	ClusterSelector  *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	ClusterOverrides []ClusterOverride     `json:"clusterOverrides,omitempty"`
 }
 
 type GenericOverrideSpec struct {
@@ -51,32 +71,62 @@ type GenericOverride struct {
 // primary mechanism of association between a federated resource in
 // the host cluster and the target resources in the member clusters.
 var invalidPaths = sets.NewString(
-	"metadata.namespace",
-	"metadata.name",
-	"metadata.generateName",
+	"/metadata/namespace",
+	"/metadata/name",
+	"/metadata/generateName",
+)
+
+const (
+	AddOp     = "add"
+	RemoveOp  = "remove"
+	ReplaceOp = "replace"
 )
 
-// Mapping of qualified path (e.g. spec.replicas) to value
-type ClusterOverridesMap map[string]interface{}
+// The subset of JSON Patch operations supported for overrides.  An
+// empty op replaces the value at the path if one is present and
+// otherwise adds it.
+var validOps = sets.NewString(
+	"",
+	AddOp,
+	RemoveOp,
+	ReplaceOp,
+)
+
+// Ordered list of overrides for a cluster
+type ClusterOverrides []ClusterOverride
 
 // Mapping of clusterName to overrides for the cluster
-type OverridesMap map[string]ClusterOverridesMap
+type OverridesMap map[string]ClusterOverrides
 
 // ToUnstructuredSlice converts the map of overrides to a slice of
 // interfaces that can be set in an unstructured object.
 func (m OverridesMap) ToUnstructuredSlice() []interface{} {
+	clusterNames := []string{}
+	for clusterName := range m {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	// Ensure a stable ordering to avoid unnecessary updates.
+	sort.Strings(clusterNames)
+
 	overrides := []interface{}{}
-	for clusterName, clusterOverridesMap := range m {
-		clusterOverrides := []map[string]interface{}{}
-		for path, value := range clusterOverridesMap {
-			clusterOverrides = append(clusterOverrides, map[string]interface{}{
-				PathField:  path,
-				ValueField: value,
-			})
+	for _, clusterName := range clusterNames {
+		clusterOverrides := m[clusterName]
+		rawClusterOverrides := []interface{}{}
+		for _, clusterOverride := range clusterOverrides {
+			rawClusterOverride := map[string]interface{}{
+				PathField: clusterOverride.Path,
+			}
+			if len(clusterOverride.Op) > 0 {
+				rawClusterOverride[OpField] = clusterOverride.Op
+			}
+			if clusterOverride.Value != nil {
+				rawClusterOverride[ValueField] = clusterOverride.Value
+			}
+			rawClusterOverrides = append(rawClusterOverrides, rawClusterOverride)
 		}
 		overridesItem := map[string]interface{}{
 			ClusterNameField:      clusterName,
-			ClusterOverridesField: clusterOverrides,
+			ClusterOverridesField: rawClusterOverrides,
 		}
 		overrides = append(overrides, overridesItem)
 	}
@@ -84,12 +134,108 @@ func (m OverridesMap) ToUnstructuredSlice() []interface{} {
 }
 
 // GetOverrides returns a map of overrides populated from the given
-// unstructured object.
+// unstructured object.  Only overrides that target a cluster by name
+// are included.  Use GetOverridesForClusters to also resolve
+// overrides that target clusters by label selector.
 func GetOverrides(rawObj *unstructured.Unstructured) (OverridesMap, error) {
+	overrideItems, err := getOverrideItems(rawObj)
+	if err != nil {
+		return nil, err
+	}
+	return namedOverrides(overrideItems), nil
+}
+
+// GetOverridesForClusters returns a map of the overrides that apply
+// to each of the given clusters.
+//
+// The overrides of all selectors matching a cluster are combined in
+// the order they are defined, and an error is returned if more than
+// one of the matching selectors overrides the same path.  An override
+// that names a cluster replaces a selector override for the same path,
+// and selector overrides are applied before the overrides naming the
+// cluster.
+func GetOverridesForClusters(rawObj *unstructured.Unstructured, clusters []*fedv1a1.KubefedCluster) (OverridesMap, error) {
+	overrideItems, err := getOverrideItems(rawObj)
+	if err != nil {
+		return nil, err
+	}
+	overridesMap := namedOverrides(overrideItems)
+	for _, cluster := range clusters {
+		clusterName := cluster.Name
+		namedPaths := sets.NewString()
+		for _, clusterOverride := range overridesMap[clusterName] {
+			namedPaths.Insert(clusterOverride.Path)
+		}
+		clusterOverrides := ClusterOverrides{}
+		paths := sets.NewString()
+		for i, overrideItem := range overrideItems {
+			if overrideItem.ClusterSelector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(overrideItem.ClusterSelector)
+			if err != nil {
+				return nil, errors.Wrapf(err, "overrides[%d] has an invalid cluster selector", i)
+			}
+			if !selector.Matches(labels.Set(cluster.Labels)) {
+				continue
+			}
+			for _, clusterOverride := range overrideItem.ClusterOverrides {
+				if paths.Has(clusterOverride.Path) {
+					return nil, errors.Errorf("path %q is overridden by more than one cluster selector matching cluster %q", clusterOverride.Path, clusterName)
+				}
+				paths.Insert(clusterOverride.Path)
+				if namedPaths.Has(clusterOverride.Path) {
+					// Explicit cluster overrides take precedence
+					continue
+				}
+				clusterOverrides = append(clusterOverrides, clusterOverride)
+			}
+		}
+		if len(clusterOverrides) > 0 {
+			overridesMap[clusterName] = append(clusterOverrides, overridesMap[clusterName]...)
+		}
+	}
+
+	return overridesMap, nil
+}
+
+// HasSelectorOverrides indicates whether the given unstructured
+// object defines overrides that target clusters by label selector.
+func HasSelectorOverrides(rawObj *unstructured.Unstructured) (bool, error) {
+	overrideItems, err := getOverrideItems(rawObj)
+	if err != nil {
+		return false, err
+	}
+	for _, overrideItem := range overrideItems {
+		if overrideItem.ClusterSelector != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// namedOverrides returns a map of the overrides that target a
+// cluster by name.
+func namedOverrides(overrideItems []GenericOverrideItem) OverridesMap {
 	overridesMap := make(OverridesMap)
+	for _, overrideItem := range overrideItems {
+		if overrideItem.ClusterSelector != nil {
+			continue
+		}
+		clusterOverrides := ClusterOverrides(overrideItem.ClusterOverrides)
+		if clusterOverrides == nil {
+			clusterOverrides = ClusterOverrides{}
+		}
+		overridesMap[overrideItem.ClusterName] = clusterOverrides
+	}
+	return overridesMap
+}
 
+// getOverrideItems returns the validated override items of the given
+// unstructured object.
+func getOverrideItems(rawObj *unstructured.Unstructured) ([]GenericOverrideItem, error) {
 	if rawObj == nil {
-		return overridesMap, nil
+		return nil, nil
 	}
 
 	override := GenericOverride{}
@@ -100,32 +246,68 @@ func GetOverrides(rawObj *unstructured.Unstructured) (OverridesMap, error) {
 
 	if override.Spec == nil || override.Spec.Overrides == nil {
 		// No overrides defined for the federated type
-		return overridesMap, nil
+		return nil, nil
 	}
 
-	for _, overrideItem := range override.Spec.Overrides {
+	clusterNames := sets.NewString()
+	for i, overrideItem := range override.Spec.Overrides {
 		clusterName := overrideItem.ClusterName
-		if _, ok := overridesMap[clusterName]; ok {
-			return nil, errors.Errorf("cluster %q appears more than once", clusterName)
+		target := fmt.Sprintf("cluster %q", clusterName)
+		if overrideItem.ClusterSelector != nil {
+			if len(clusterName) > 0 {
+				return nil, errors.Errorf("overrides[%d] may not specify both a cluster name and a cluster selector", i)
+			}
+			if _, err := metav1.LabelSelectorAsSelector(overrideItem.ClusterSelector); err != nil {
+				return nil, errors.Wrapf(err, "overrides[%d] has an invalid cluster selector", i)
+			}
+			target = fmt.Sprintf("overrides[%d]", i)
+		} else {
+			if len(clusterName) == 0 {
+				return nil, errors.Errorf("overrides[%d] must specify either a cluster name or a cluster selector", i)
+			}
+			if clusterNames.Has(clusterName) {
+				return nil, errors.Errorf("cluster %q appears more than once", clusterName)
+			}
+			clusterNames.Insert(clusterName)
 		}
-		overridesMap[clusterName] = make(ClusterOverridesMap)
-
-		clusterOverrides := overrideItem.ClusterOverrides
 
-		for i, clusterOverride := range clusterOverrides {
+		paths := sets.NewString()
+		for j := range overrideItem.ClusterOverrides {
+			clusterOverride := &overrideItem.ClusterOverrides[j]
+			if len(clusterOverride.Path) == 0 {
+				return nil, errors.Errorf("override[%d] for %s has an empty path", j, target)
+			}
+			clusterOverride.Path = overridePath(clusterOverride.Path)
 			path := clusterOverride.Path
 			if invalidPaths.Has(path) {
-				return nil, errors.Errorf("override[%d] for cluster %q has an invalid path: %s", i, clusterName, path)
+				return nil, errors.Errorf("override[%d] for %s has an invalid path: %s", j, target, path)
 			}
-			if _, ok := overridesMap[clusterName][path]; ok {
-				return nil, errors.Errorf("path %q appears more than once for cluster %q", path, clusterName)
+			if !validOps.Has(clusterOverride.Op) {
+				return nil, errors.Errorf("override[%d] for %s has an invalid op: %s", j, target, clusterOverride.Op)
 			}
-
-			overridesMap[clusterName][path] = clusterOverride.Value
+			if paths.Has(path) {
+				return nil, errors.Errorf("path %q appears more than once for %s", path, target)
+			}
+			paths.Insert(path)
 		}
 	}
 
-	return overridesMap, nil
+	return override.Spec.Overrides, nil
+}
+
+// overridePath returns the JSON pointer for the given override path.
+// A path that is not a JSON pointer is a dotted field path
+// (e.g. spec.replicas) as supported before overrides became JSON
+// Patch operations.
+func overridePath(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	fields := strings.Split(path, ".")
+	for i, field := range fields {
+		fields[i] = escapeJSONPointer(field)
+	}
+	return "/" + strings.Join(fields, "/")
 }
 
 // SetOverrides sets the spec.overrides field of the unstructured
@@ -141,10 +323,126 @@ func SetOverrides(fedObject *unstructured.Unstructured, overridesMap OverridesMa
 	if !ok {
 		return errors.Errorf("Unable to set overrides since %q is not an object: %T", SpecField, rawSpec)
 	}
-	spec[OverridesField] = overridesMap.ToUnstructuredSlice()
+	overrides := overridesMap.ToUnstructuredSlice()
+
+	// Retain overrides that target clusters by label selector since
+	// they are not represented in the overrides map.
+	rawOverrides, ok := spec[OverridesField].([]interface{})
+	if ok {
+		for _, rawOverride := range rawOverrides {
+			overrideItem, ok := rawOverride.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok := overrideItem[ClusterSelectorField]; ok {
+				overrides = append(overrides, overrideItem)
+			}
+		}
+	}
+
+	spec[OverridesField] = overrides
+	return nil
+}
+
+// ApplyJsonPatch applies the given overrides to the unstructured
+// object as JSON Patch operations in the order they are defined.  An
+// override without an op replaces the value at its path if one is
+// present and otherwise adds it, creating any missing parent objects,
+// so that overrides defined before ops were supported continue to set
+// fields that are absent from the template.
+func ApplyJsonPatch(obj *unstructured.Unstructured, overrides ClusterOverrides) error {
+	for _, override := range overrides {
+		if override.Op == "" {
+			override.Op = ReplaceOp
+			// A path that cannot be parsed is left for the patch to
+			// report.
+			fields, err := ParseJSONPointer(override.Path)
+			if err == nil {
+				if _, found := nestedValue(obj.Object, fields); !found {
+					override.Op = AddOp
+					addParentObjects(obj.Object, fields)
+				}
+			}
+		}
+		if err := applyPatchOperation(obj, override); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPatchOperation(obj *unstructured.Unstructured, override ClusterOverride) error {
+	patchBytes, err := json.Marshal([]ClusterOverride{override})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal overrides to json")
+	}
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode overrides as a json patch")
+	}
+
+	objBytes, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchedBytes, err := patch.Apply(objBytes)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to apply override of %q", override.Path)
+	}
+	// Decode with the apimachinery json package to ensure that
+	// integers are not converted to floats.
+	patchedObj := make(map[string]interface{})
+	if err := utiljson.Unmarshal(patchedBytes, &patchedObj); err != nil {
+		return errors.Wrap(err, "Failed to decode the overridden object")
+	}
+	obj.Object = patchedObj
 	return nil
 }
 
+// nestedValue returns the value of the given object at the location
+// identified by the given JSON pointer fields and whether it was
+// found.
+func nestedValue(obj interface{}, fields []string) (interface{}, bool) {
+	value := obj
+	for _, field := range fields {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			value, ok = typedValue[field]
+			if !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(field)
+			if err != nil || index < 0 || index >= len(typedValue) {
+				return nil, false
+			}
+			value = typedValue[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// addParentObjects adds an empty object for each missing parent of
+// the location identified by the given JSON pointer fields.  Parents
+// that are not objects are left for the patch to report.
+func addParentObjects(obj map[string]interface{}, fields []string) {
+	for _, field := range fields[:len(fields)-1] {
+		value, ok := obj[field]
+		if !ok {
+			value = map[string]interface{}{}
+			obj[field] = value
+		}
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		obj = child
+	}
+}
+
 // UnstructuredToInterface converts an unstructured object to the
 // provided interface by json marshalling/unmarshalling.
 func UnstructuredToInterface(rawObj *unstructured.Unstructured, obj interface{}) error {
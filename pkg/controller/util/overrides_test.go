@@ -0,0 +1,423 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+func newOverridesObject(clusterOverrides ...map[string]interface{}) *unstructured.Unstructured {
+	rawOverrides := []interface{}{}
+	for _, clusterOverride := range clusterOverrides {
+		rawOverrides = append(rawOverrides, clusterOverride)
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			SpecField: map[string]interface{}{
+				OverridesField: []interface{}{
+					map[string]interface{}{
+						ClusterNameField:      "cluster1",
+						ClusterOverridesField: rawOverrides,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetOverrides(t *testing.T) {
+	testCases := map[string]struct {
+		obj           *unstructured.Unstructured
+		expectedPaths []string
+		expectedError bool
+	}{
+		"valid overrides": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "/spec/replicas", ValueField: int64(2)},
+				map[string]interface{}{OpField: AddOp, PathField: "/metadata/labels/foo", ValueField: "bar"},
+				map[string]interface{}{OpField: RemoveOp, PathField: "/metadata/annotations"},
+			),
+			expectedPaths: []string{"/spec/replicas", "/metadata/labels/foo", "/metadata/annotations"},
+		},
+		"dotted paths converted to json pointers": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "spec.replicas", ValueField: int64(2)},
+				map[string]interface{}{PathField: "metadata.annotations.example.com/a~b", ValueField: "c"},
+			),
+			expectedPaths: []string{"/spec/replicas", "/metadata/annotations/example/com~1a~0b"},
+		},
+		"dotted path duplicating a json pointer": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "spec.replicas", ValueField: int64(2)},
+				map[string]interface{}{PathField: "/spec/replicas", ValueField: int64(3)},
+			),
+			expectedError: true,
+		},
+		"empty path": {
+			obj: newOverridesObject(
+				map[string]interface{}{ValueField: int64(2)},
+			),
+			expectedError: true,
+		},
+		"invalid dotted path": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "metadata.name", ValueField: "foo"},
+			),
+			expectedError: true,
+		},
+		"invalid path": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "/metadata/name", ValueField: "foo"},
+			),
+			expectedError: true,
+		},
+		"invalid op": {
+			obj: newOverridesObject(
+				map[string]interface{}{OpField: "move", PathField: "/spec/replicas"},
+			),
+			expectedError: true,
+		},
+		"duplicate path": {
+			obj: newOverridesObject(
+				map[string]interface{}{PathField: "/spec/replicas", ValueField: int64(2)},
+				map[string]interface{}{PathField: "/spec/replicas", ValueField: int64(3)},
+			),
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			overridesMap, err := GetOverrides(testCase.obj)
+			if testCase.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			paths := []string{}
+			for _, clusterOverride := range overridesMap["cluster1"] {
+				paths = append(paths, clusterOverride.Path)
+			}
+			if !reflect.DeepEqual(testCase.expectedPaths, paths) {
+				t.Fatalf("Expected paths %v, got %v", testCase.expectedPaths, paths)
+			}
+		})
+	}
+}
+
+func TestApplyJsonPatch(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"foo": "bar",
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"containers": []interface{}{
+					map[string]interface{}{
+						"name":  "app",
+						"image": "registry.example.com/app:1.0",
+					},
+				},
+			},
+		},
+	}
+	overrides := ClusterOverrides{
+		{
+			Path:  "/spec/replicas",
+			Value: 3,
+		},
+		{
+			Op:    ReplaceOp,
+			Path:  "/spec/containers/0/image",
+			Value: "registry.cluster1.example.com/app:1.0",
+		},
+		{
+			Op:   AddOp,
+			Path: "/spec/containers/-",
+			Value: map[string]interface{}{
+				"name":  "sidecar",
+				"image": "registry.cluster1.example.com/sidecar:1.0",
+			},
+		},
+		{
+			Op:   RemoveOp,
+			Path: "/metadata/annotations/foo",
+		},
+	}
+
+	err := ApplyJsonPatch(obj, overrides)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedObj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "registry.cluster1.example.com/app:1.0",
+				},
+				map[string]interface{}{
+					"name":  "sidecar",
+					"image": "registry.cluster1.example.com/sidecar:1.0",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expectedObj, obj.Object) {
+		t.Fatalf("Expected %v, got %v", expectedObj, obj.Object)
+	}
+
+	err = ApplyJsonPatch(obj, ClusterOverrides{{Op: RemoveOp, Path: "/spec/missing"}})
+	if err == nil {
+		t.Fatalf("Expected an error when removing a missing field")
+	}
+}
+
+func TestApplyJsonPatchWithoutOp(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"args": []interface{}{"--v=1", "--port=80"},
+			},
+		},
+	}
+	overrides := ClusterOverrides{
+		{
+			// A missing field is added along with its parents.
+			Path:  "/spec/template/metadata/labels/tier",
+			Value: "frontend",
+		},
+		{
+			// An existing array element is replaced rather than an
+			// element being inserted.
+			Path:  "/spec/args/0",
+			Value: "--v=4",
+		},
+	}
+
+	err := ApplyJsonPatch(obj, overrides)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedObj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"args": []interface{}{"--v=4", "--port=80"},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"tier": "frontend",
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expectedObj, obj.Object) {
+		t.Fatalf("Expected %v, got %v", expectedObj, obj.Object)
+	}
+}
+
+func TestGetOverridesForClusters(t *testing.T) {
+	clusters := []*fedv1a1.KubefedCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster1",
+				Labels: map[string]string{"region": "eu", "env": "prod"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster2",
+				Labels: map[string]string{"region": "eu"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster3",
+				Labels: map[string]string{"region": "us"},
+			},
+		},
+	}
+	newSelectorItem := func(matchLabels map[string]interface{}, path string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			ClusterSelectorField: map[string]interface{}{
+				MatchLabelsField: matchLabels,
+			},
+			ClusterOverridesField: []interface{}{
+				map[string]interface{}{PathField: path, ValueField: value},
+			},
+		}
+	}
+	newNamedItem := func(clusterName, path string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			ClusterNameField: clusterName,
+			ClusterOverridesField: []interface{}{
+				map[string]interface{}{PathField: path, ValueField: value},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		overrideItems []interface{}
+		expected      OverridesMap
+		expectedError bool
+	}{
+		"selector overrides apply to matching clusters": {
+			overrideItems: []interface{}{
+				newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/replicas", int64(2)),
+			},
+			expected: OverridesMap{
+				"cluster1": ClusterOverrides{{Path: "/spec/replicas", Value: float64(2)}},
+				"cluster2": ClusterOverrides{{Path: "/spec/replicas", Value: float64(2)}},
+			},
+		},
+		"cluster name takes precedence over selector for the same path": {
+			overrideItems: []interface{}{
+				newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/replicas", int64(2)),
+				newNamedItem("cluster1", "/spec/replicas", int64(5)),
+			},
+			expected: OverridesMap{
+				"cluster1": ClusterOverrides{{Path: "/spec/replicas", Value: float64(5)}},
+				"cluster2": ClusterOverrides{{Path: "/spec/replicas", Value: float64(2)}},
+			},
+		},
+		"selector and cluster name overrides of different paths are merged": {
+			overrideItems: []interface{}{
+				newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/paused", true),
+				newNamedItem("cluster1", "/spec/replicas", int64(5)),
+			},
+			expected: OverridesMap{
+				"cluster1": ClusterOverrides{
+					{Path: "/spec/paused", Value: true},
+					{Path: "/spec/replicas", Value: float64(5)},
+				},
+				"cluster2": ClusterOverrides{{Path: "/spec/paused", Value: true}},
+			},
+		},
+		"overrides of multiple matching selectors are combined": {
+			overrideItems: []interface{}{
+				newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/replicas", int64(2)),
+				newSelectorItem(map[string]interface{}{"env": "prod"}, "/spec/paused", true),
+			},
+			expected: OverridesMap{
+				"cluster1": ClusterOverrides{
+					{Path: "/spec/replicas", Value: float64(2)},
+					{Path: "/spec/paused", Value: true},
+				},
+				"cluster2": ClusterOverrides{{Path: "/spec/replicas", Value: float64(2)}},
+			},
+		},
+		"conflicting selectors are rejected": {
+			overrideItems: []interface{}{
+				newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/replicas", int64(2)),
+				newSelectorItem(map[string]interface{}{"env": "prod"}, "/spec/replicas", int64(3)),
+			},
+			expectedError: true,
+		},
+		"both cluster name and selector are rejected": {
+			overrideItems: []interface{}{
+				func() map[string]interface{} {
+					item := newSelectorItem(map[string]interface{}{"region": "eu"}, "/spec/replicas", int64(2))
+					item[ClusterNameField] = "cluster1"
+					return item
+				}(),
+			},
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					SpecField: map[string]interface{}{
+						OverridesField: testCase.overrideItems,
+					},
+				},
+			}
+			overridesMap, err := GetOverridesForClusters(obj, clusters)
+			if testCase.expectedError {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(testCase.expected, overridesMap) {
+				t.Fatalf("Expected %v, got %v", testCase.expected, overridesMap)
+			}
+		})
+	}
+}
+
+func TestSetOverridesRetainsSelectorOverrides(t *testing.T) {
+	selectorItem := map[string]interface{}{
+		ClusterSelectorField: map[string]interface{}{
+			MatchLabelsField: map[string]interface{}{"region": "eu"},
+		},
+		ClusterOverridesField: []interface{}{
+			map[string]interface{}{PathField: "/spec/paused", ValueField: true},
+		},
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			SpecField: map[string]interface{}{
+				OverridesField: []interface{}{selectorItem},
+			},
+		},
+	}
+	overridesMap := OverridesMap{
+		"cluster1": ClusterOverrides{{Path: "/spec/replicas", Value: int64(2)}},
+	}
+	if err := SetOverrides(obj, overridesMap); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	hasSelectorOverrides, err := HasSelectorOverrides(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !hasSelectorOverrides {
+		t.Fatalf("Expected selector overrides to be retained")
+	}
+	namedOverrides, err := GetOverrides(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := namedOverrides["cluster1"]; !ok || len(namedOverrides) != 1 {
+		t.Fatalf("Expected overrides for cluster1 only, got %v", namedOverrides)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 )
 
 type GenericClusterReference struct {
@@ -27,8 +29,10 @@ type GenericClusterReference struct {
 }
 
 type GenericPlacementFields struct {
-	Clusters        []GenericClusterReference `json:"clusters,omitempty"`
-	ClusterSelector *metav1.LabelSelector     `json:"clusterSelector,omitempty"`
+	Clusters          []GenericClusterReference  `json:"clusters,omitempty"`
+	ClusterSelector   *metav1.LabelSelector      `json:"clusterSelector,omitempty"`
+	SpreadConstraints *fedv1a1.SpreadConstraints `json:"spreadConstraints,omitempty"`
+	Failover          *fedv1a1.FailoverPlacement `json:"failover,omitempty"`
 }
 
 type GenericPlacementSpec struct {
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+// PolicyInformers are informers for the propagation and override
+// policies read by the sync controllers of every federated type.  The
+// informers are shared so that the host cluster is watched once for
+// each kind of policy rather than once per federated type.
+type PolicyInformers struct {
+	// Informers for the namespaced policies in the target namespace.
+	PropagationPolicies cache.SharedInformer
+	OverridePolicies    cache.SharedInformer
+
+	// Informers for cluster-scoped policies and the namespaces their
+	// namespace selectors are matched against.  Will only be
+	// initialized if federation is deployed cluster-wide.
+	ClusterPropagationPolicies cache.SharedInformer
+	ClusterOverridePolicies    cache.SharedInformer
+	Namespaces                 cache.SharedInformer
+}
+
+// NewPolicyInformers returns the policy informers for the given
+// configuration.  The informers must be started with Run.
+func NewPolicyInformers(controllerConfig *ControllerConfig) (*PolicyInformers, error) {
+	i := &PolicyInformers{}
+
+	var err error
+	kubeConfig := controllerConfig.KubeConfig
+	targetNamespace := controllerConfig.TargetNamespace
+	i.PropagationPolicies, err = NewGenericSharedInformer(kubeConfig, targetNamespace, &fedv1a1.PropagationPolicy{}, NoResyncPeriod)
+	if err != nil {
+		return nil, err
+	}
+	i.OverridePolicies, err = NewGenericSharedInformer(kubeConfig, targetNamespace, &fedv1a1.OverridePolicy{}, NoResyncPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	if controllerConfig.LimitedScope() {
+		// Cluster-scoped policies are not considered when federation
+		// is limited to a single namespace.
+		return i, nil
+	}
+
+	i.ClusterPropagationPolicies, err = NewGenericSharedInformer(kubeConfig, metav1.NamespaceAll, &fedv1a1.ClusterPropagationPolicy{}, NoResyncPeriod)
+	if err != nil {
+		return nil, err
+	}
+	i.ClusterOverridePolicies, err = NewGenericSharedInformer(kubeConfig, metav1.NamespaceAll, &fedv1a1.ClusterOverridePolicy{}, NoResyncPeriod)
+	if err != nil {
+		return nil, err
+	}
+	i.Namespaces, err = NewGenericSharedInformer(kubeConfig, metav1.NamespaceAll, &corev1.Namespace{}, NoResyncPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (i *PolicyInformers) informers() []cache.SharedInformer {
+	informers := []cache.SharedInformer{
+		i.PropagationPolicies,
+		i.OverridePolicies,
+	}
+	if i.Namespaces != nil {
+		informers = append(informers,
+			i.ClusterPropagationPolicies,
+			i.ClusterOverridePolicies,
+			i.Namespaces,
+		)
+	}
+	return informers
+}
+
+// Run starts the informers.  It must only be called once.
+func (i *PolicyInformers) Run(stopChan <-chan struct{}) {
+	for _, informer := range i.informers() {
+		go informer.Run(stopChan)
+	}
+}
+
+func (i *PolicyInformers) HasSynced() bool {
+	for _, informer := range i.informers() {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
@@ -35,6 +35,11 @@ const (
 // ObjectVersion retrieves the field type-prefixed value used for
 // determining currency of the given cluster object.
 func ObjectVersion(clusterObj *unstructured.Unstructured) string {
+	// Objects in pull-mode clusters are versioned by the
+	// MemberResource they are read from.
+	if version, ok := clusterObj.GetAnnotations()[PullVersionAnnotation]; ok {
+		return version
+	}
 	generation := clusterObj.GetGeneration()
 	if generation != 0 {
 		return fmt.Sprintf("%s%d", generationPrefix, generation)
@@ -0,0 +1,584 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// These labels identify the cluster and the API resource of the
+	// object held by a MemberResource.
+	MemberResourceClusterLabel  = "kubefed.k8s.io/cluster-name"
+	MemberResourceResourceLabel = "kubefed.k8s.io/target-resource"
+
+	// PullVersionAnnotation records the version of an object read
+	// from a MemberResource.  It is not written to member clusters.
+	PullVersionAnnotation = "kubefed.k8s.io/pull-version"
+
+	pullVersionPrefix = "pull:"
+)
+
+// MemberResourceGroupVersionResource identifies the MemberResource
+// API resource.
+var MemberResourceGroupVersionResource = fedv1a1.SchemeGroupVersion.WithResource("memberresources")
+
+// SecretGroupVersionResource identifies the Secret API resource.
+var SecretGroupVersionResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// secretDataFields are the fields of a Secret that hold its data.
+var secretDataFields = []string{"data", "stringData"}
+
+// PullClusterNamespace returns the namespace of the host cluster that
+// holds the MemberResources of the named pull-mode cluster.  Each
+// cluster has its own namespace so that the agent of a cluster can be
+// restricted to the resources destined for that cluster.
+func PullClusterNamespace(fedNamespace, clusterName string) string {
+	return fmt.Sprintf("%s-%s", fedNamespace, clusterName)
+}
+
+// IsPulledSecret indicates whether the given object is a Secret read
+// from a MemberResource, whose data is not reported by the agent.
+func IsPulledSecret(obj *unstructured.Unstructured) bool {
+	if _, ok := obj.GetAnnotations()[PullVersionAnnotation]; !ok {
+		return false
+	}
+	return obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret"
+}
+
+// RemoveSecretData removes the data of the given Secret object.
+func RemoveSecretData(obj *unstructured.Unstructured) {
+	for _, field := range secretDataFields {
+		delete(obj.Object, field)
+	}
+}
+
+// MemberResourceName returns the name of the MemberResource that holds
+// the named object of the given API resource for the given cluster.
+func MemberResourceName(clusterName string, groupResource schema.GroupResource, namespace, name string) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s/%s/%s", groupResource.String(), namespace, name)
+	return fmt.Sprintf("%s-%016x", clusterName, hash.Sum64())
+}
+
+// pullResourceClient is the ResourceClient of a pull-mode cluster.
+// Rather than accessing the cluster, it writes the objects destined
+// for the cluster to MemberResources in the host cluster and reads
+// objects as last reported by the agent running in the cluster.
+type pullResourceClient struct {
+	client dynamic.ResourceInterface
+	// Client for the secrets holding the data of Secret objects
+	secretClient  dynamic.ResourceInterface
+	clusterName   string
+	groupVersion  schema.GroupVersion
+	groupResource schema.GroupResource
+	namespaced    bool
+	kind          string
+}
+
+// NewPullResourceClient returns a ResourceClient for the given API
+// resource of the given pull-mode cluster that is backed by the
+// MemberResources in the namespace of the cluster in the host cluster.
+func NewPullResourceClient(hostConfig *rest.Config, fedNamespace, clusterName string, apiResource *metav1.APIResource) (ResourceClient, error) {
+	client, err := dynamic.NewForConfig(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	namespace := PullClusterNamespace(fedNamespace, clusterName)
+	return newPullResourceClient(client.Resource(MemberResourceGroupVersionResource).Namespace(namespace),
+		client.Resource(SecretGroupVersionResource).Namespace(namespace), clusterName, apiResource), nil
+}
+
+func newPullResourceClient(client, secretClient dynamic.ResourceInterface, clusterName string, apiResource *metav1.APIResource) *pullResourceClient {
+	return &pullResourceClient{
+		client:        client,
+		secretClient:  secretClient,
+		clusterName:   clusterName,
+		groupVersion:  schema.GroupVersion{Group: apiResource.Group, Version: apiResource.Version},
+		groupResource: schema.GroupResource{Group: apiResource.Group, Resource: apiResource.Name},
+		namespaced:    apiResource.Namespaced,
+		kind:          apiResource.Kind,
+	}
+}
+
+func (c *pullResourceClient) Resources(namespace string) dynamic.ResourceInterface {
+	if !c.namespaced {
+		namespace = ""
+	}
+	return &memberResources{pullResourceClient: c, namespace: namespace}
+}
+
+func (c *pullResourceClient) Kind() string {
+	return c.kind
+}
+
+func (c *pullResourceClient) Create(obj *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, error) {
+	return c.create(obj, fieldManager, metav1.CreateOptions{})
+}
+
+func (c *pullResourceClient) Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	return c.write(obj, fieldManager, force)
+}
+
+// memberResourceSelector selects the MemberResources of the API
+// resource of the client for the cluster of the client.
+func (c *pullResourceClient) memberResourceSelector() string {
+	return labels.SelectorFromSet(labels.Set{
+		MemberResourceClusterLabel:  c.clusterName,
+		MemberResourceResourceLabel: c.groupResource.String(),
+	}).String()
+}
+
+func (c *pullResourceClient) memberResourceName(namespace, name string) string {
+	return MemberResourceName(c.clusterName, c.groupResource, namespace, name)
+}
+
+// get retrieves the MemberResource holding the named object.  A
+// MemberResource holding a different object is treated as not found.
+func (c *pullResourceClient) get(namespace, name string) (*fedv1a1.MemberResource, error) {
+	obj, err := c.client.Get(c.memberResourceName(namespace, name), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, apierrors.NewNotFound(c.groupResource, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	memberResource, err := c.toMemberResource(obj)
+	if err != nil {
+		return nil, err
+	}
+	target, err := unstructuredFromRaw(&memberResource.Spec.Object)
+	if err != nil {
+		return nil, err
+	}
+	if target.GetNamespace() != namespace || target.GetName() != name {
+		return nil, apierrors.NewNotFound(c.groupResource, name)
+	}
+	return memberResource, nil
+}
+
+// create creates a MemberResource holding the given object.  If a
+// field manager is given, the agent creates the object with
+// server-side apply as that field manager.
+func (c *pullResourceClient) create(obj *unstructured.Unstructured, fieldManager string, options metav1.CreateOptions) (*unstructured.Unstructured, error) {
+	memberResource := &fedv1a1.MemberResource{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fedv1a1.SchemeGroupVersion.String(),
+			Kind:       "MemberResource",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: c.memberResourceName(obj.GetNamespace(), obj.GetName()),
+			Labels: map[string]string{
+				MemberResourceClusterLabel:  c.clusterName,
+				MemberResourceResourceLabel: c.groupResource.String(),
+			},
+		},
+		Spec: fedv1a1.MemberResourceSpec{
+			ClusterName:  c.clusterName,
+			Resource:     c.groupResource.Resource,
+			FieldManager: fieldManager,
+		},
+	}
+	data, err := setSpecObject(memberResource, obj)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		// The data secret is written first so that it exists when
+		// the agent observes the MemberResource.
+		if err := c.writeDataSecret(memberResource, data, nil); err != nil {
+			return nil, err
+		}
+	}
+	memberResourceObj, err := GetUnstructured(memberResource)
+	if err != nil {
+		return nil, err
+	}
+	createdObj, err := c.client.Create(memberResourceObj, options)
+	if apierrors.IsAlreadyExists(err) {
+		return nil, apierrors.NewAlreadyExists(c.groupResource, obj.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		// The data secret is garbage collected with the
+		// MemberResource.
+		if err := c.writeDataSecret(memberResource, data, createdObj); err != nil {
+			return nil, err
+		}
+	}
+	return c.appliedObject(createdObj)
+}
+
+// write updates the MemberResource holding the given object.  If the
+// object is no longer labeled as managed, the MemberResource is
+// deleted and the agent leaves the object in the cluster.
+func (c *pullResourceClient) write(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	memberResource, err := c.get(obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if len(obj.GetResourceVersion()) > 0 {
+		// The resource version of an object read from a
+		// MemberResource is that of the MemberResource.
+		memberResource.ResourceVersion = obj.GetResourceVersion()
+	}
+	data, err := setSpecObject(memberResource, obj)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		owner, err := GetUnstructured(memberResource)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.writeDataSecret(memberResource, data, owner); err != nil {
+			return nil, err
+		}
+	}
+	memberResource.Spec.FieldManager = fieldManager
+	memberResource.Spec.Force = force
+	orphan := !HasManagedLabel(obj)
+	memberResource.Spec.Orphan = orphan
+
+	memberResourceObj, err := GetUnstructured(memberResource)
+	if err != nil {
+		return nil, err
+	}
+	updatedObj, err := c.client.Update(memberResourceObj, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, apierrors.NewNotFound(c.groupResource, obj.GetName())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if orphan {
+		err := c.client.Delete(memberResource.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		return c.targetObjectFor(updatedObj)
+	}
+	return c.appliedObject(updatedObj)
+}
+
+// writeDataSecret writes the given data of a Secret object to the data
+// secret of the given MemberResource, and records the version of the
+// data secret in the MemberResource.  If an owner is provided, the
+// data secret is owned by it.
+func (c *pullResourceClient) writeDataSecret(memberResource *fedv1a1.MemberResource, data map[string]interface{}, owner *unstructured.Unstructured) error {
+	name := memberResource.Name
+	memberResource.Spec.DataSecretName = name
+	secret, err := c.secretClient.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &unstructured.Unstructured{}
+		secret.SetAPIVersion("v1")
+		secret.SetKind("Secret")
+		secret.SetName(name)
+		secret.SetLabels(map[string]string{MemberResourceClusterLabel: c.clusterName})
+	} else if err != nil {
+		return errors.Wrapf(err, "Failed to retrieve the data secret %q", name)
+	}
+	desired := secret.DeepCopy()
+	RemoveSecretData(desired)
+	for field, value := range data {
+		desired.Object[field] = value
+	}
+	if owner != nil {
+		desired.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: fedv1a1.SchemeGroupVersion.String(),
+			Kind:       "MemberResource",
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+		}})
+	}
+	switch {
+	case len(secret.GetResourceVersion()) == 0:
+		secret, err = c.secretClient.Create(desired, metav1.CreateOptions{})
+	case !reflect.DeepEqual(secret, desired):
+		secret, err = c.secretClient.Update(desired, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Failed to write the data secret %q", name)
+	}
+	memberResource.Spec.DataSecretVersion = secret.GetResourceVersion()
+	return nil
+}
+
+// appliedObject returns the object read from the given MemberResource
+// or the error reported by the agent if it failed to write the
+// current generation of the MemberResource.
+func (c *pullResourceClient) appliedObject(memberResourceObj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	memberResource, err := c.toMemberResource(memberResourceObj)
+	if err != nil {
+		return nil, err
+	}
+	status := memberResource.Status
+	if len(status.Error) > 0 && status.ObservedGeneration == memberResource.Generation {
+		return nil, errors.Errorf("agent of cluster %q failed to write the resource: %s", c.clusterName, status.Error)
+	}
+	return targetObject(memberResource)
+}
+
+func (c *pullResourceClient) targetObjectFor(memberResourceObj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	memberResource, err := c.toMemberResource(memberResourceObj)
+	if err != nil {
+		return nil, err
+	}
+	return targetObject(memberResource)
+}
+
+// targetObject returns the object held by the given MemberResource.
+// The object as last reported by the agent is returned if available,
+// otherwise the object destined for the cluster.  The data of a
+// Secret is not included in either.  The resource
+// version of the object is that of the MemberResource so that the
+// object can be watched, and the version of the object is recorded
+// in an annotation.
+func targetObject(memberResource *fedv1a1.MemberResource) (*unstructured.Unstructured, error) {
+	reported := memberResource.Status.Object != nil && len(memberResource.Status.Object.Raw) > 0
+	raw := &memberResource.Spec.Object
+	if reported {
+		raw = memberResource.Status.Object
+	}
+	obj, err := unstructuredFromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberResource.Spec.DataSecretName) > 0 {
+		RemoveSecretData(obj)
+	}
+	clusterVersion := ""
+	if reported {
+		clusterVersion = ObjectVersion(obj)
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[PullVersionAnnotation] = fmt.Sprintf("%s%d:%d:%s", pullVersionPrefix,
+		memberResource.Generation, memberResource.Status.ObservedGeneration, clusterVersion)
+	obj.SetAnnotations(annotations)
+	obj.SetResourceVersion(memberResource.ResourceVersion)
+	obj.SetDeletionTimestamp(memberResource.DeletionTimestamp)
+	return obj, nil
+}
+
+func (c *pullResourceClient) toMemberResource(obj *unstructured.Unstructured) (*fedv1a1.MemberResource, error) {
+	memberResource := &fedv1a1.MemberResource{}
+	if err := UnstructuredToInterface(obj, memberResource); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode MemberResource %q", obj.GetName())
+	}
+	return memberResource, nil
+}
+
+func (c *pullResourceClient) notSupported(action string) error {
+	return apierrors.NewMethodNotSupported(c.groupResource, fmt.Sprintf("%s in pull-mode cluster %q", action, c.clusterName))
+}
+
+// setSpecObject sets the given object as the object destined for the
+// cluster of the given MemberResource.  The data of a Secret object is
+// not included and is returned instead.
+func setSpecObject(memberResource *fedv1a1.MemberResource, obj *unstructured.Unstructured) (map[string]interface{}, error) {
+	specObj := obj.DeepCopy()
+	var data map[string]interface{}
+	if memberResource.Spec.Resource == SecretGroupVersionResource.Resource && obj.GetAPIVersion() == "v1" {
+		data = map[string]interface{}{}
+		for _, field := range secretDataFields {
+			if value, ok := specObj.Object[field]; ok {
+				data[field] = value
+			}
+		}
+		RemoveSecretData(specObj)
+	}
+	annotations := specObj.GetAnnotations()
+	if _, ok := annotations[PullVersionAnnotation]; ok {
+		delete(annotations, PullVersionAnnotation)
+		specObj.SetAnnotations(annotations)
+	}
+	specObj.SetResourceVersion("")
+	specObj.SetDeletionTimestamp(nil)
+	raw, err := specObj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	memberResource.Spec.Object = runtime.RawExtension{Raw: raw}
+	return data, nil
+}
+
+func unstructuredFromRaw(raw *runtime.RawExtension) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode the object of a MemberResource")
+	}
+	return obj, nil
+}
+
+// memberResources implements dynamic.ResourceInterface for the objects
+// of an API resource in a namespace of a pull-mode cluster.
+type memberResources struct {
+	*pullResourceClient
+	namespace string
+}
+
+func (r *memberResources) Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, r.notSupported("create subresource")
+	}
+	return r.create(obj, "", options)
+}
+
+func (r *memberResources) Update(obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, r.notSupported("update subresource")
+	}
+	return r.write(obj, "", false)
+}
+
+func (r *memberResources) UpdateStatus(obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return nil, r.notSupported("update status")
+}
+
+func (r *memberResources) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	if len(subresources) > 0 {
+		return r.notSupported("delete subresource")
+	}
+	if _, err := r.get(r.namespace, name); err != nil {
+		return err
+	}
+	err := r.client.Delete(r.memberResourceName(r.namespace, name), options)
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewNotFound(r.groupResource, name)
+	}
+	return err
+}
+
+func (r *memberResources) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	return r.notSupported("delete collection")
+}
+
+func (r *memberResources) Get(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, r.notSupported("get subresource")
+	}
+	memberResource, err := r.get(r.namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return targetObject(memberResource)
+}
+
+func (r *memberResources) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	selector, err := r.listSelector(opts)
+	if err != nil {
+		return nil, err
+	}
+	memberResourceList, err := r.client.List(r.memberResourceListOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion(r.groupVersion.String())
+	list.SetKind(r.kind + "List")
+	list.SetResourceVersion(memberResourceList.GetResourceVersion())
+	for i := range memberResourceList.Items {
+		obj, err := r.targetObjectFor(&memberResourceList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if r.inNamespace(obj) && selector.Matches(labels.Set(obj.GetLabels())) {
+			list.Items = append(list.Items, *obj)
+		}
+	}
+	return list, nil
+}
+
+func (r *memberResources) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	selector, err := r.listSelector(opts)
+	if err != nil {
+		return nil, err
+	}
+	w, err := r.client.Watch(r.memberResourceListOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		memberResourceObj, ok := in.Object.(*unstructured.Unstructured)
+		if !ok {
+			return in, in.Type == watch.Error
+		}
+		obj, err := r.targetObjectFor(memberResourceObj)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return in, false
+		}
+		if !r.inNamespace(obj) {
+			return in, false
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			// An object that no longer matches the selector is
+			// deleted from the perspective of the watcher.
+			return watch.Event{Type: watch.Deleted, Object: obj}, in.Type == watch.Modified
+		}
+		return watch.Event{Type: in.Type, Object: obj}, true
+	}), nil
+}
+
+func (r *memberResources) Patch(name string, pt types.PatchType, data []byte, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, r.notSupported("patch")
+}
+
+// listSelector returns the label selector of the given options, which
+// is applied to objects rather than to MemberResources.
+func (r *memberResources) listSelector(opts metav1.ListOptions) (labels.Selector, error) {
+	if len(opts.FieldSelector) > 0 {
+		return nil, r.notSupported("select by field")
+	}
+	return labels.Parse(opts.LabelSelector)
+}
+
+func (r *memberResources) memberResourceListOptions(opts metav1.ListOptions) metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector:   r.memberResourceSelector(),
+		ResourceVersion: opts.ResourceVersion,
+		TimeoutSeconds:  opts.TimeoutSeconds,
+		Watch:           opts.Watch,
+	}
+}
+
+func (r *memberResources) inNamespace(obj *unstructured.Unstructured) bool {
+	return r.namespace == metav1.NamespaceAll || obj.GetNamespace() == r.namespace
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// fakeMemberResources is an in-memory store of MemberResources that
+// increments the generation of a MemberResource when its spec changes.
+type fakeMemberResources struct {
+	objs    map[string]*unstructured.Unstructured
+	version int
+}
+
+var memberResourceGroupResource = MemberResourceGroupVersionResource.GroupResource()
+
+func (f *fakeMemberResources) Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if _, ok := f.objs[obj.GetName()]; ok {
+		return nil, apierrors.NewAlreadyExists(memberResourceGroupResource, obj.GetName())
+	}
+	obj = obj.DeepCopy()
+	obj.SetGeneration(1)
+	f.store(obj)
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeMemberResources) Update(obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	existing, ok := f.objs[obj.GetName()]
+	if !ok {
+		return nil, apierrors.NewNotFound(memberResourceGroupResource, obj.GetName())
+	}
+	if existing.GetResourceVersion() != obj.GetResourceVersion() {
+		return nil, apierrors.NewConflict(memberResourceGroupResource, obj.GetName(), nil)
+	}
+	obj = obj.DeepCopy()
+	obj.Object["status"] = existing.Object["status"]
+	obj.SetGeneration(existing.GetGeneration())
+	existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !specEqual(existingSpec, spec) {
+		obj.SetGeneration(existing.GetGeneration() + 1)
+	}
+	f.store(obj)
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeMemberResources) UpdateStatus(obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	existing, ok := f.objs[obj.GetName()]
+	if !ok {
+		return nil, apierrors.NewNotFound(memberResourceGroupResource, obj.GetName())
+	}
+	existing.Object["status"] = obj.Object["status"]
+	f.store(existing)
+	return existing.DeepCopy(), nil
+}
+
+func (f *fakeMemberResources) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	if _, ok := f.objs[name]; !ok {
+		return apierrors.NewNotFound(memberResourceGroupResource, name)
+	}
+	delete(f.objs, name)
+	return nil
+}
+
+func (f *fakeMemberResources) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	return nil
+}
+
+func (f *fakeMemberResources) Get(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	obj, ok := f.objs[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(memberResourceGroupResource, name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeMemberResources) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetResourceVersion(strconv.Itoa(f.version))
+	for _, obj := range f.objs {
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			list.Items = append(list.Items, *obj.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeMemberResources) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return watch.NewEmptyWatch(), nil
+}
+
+func (f *fakeMemberResources) Patch(name string, pt types.PatchType, data []byte, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, nil
+}
+
+func (f *fakeMemberResources) store(obj *unstructured.Unstructured) {
+	f.version++
+	obj.SetResourceVersion(strconv.Itoa(f.version))
+	f.objs[obj.GetName()] = obj
+}
+
+func specEqual(a, b map[string]interface{}) bool {
+	aObj := &unstructured.Unstructured{Object: a}
+	bObj := &unstructured.Unstructured{Object: b}
+	aJSON, _ := aObj.MarshalJSON()
+	bJSON, _ := bObj.MarshalJSON()
+	return string(aJSON) == string(bJSON)
+}
+
+func TestPullResourceClient(t *testing.T) {
+	apiResource := &metav1.APIResource{Group: "apps", Version: "v1", Kind: "Deployment", Name: "deployments", Namespaced: true}
+	fake := &fakeMemberResources{objs: make(map[string]*unstructured.Unstructured)}
+	secrets := &fakeMemberResources{objs: make(map[string]*unstructured.Unstructured)}
+	client := newPullResourceClient(fake, secrets, "edge1", apiResource)
+	resources := client.Resources("ns")
+
+	newDeployment := func(name string, replicas int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": replicas},
+		}}
+		obj.SetAPIVersion("apps/v1")
+		obj.SetKind("Deployment")
+		obj.SetNamespace("ns")
+		obj.SetName(name)
+		AddManagedLabel(obj)
+		return obj
+	}
+	reportStatus := func(name string, clusterObj *unstructured.Unstructured, errorMessage string) {
+		memberResourceName := MemberResourceName("edge1", schema.GroupResource{Group: "apps", Resource: "deployments"}, "ns", name)
+		memberResource := fake.objs[memberResourceName]
+		raw, err := clusterObj.MarshalJSON()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		statusObj := map[string]interface{}{}
+		statusUnstructured := &unstructured.Unstructured{}
+		if err := statusUnstructured.UnmarshalJSON(raw); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		statusObj["object"] = statusUnstructured.Object
+		statusObj["observedGeneration"] = memberResource.GetGeneration()
+		statusObj["error"] = errorMessage
+		memberResource.Object["status"] = statusObj
+		fake.store(memberResource)
+	}
+
+	// Creation writes a MemberResource for the cluster.
+	createdObj, err := resources.Create(newDeployment("foo", 1), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version := ObjectVersion(createdObj); version != "pull:1:0:" {
+		t.Fatalf("Expected version %q of the created object, got %q", "pull:1:0:", version)
+	}
+	if _, err := resources.Create(newDeployment("foo", 1), metav1.CreateOptions{}); !apierrors.IsAlreadyExists(err) {
+		t.Fatalf("Expected an already exists error, got %v", err)
+	}
+	if len(fake.objs) != 1 {
+		t.Fatalf("Expected 1 MemberResource, got %d", len(fake.objs))
+	}
+	for _, memberResource := range fake.objs {
+		if memberResource.GetLabels()[MemberResourceClusterLabel] != "edge1" || memberResource.GetLabels()[MemberResourceResourceLabel] != "deployments.apps" {
+			t.Fatalf("Unexpected labels of MemberResource: %v", memberResource.GetLabels())
+		}
+		if strings.Contains(string(mustMarshal(t, memberResource)), PullVersionAnnotation) {
+			t.Fatalf("Expected the version annotation not to be written")
+		}
+	}
+
+	// The object reported by the agent is read in place of the
+	// object destined for the cluster.
+	clusterObj := newDeployment("foo", 1)
+	clusterObj.SetGeneration(4)
+	clusterObj.SetResourceVersion("1234")
+	reportStatus("foo", clusterObj, "")
+	obj, err := resources.Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if obj.GetGeneration() != 4 {
+		t.Fatalf("Expected the reported object, got %v", obj)
+	}
+	if version := ObjectVersion(obj); version != "pull:1:1:gen:4" {
+		t.Fatalf("Expected version %q, got %q", "pull:1:1:gen:4", version)
+	}
+	if _, err := client.Resources("other").Get("foo", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected a not found error for another namespace, got %v", err)
+	}
+
+	// Listing filters by namespace and label selector.
+	if _, err := resources.Create(newDeployment("bar", 1), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	unmanaged := newDeployment("baz", 1)
+	RemoveManagedLabel(unmanaged)
+	if _, err := resources.Create(unmanaged, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	list, err := resources.List(metav1.ListOptions{LabelSelector: ManagedByFederationLabelKey + "=" + ManagedByFederationLabelValue})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("Expected 2 managed objects, got %d", len(list.Items))
+	}
+	list, err = client.Resources("other").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("Expected no objects in another namespace, got %d", len(list.Items))
+	}
+
+	// An update reports the error of the agent for the current
+	// generation.
+	reportStatus("foo", clusterObj, "forbidden")
+	obj, err = resources.Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	desiredObj := newDeployment("foo", 1)
+	desiredObj.SetResourceVersion(obj.GetResourceVersion())
+	if _, err := resources.Update(desiredObj, metav1.UpdateOptions{}); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Fatalf("Expected the error of the agent, got %v", err)
+	}
+	obj, err = resources.Get("foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	desiredObj = newDeployment("foo", 2)
+	desiredObj.SetResourceVersion(obj.GetResourceVersion())
+	updatedObj, err := resources.Update(desiredObj, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version := ObjectVersion(updatedObj); version != "pull:2:1:gen:4" {
+		t.Fatalf("Expected version %q, got %q", "pull:2:1:gen:4", version)
+	}
+
+	// Removing the managed label orphans the object.
+	RemoveManagedLabel(updatedObj)
+	if _, err := resources.Update(updatedObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := resources.Get("foo", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected the MemberResource of an orphaned object to be deleted, got %v", err)
+	}
+
+	if err := resources.Delete("bar", &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := resources.Delete("bar", &metav1.DeleteOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestPullResourceClientSecretData(t *testing.T) {
+	apiResource := &metav1.APIResource{Version: "v1", Kind: "Secret", Name: "secrets", Namespaced: true}
+	fake := &fakeMemberResources{objs: make(map[string]*unstructured.Unstructured)}
+	secrets := &fakeMemberResources{objs: make(map[string]*unstructured.Unstructured)}
+	resources := newPullResourceClient(fake, secrets, "edge1", apiResource).Resources("ns")
+
+	newSecret := func(password string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"type": "Opaque",
+			"data": map[string]interface{}{"password": password},
+		}}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("Secret")
+		obj.SetNamespace("ns")
+		obj.SetName("foo")
+		AddManagedLabel(obj)
+		return obj
+	}
+	memberResourceName := MemberResourceName("edge1", schema.GroupResource{Resource: "secrets"}, "ns", "foo")
+
+	createdObj, err := resources.Create(newSecret("c2VjcmV0"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := createdObj.Object["data"]; ok {
+		t.Fatalf("Expected the data not to be read from the MemberResource")
+	}
+	memberResource := fake.objs[memberResourceName]
+	if strings.Contains(string(mustMarshal(t, memberResource)), "c2VjcmV0") {
+		t.Fatalf("Expected the data not to be written to the MemberResource")
+	}
+	dataSecret, ok := secrets.objs[memberResourceName]
+	if !ok {
+		t.Fatalf("Expected a data secret named %q", memberResourceName)
+	}
+	password, _, _ := unstructured.NestedString(dataSecret.Object, "data", "password")
+	if password != "c2VjcmV0" {
+		t.Fatalf("Expected the data to be written to the data secret, got %v", dataSecret.Object)
+	}
+	if owners := dataSecret.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != memberResourceName {
+		t.Fatalf("Expected the data secret to be owned by the MemberResource, got %v", owners)
+	}
+	dataSecretName, _, _ := unstructured.NestedString(memberResource.Object, "spec", "dataSecretName")
+	if dataSecretName != memberResourceName {
+		t.Fatalf("Expected data secret name %q, got %q", memberResourceName, dataSecretName)
+	}
+
+	// A change to the data changes the generation of the
+	// MemberResource.
+	desiredObj := newSecret("bmV3")
+	desiredObj.SetResourceVersion(createdObj.GetResourceVersion())
+	if _, err := resources.Update(desiredObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if generation := fake.objs[memberResourceName].GetGeneration(); generation != 2 {
+		t.Fatalf("Expected generation 2 of the MemberResource, got %d", generation)
+	}
+	password, _, _ = unstructured.NestedString(secrets.objs[memberResourceName].Object, "data", "password")
+	if password != "bmV3" {
+		t.Fatalf("Expected the data secret to be updated, got %v", secrets.objs[memberResourceName].Object)
+	}
+}
+
+func mustMarshal(t *testing.T, obj *unstructured.Unstructured) []byte {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return data
+}
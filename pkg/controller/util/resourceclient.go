@@ -17,22 +17,44 @@ limitations under the License.
 package util
 
 import (
+	"context"
+	"net/http"
+	"strconv"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 )
 
+// ApplyPatchType is the patch type of a server-side apply request.
+// It is not defined by the vendored version of apimachinery.
+const ApplyPatchType types.PatchType = "application/apply-patch+yaml"
+
 type ResourceClient interface {
 	Resources(namespace string) dynamic.ResourceInterface
 	Kind() string
+	// Create creates the given object with the given field manager
+	// recorded as the owner of its fields.
+	Create(obj *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, error)
+	// Apply writes the given object with server-side apply as the
+	// given field manager.  If force is true, fields managed by
+	// other field managers are taken over instead of conflicting.
+	Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error)
 }
 
 type resourceClient struct {
 	client      dynamic.Interface
+	restClient  rest.Interface
 	apiResource schema.GroupVersionResource
 	namespaced  bool
 	kind        string
+	dryRun      bool
 }
 
 func NewResourceClient(config *rest.Config, apiResource *metav1.APIResource) (ResourceClient, error) {
@@ -46,15 +68,50 @@ func NewResourceClient(config *rest.Config, apiResource *metav1.APIResource) (Re
 		return nil, err
 	}
 
+	// The dynamic client does not support the parameters of
+	// server-side apply, so apply requests are sent with a rest
+	// client that addresses resources by absolute path.
+	restConfig := rest.CopyConfig(config)
+	restConfig.GroupVersion = &schema.GroupVersion{}
+	restConfig.ContentType = runtime.ContentTypeJSON
+	restConfig.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	if restConfig.UserAgent == "" {
+		restConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	restClient, err := rest.RESTClientFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &resourceClient{
 		client:      client,
+		restClient:  restClient,
 		apiResource: resource,
 		namespaced:  apiResource.Namespaced,
 		kind:        apiResource.Kind,
 	}, nil
 }
 
+// NewDryRunResourceClient returns a client whose writes are
+// validated, defaulted and admitted by the API server without being
+// persisted.
+func NewDryRunResourceClient(config *rest.Config, apiResource *metav1.APIResource) (ResourceClient, error) {
+	client, err := NewResourceClient(config, apiResource)
+	if err != nil {
+		return nil, err
+	}
+	client.(*resourceClient).dryRun = true
+	return client, nil
+}
+
 func (c *resourceClient) Resources(namespace string) dynamic.ResourceInterface {
+	if c.dryRun {
+		return &dryRunResources{ResourceInterface: c.resources(namespace)}
+	}
+	return c.resources(namespace)
+}
+
+func (c *resourceClient) resources(namespace string) dynamic.ResourceInterface {
 	// TODO(marun) Consider returning Interface instead of
 	// ResourceInterface to allow callers to decide if they want to
 	// invoke Namespace().  Either that, or replace the use of
@@ -68,3 +125,130 @@ func (c *resourceClient) Resources(namespace string) dynamic.ResourceInterface {
 func (c *resourceClient) Kind() string {
 	return c.kind
 }
+
+func (c *resourceClient) Create(obj *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	// The vendored CreateOptions do not support a field manager.
+	request := c.restClient.
+		Post().
+		AbsPath(c.resourcePath(obj.GetNamespace(), "")...).
+		Param("fieldManager", fieldManager)
+	if c.dryRun {
+		request = request.Param("dryRun", metav1.DryRunAll)
+	}
+	result := request.Body(data).Do()
+	return decodeResult(result)
+}
+
+func (c *resourceClient) Apply(obj *unstructured.Unstructured, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	request := c.restClient.
+		Patch(ApplyPatchType).
+		AbsPath(c.resourcePath(obj.GetNamespace(), obj.GetName())...).
+		Param("fieldManager", fieldManager).
+		Param("force", strconv.FormatBool(force))
+	if c.dryRun {
+		request = request.Param("dryRun", metav1.DryRunAll)
+	}
+	result := request.Body(data).Do()
+	return decodeResult(result)
+}
+
+func decodeResult(result rest.Result) (*unstructured.Unstructured, error) {
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	retBytes, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+	uncastObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, retBytes)
+	if err != nil {
+		return nil, err
+	}
+	return uncastObj.(*unstructured.Unstructured), nil
+}
+
+func (c *resourceClient) resourcePath(namespace, name string) []string {
+	path := []string{}
+	if len(c.apiResource.Group) == 0 {
+		path = append(path, "api")
+	} else {
+		path = append(path, "apis", c.apiResource.Group)
+	}
+	path = append(path, c.apiResource.Version)
+	if c.namespaced && len(namespace) > 0 {
+		path = append(path, "namespaces", namespace)
+	}
+	return append(path, c.apiResource.Resource, name)
+}
+
+// dryRunResources is a dynamic.ResourceInterface whose writes are not
+// persisted.
+type dryRunResources struct {
+	dynamic.ResourceInterface
+}
+
+func (r *dryRunResources) Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.Create(obj, options, subresources...)
+}
+
+func (r *dryRunResources) Update(obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.Update(obj, options, subresources...)
+}
+
+func (r *dryRunResources) UpdateStatus(obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.UpdateStatus(obj, options)
+}
+
+func (r *dryRunResources) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.Delete(name, options, subresources...)
+}
+
+func (r *dryRunResources) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.DeleteCollection(options, listOptions)
+}
+
+func (r *dryRunResources) Patch(name string, pt types.PatchType, data []byte, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	options.DryRun = []string{metav1.DryRunAll}
+	return r.ResourceInterface.Patch(name, pt, data, options, subresources...)
+}
+
+// WrapTransportWithContext returns a transport wrapper that
+// associates requests with the given context so that they are
+// cancelled when the context is done.  The wrapper applies the given
+// existing wrapper, if any, first.
+func WrapTransportWithContext(ctx context.Context, wrapTransport func(http.RoundTripper) http.RoundTripper) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		if wrapTransport != nil {
+			rt = wrapTransport(rt)
+		}
+		return &contextRoundTripper{ctx: ctx, delegate: rt}
+	}
+}
+
+type contextRoundTripper struct {
+	ctx      context.Context
+	delegate http.RoundTripper
+}
+
+func (rt *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.delegate.RoundTrip(req.WithContext(rt.ctx))
+}
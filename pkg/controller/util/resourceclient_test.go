@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+func TestResourceClientApply(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "bar",
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPatch {
+			t.Errorf("Expected method %s, got %s", http.MethodPatch, req.Method)
+		}
+		if req.URL.Path != "/apis/apps/v1/namespaces/bar/deployments/foo" {
+			t.Errorf("Unexpected path %q", req.URL.Path)
+		}
+		if contentType := req.Header.Get("Content-Type"); contentType != string(ApplyPatchType) {
+			t.Errorf("Expected content type %q, got %q", ApplyPatchType, contentType)
+		}
+		query := req.URL.Query()
+		if query.Get("fieldManager") != "kubefed" || query.Get("force") != "true" {
+			t.Errorf("Unexpected query %q", req.URL.RawQuery)
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := NewResourceClient(&rest.Config{Host: server.URL}, &metav1.APIResource{
+		Group:      "apps",
+		Version:    "v1",
+		Name:       "deployments",
+		Kind:       "Deployment",
+		Namespaced: true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	appliedObj, err := client.Apply(obj, "kubefed", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if appliedObj.GetName() != "foo" {
+		t.Fatalf("Expected the applied object to be returned")
+	}
+}
+
+func TestResourceClientCreate(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "bar",
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			t.Errorf("Expected method %s, got %s", http.MethodPost, req.Method)
+		}
+		if req.URL.Path != "/api/v1/namespaces/bar/configmaps" {
+			t.Errorf("Unexpected path %q", req.URL.Path)
+		}
+		if req.URL.Query().Get("fieldManager") != "kubefed" {
+			t.Errorf("Unexpected query %q", req.URL.RawQuery)
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := NewResourceClient(&rest.Config{Host: server.URL}, &metav1.APIResource{
+		Version:    "v1",
+		Name:       "configmaps",
+		Kind:       "ConfigMap",
+		Namespaced: true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	createdObj, err := client.Create(obj, "kubefed")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if createdObj.GetName() != "foo" {
+		t.Fatalf("Expected the created object to be returned")
+	}
+}
+
+func TestDryRunResourceClient(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "bar",
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("dryRun") != metav1.DryRunAll {
+			t.Errorf("Expected a dry run for %s %q, got query %q", req.Method, req.URL.Path, req.URL.RawQuery)
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := NewDryRunResourceClient(&rest.Config{Host: server.URL}, &metav1.APIResource{
+		Version:    "v1",
+		Name:       "configmaps",
+		Kind:       "ConfigMap",
+		Namespaced: true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Resources("bar").Update(obj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Create(obj, "kubefed"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Apply(obj, "kubefed", false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
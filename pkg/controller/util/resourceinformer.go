@@ -40,24 +40,40 @@ func NewManagedResourceInformer(client ResourceClient, namespace string, trigger
 	return newResourceInformer(client, namespace, triggerFunc, labelSelector)
 }
 
+// NewIndexedResourceInformer returns an informer whose store maintains
+// the given indexes.
+func NewIndexedResourceInformer(client ResourceClient, namespace string, triggerFunc func(pkgruntime.Object), indexers cache.Indexers) (cache.Indexer, cache.Controller) {
+	return cache.NewIndexerInformer(
+		newResourceListWatch(client, namespace, ""),
+		nil, // Skip checks for expected type since the type will depend on the client
+		NoResyncPeriod,
+		NewTriggerOnAllChanges(triggerFunc),
+		indexers,
+	)
+}
+
 func newResourceInformer(client ResourceClient, namespace string, triggerFunc func(pkgruntime.Object), labelSelector string) (cache.Store, cache.Controller) {
 	return cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(options metav1.ListOptions) (pkgruntime.Object, error) {
-				options.LabelSelector = labelSelector
-				return client.Resources(namespace).List(options)
-			},
-			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				options.LabelSelector = labelSelector
-				return client.Resources(namespace).Watch(options)
-			},
-		},
+		newResourceListWatch(client, namespace, labelSelector),
 		nil, // Skip checks for expected type since the type will depend on the client
 		NoResyncPeriod,
 		NewTriggerOnAllChanges(triggerFunc),
 	)
 }
 
+func newResourceListWatch(client ResourceClient, namespace, labelSelector string) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (pkgruntime.Object, error) {
+			options.LabelSelector = labelSelector
+			return client.Resources(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = labelSelector
+			return client.Resources(namespace).Watch(options)
+		},
+	}
+}
+
 func ObjFromCache(store cache.Store, kind, key string) (*unstructured.Unstructured, error) {
 	obj, err := rawObjFromCache(store, kind, key)
 	if err != nil {
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// GenericRolloutStrategy determines how updates to the resources
+// managed by a federated resource are rolled out across member
+// clusters.
+type GenericRolloutStrategy struct {
+	// The maximum number or percentage of clusters that may be
+	// updating at a time.  Defaults to 1.
+	MaxUpdating *intstr.IntOrString `json:"maxUpdating,omitempty"`
+
+	// Clusters to update before all other clusters.  Other clusters
+	// will not be updated until all canary clusters are updated (and
+	// healthy if waitForHealthy is true).
+	CanaryClusters []string `json:"canaryClusters,omitempty"`
+
+	// The order in which to update clusters.  Clusters not listed
+	// are updated after listed clusters in name order.
+	ClusterOrder []string `json:"clusterOrder,omitempty"`
+
+	// Whether an updated cluster is considered to be updating until
+	// the resource in the cluster reports itself healthy.
+	WaitForHealthy bool `json:"waitForHealthy,omitempty"`
+}
+
+type GenericRolloutStrategySpec struct {
+	RolloutStrategy *GenericRolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+type GenericRollout struct {
+	Spec GenericRolloutStrategySpec `json:"spec,omitempty"`
+}
+
+// GetRolloutStrategy returns the rollout strategy of a federated
+// resource, or nil if the resource does not define one.
+func GetRolloutStrategy(obj *unstructured.Unstructured) (*GenericRolloutStrategy, error) {
+	rollout := &GenericRollout{}
+	err := UnstructuredToInterface(obj, rollout)
+	if err != nil {
+		return nil, err
+	}
+	return rollout.Spec.RolloutStrategy, nil
+}
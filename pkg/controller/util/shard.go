@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+)
+
+// ShardOwner determines whether a replica of the controller manager
+// owns a shard key.
+type ShardOwner interface {
+	// Owns indicates whether the replica owns the given shard key.
+	Owns(key string) bool
+	// OnRebalance calls the given function whenever the ownership of
+	// shard keys may have changed, until the stop channel is closed.
+	OnRebalance(stopChan <-chan struct{}, rebalanceFunc func())
+}
+
+// ShardingConfig determines the federated resources and member
+// clusters a replica of the controller manager is responsible for.
+// The zero value indicates that work is not sharded.
+type ShardingConfig struct {
+	Mode  fedv1a1.ShardingMode
+	Owner ShardOwner
+}
+
+// ResourceShardKey returns the shard key of the federated resources
+// of the named type in the given namespace.
+func ResourceShardKey(typeName, namespace string) string {
+	return typeName + "/" + namespace
+}
+
+// ClusterShardKey returns the shard key of the named member cluster.
+func ClusterShardKey(clusterName string) string {
+	return "cluster/" + clusterName
+}
+
+// Sharded indicates whether work is divided between replicas.
+func (c ShardingConfig) Sharded() bool {
+	return c.Mode != "" && c.Owner != nil
+}
+
+// OwnsResource indicates whether the replica propagates the federated
+// resources of the named type in the given namespace.
+func (c ShardingConfig) OwnsResource(typeName, namespace string) bool {
+	if !c.Sharded() || c.Mode != fedv1a1.ShardByTypeNamespace {
+		return true
+	}
+	return c.Owner.Owns(ResourceShardKey(typeName, namespace))
+}
+
+// OwnsCluster indicates whether the replica propagates federated
+// resources to the named member cluster.
+func (c ShardingConfig) OwnsCluster(clusterName string) bool {
+	if !c.Sharded() || c.Mode != fedv1a1.ShardByCluster {
+		return true
+	}
+	return c.Owner.Owns(ClusterShardKey(clusterName))
+}
+
+// OwnsResourceStatus indicates whether the replica collects the status
+// of the federated resources of the named type in the given namespace.
+// Status is collected from all member clusters, so its collection is
+// divided by type and namespace regardless of the sharding mode.
+func (c ShardingConfig) OwnsResourceStatus(typeName, namespace string) bool {
+	if !c.Sharded() {
+		return true
+	}
+	return c.Owner.Owns(ResourceShardKey(typeName, namespace))
+}
+
+// OnRebalance calls the given function whenever the ownership of
+// shard keys may have changed, until the stop channel is closed.
+func (c ShardingConfig) OnRebalance(stopChan <-chan struct{}, rebalanceFunc func()) {
+	if c.Sharded() {
+		c.Owner.OnRebalance(stopChan, rebalanceFunc)
+	}
+}
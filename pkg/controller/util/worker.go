@@ -23,6 +23,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/kubefed/pkg/metrics"
 )
 
 type ReconcileFunc func(qualifiedName QualifiedName) ReconciliationStatus
@@ -47,6 +49,9 @@ type WorkerTiming struct {
 }
 
 type asyncWorker struct {
+	// Name under which metrics for the worker are recorded
+	name string
+
 	reconcile ReconcileFunc
 
 	timing WorkerTiming
@@ -64,7 +69,7 @@ type asyncWorker struct {
 	backoff *flowcontrol.Backoff
 }
 
-func NewReconcileWorker(reconcile ReconcileFunc, timing WorkerTiming) ReconcileWorker {
+func NewReconcileWorker(name string, reconcile ReconcileFunc, timing WorkerTiming) ReconcileWorker {
 	if timing.Interval == 0 {
 		timing.Interval = time.Second * 1
 	}
@@ -78,10 +83,11 @@ func NewReconcileWorker(reconcile ReconcileFunc, timing WorkerTiming) ReconcileW
 		timing.MaxBackoff = time.Minute
 	}
 	return &asyncWorker{
+		name:      name,
 		reconcile: reconcile,
 		timing:    timing,
-		deliverer: NewDelayingDeliverer(),
-		queue:     workqueue.New(),
+		deliverer: NewDelayingDeliverer(name),
+		queue:     workqueue.NewNamed(name),
 		backoff:   flowcontrol.NewBackOff(timing.InitialBackoff, timing.MaxBackoff),
 	}
 }
@@ -136,6 +142,7 @@ func (w *asyncWorker) SetDelay(retryDelay, clusterSyncDelay time.Duration) {
 func (w *asyncWorker) deliver(qualifiedName QualifiedName, delay time.Duration, failed bool) {
 	key := qualifiedName.String()
 	if failed {
+		metrics.WorkerRetry(w.name)
 		w.backoff.Next(key, time.Now())
 		delay = delay + w.backoff.Get(key)
 	} else {
@@ -23,6 +23,67 @@ import (
 )
 
 func federatedTypeValidationSchema(templateSchema map[string]v1beta1.JSONSchemaProps) *v1beta1.CustomResourceValidation {
+	clusterSelectorSchema := v1beta1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]v1beta1.JSONSchemaProps{
+			"matchExpressions": {
+				Type: "array",
+				Items: &v1beta1.JSONSchemaPropsOrArray{
+					Schema: &v1beta1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]v1beta1.JSONSchemaProps{
+							"key": {
+								Type: "string",
+							},
+							"operator": {
+								Type: "string",
+							},
+							"values": {
+								Type: "array",
+								Items: &v1beta1.JSONSchemaPropsOrArray{
+									Schema: &v1beta1.JSONSchemaProps{
+										Type: "string",
+									},
+								},
+							},
+						},
+						Required: []string{
+							"key",
+							"operator",
+						},
+					},
+				},
+			},
+			"matchLabels": {
+				Type: "object",
+				AdditionalProperties: &v1beta1.JSONSchemaPropsOrBool{
+					Schema: &v1beta1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+		},
+	}
+
+	clusterReferencesSchema := v1beta1.JSONSchemaProps{
+		Type: "array",
+		Items: &v1beta1.JSONSchemaPropsOrArray{
+			Schema: &v1beta1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]v1beta1.JSONSchemaProps{
+					"name": {
+						Type: "string",
+					},
+				},
+				Required: []string{
+					"name",
+				},
+			},
+		},
+	}
+	minimumClusters := float64(0)
+	minimumGracePeriodSeconds := float64(0)
+
 	schema := ValidationSchema(v1beta1.JSONSchemaProps{
 		Type: "object",
 		Properties: map[string]v1beta1.JSONSchemaProps{
@@ -33,65 +94,146 @@ func federatedTypeValidationSchema(templateSchema map[string]v1beta1.JSONSchemaP
 					// scheduling mechanism to explicitly indicate
 					// placement. If one or more clusters is provided,
 					// the clusterSelector field will be ignored.
-					"clusters": {
+					"clusters":        clusterReferencesSchema,
+					"clusterSelector": clusterSelectorSchema,
+					// Failover placement selects clusters from an
+					// ordered list of preferred clusters.
+					"failover": {
+						Type: "object",
+						Properties: map[string]v1beta1.JSONSchemaProps{
+							"preferredClusters": clusterReferencesSchema,
+							"clusterCount": {
+								Type:    "integer",
+								Format:  "int32",
+								Minimum: &minimumClusters,
+							},
+							"replaceUnavailableAfter": {
+								Type: "string",
+							},
+						},
+						Required: []string{
+							"preferredClusters",
+						},
+					},
+					// Spread constraints limit the clusters
+					// selected by clusters or clusterSelector.
+					"spreadConstraints": {
+						Type: "object",
+						Properties: map[string]v1beta1.JSONSchemaProps{
+							"spreadBy": {
+								Type: "string",
+								Enum: []v1beta1.JSON{
+									{Raw: []byte(`"Region"`)},
+									{Raw: []byte(`"Zone"`)},
+									{Raw: []byte(`"Label"`)},
+								},
+							},
+							"labelKey": {
+								Type: "string",
+							},
+							"maxClusters": {
+								Type:    "integer",
+								Format:  "int32",
+								Minimum: &minimumClusters,
+							},
+							"minClusters": {
+								Type:    "integer",
+								Format:  "int32",
+								Minimum: &minimumClusters,
+							},
+						},
+					},
+				},
+			},
+			// The rollout strategy determines how updates are
+			// rolled out across member clusters.
+			"rolloutStrategy": {
+				Type: "object",
+				Properties: map[string]v1beta1.JSONSchemaProps{
+					"maxUpdating": {
+						AnyOf: []v1beta1.JSONSchemaProps{
+							{
+								Type: "integer",
+							},
+							{
+								Type: "string",
+							},
+						},
+					},
+					"canaryClusters": {
 						Type: "array",
 						Items: &v1beta1.JSONSchemaPropsOrArray{
 							Schema: &v1beta1.JSONSchemaProps{
-								Type: "object",
-								Properties: map[string]v1beta1.JSONSchemaProps{
-									"name": {
-										Type: "string",
-									},
-								},
-								Required: []string{
-									"name",
-								},
+								Type: "string",
 							},
 						},
 					},
-					"clusterSelector": {
+					"clusterOrder": {
+						Type: "array",
+						Items: &v1beta1.JSONSchemaPropsOrArray{
+							Schema: &v1beta1.JSONSchemaProps{
+								Type: "string",
+							},
+						},
+					},
+					"waitForHealthy": {
+						Type: "boolean",
+					},
+				},
+			},
+			// The drift policy determines whether changes made
+			// directly to managed resources in member clusters are
+			// overwritten or only reported.
+			"driftPolicy": {
+				Type: "string",
+				Enum: []v1beta1.JSON{
+					{Raw: []byte(`"Overwrite"`)},
+					{Raw: []byte(`"Report"`)},
+				},
+			},
+			// Dependencies must be propagated to a member cluster
+			// before the managed resource is created there.
+			"dependsOn": {
+				Type: "array",
+				Items: &v1beta1.JSONSchemaPropsOrArray{
+					Schema: &v1beta1.JSONSchemaProps{
 						Type: "object",
 						Properties: map[string]v1beta1.JSONSchemaProps{
-							"matchExpressions": {
-								Type: "array",
-								Items: &v1beta1.JSONSchemaPropsOrArray{
-									Schema: &v1beta1.JSONSchemaProps{
-										Type: "object",
-										Properties: map[string]v1beta1.JSONSchemaProps{
-											"key": {
-												Type: "string",
-											},
-											"operator": {
-												Type: "string",
-											},
-											"values": {
-												Type: "array",
-												Items: &v1beta1.JSONSchemaPropsOrArray{
-													Schema: &v1beta1.JSONSchemaProps{
-														Type: "string",
-													},
-												},
-											},
-										},
-										Required: []string{
-											"key",
-											"operator",
-										},
-									},
-								},
+							"group": {
+								Type: "string",
 							},
-							"matchLabels": {
-								Type: "object",
-								AdditionalProperties: &v1beta1.JSONSchemaPropsOrBool{
-									Schema: &v1beta1.JSONSchemaProps{
-										Type: "string",
-									},
-								},
+							"kind": {
+								Type: "string",
 							},
+							"namespace": {
+								Type: "string",
+							},
+							"name": {
+								Type: "string",
+							},
+						},
+						Required: []string{
+							"kind",
+							"name",
 						},
 					},
 				},
 			},
+			// The deletion policy determines whether a managed
+			// resource is deleted, orphaned or deleted after a
+			// grace period when its cluster is no longer selected.
+			"deletionPolicy": {
+				Type: "string",
+				Enum: []v1beta1.JSON{
+					{Raw: []byte(`"Delete"`)},
+					{Raw: []byte(`"Orphan"`)},
+					{Raw: []byte(`"DelayedDelete"`)},
+				},
+			},
+			"deletionGracePeriodSeconds": {
+				Type:    "integer",
+				Minimum: &minimumGracePeriodSeconds,
+			},
 			"overrides": {
 				Type: "array",
 				Items: &v1beta1.JSONSchemaPropsOrArray{
@@ -101,12 +243,24 @@ func federatedTypeValidationSchema(templateSchema map[string]v1beta1.JSONSchemaP
 							"clusterName": {
 								Type: "string",
 							},
+							// Overrides may target the clusters
+							// matching a label selector instead of a
+							// single named cluster.
+							"clusterSelector": clusterSelectorSchema,
 							"clusterOverrides": {
 								Type: "array",
 								Items: &v1beta1.JSONSchemaPropsOrArray{
 									Schema: &v1beta1.JSONSchemaProps{
 										Type: "object",
 										Properties: map[string]v1beta1.JSONSchemaProps{
+											// One of add, remove or
+											// replace.  Defaults to
+											// replace if not provided.
+											"op": {
+												Type: "string",
+											},
+											// A JSON pointer (RFC 6901)
+											// to the field to override.
 											"path": {
 												Type: "string",
 											},
@@ -166,6 +320,33 @@ func federatedTypeValidationSchema(templateSchema map[string]v1beta1.JSONSchemaP
 }
 
 func ValidationSchema(specProps v1beta1.JSONSchemaProps) *v1beta1.CustomResourceValidation {
+	clusterNamesSchema := v1beta1.JSONSchemaProps{
+		Type: "array",
+		Items: &v1beta1.JSONSchemaPropsOrArray{
+			Schema: &v1beta1.JSONSchemaProps{
+				Type: "string",
+			},
+		},
+	}
+	policyReferenceSchema := v1beta1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]v1beta1.JSONSchemaProps{
+			"kind": {
+				Type: "string",
+			},
+			"namespace": {
+				Type: "string",
+			},
+			"name": {
+				Type: "string",
+			},
+		},
+		Required: []string{
+			"kind",
+			"name",
+		},
+	}
+
 	return &v1beta1.CustomResourceValidation{
 		OpenAPIV3Schema: &v1beta1.JSONSchemaProps{
 			Properties: map[string]v1beta1.JSONSchemaProps{
@@ -183,6 +364,17 @@ func ValidationSchema(specProps v1beta1.JSONSchemaProps) *v1beta1.CustomResource
 				"status": {
 					Type: "object",
 					Properties: map[string]v1beta1.JSONSchemaProps{
+						// The generation of the federated resource
+						// reflected by the status.
+						"observedGeneration": {
+							Type:   "integer",
+							Format: "int64",
+						},
+						// The summary of the status of the resource
+						// in selected clusters.
+						"aggregatedStatus": {
+							Type: "object",
+						},
 						"conditions": {
 							Type: "array",
 							Items: &v1beta1.JSONSchemaPropsOrArray{
@@ -226,6 +418,30 @@ func ValidationSchema(specProps v1beta1.JSONSchemaProps) *v1beta1.CustomResource
 										"status": {
 											Type: "string",
 										},
+										"health": {
+											Type: "string",
+										},
+										"healthMessage": {
+											Type: "string",
+										},
+										"message": {
+											Type: "string",
+										},
+										"lastTransitionTime": {
+											Format: "date-time",
+											Type:   "string",
+										},
+										"remoteResourceVersion": {
+											Type: "string",
+										},
+										"remoteGeneration": {
+											Type:   "integer",
+											Format: "int64",
+										},
+										"retryCount": {
+											Type:   "integer",
+											Format: "int32",
+										},
 									},
 									Required: []string{
 										"name",
@@ -233,6 +449,50 @@ func ValidationSchema(specProps v1beta1.JSONSchemaProps) *v1beta1.CustomResource
 								},
 							},
 						},
+						// The policies that were resolved to
+						// determine placement and overrides.
+						"propagationPolicy": policyReferenceSchema,
+						"overridePolicy":    policyReferenceSchema,
+						// The progress of rolling out an update.
+						"rollout": {
+							Type: "object",
+							Properties: map[string]v1beta1.JSONSchemaProps{
+								"paused": {
+									Type: "boolean",
+								},
+								"updatedClusters":  clusterNamesSchema,
+								"updatingClusters": clusterNamesSchema,
+								"pendingClusters":  clusterNamesSchema,
+							},
+						},
+						// The clusters selected by spread
+						// constraints.
+						"selectedClusters": clusterNamesSchema,
+						// The clusters no longer selected whose
+						// resources will be deleted once the
+						// grace period of the DelayedDelete
+						// deletion policy has elapsed.
+						"pendingDeletions": {
+							Type: "array",
+							Items: &v1beta1.JSONSchemaPropsOrArray{
+								Schema: &v1beta1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]v1beta1.JSONSchemaProps{
+										"cluster": {
+											Type: "string",
+										},
+										"deleteAfter": {
+											Format: "date-time",
+											Type:   "string",
+										},
+									},
+									Required: []string{
+										"cluster",
+										"deleteAfter",
+									},
+								},
+							},
+						},
 					},
 				},
 			},
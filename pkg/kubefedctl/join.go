@@ -20,6 +20,7 @@ import (
 	"context"
 	goerrors "errors"
 	"io"
+	"io/ioutil"
 	"reflect"
 	"strings"
 	"time"
@@ -40,12 +41,22 @@ import (
 
 	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
 	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	ctlutil "sigs.k8s.io/kubefed/pkg/controller/util"
 	"sigs.k8s.io/kubefed/pkg/kubefedctl/options"
 	"sigs.k8s.io/kubefed/pkg/kubefedctl/util"
 )
 
 const (
 	serviceAccountSecretTimeout = 30 * time.Second
+
+	// ServiceAccountCredentialSource indicates that the control plane
+	// accesses a joining cluster with the token of a service account
+	// created by join.
+	ServiceAccountCredentialSource = "serviceaccount"
+	// KubeconfigCredentialSource indicates that the control plane
+	// accesses a joining cluster with the credentials of the
+	// kubeconfig used by join.
+	KubeconfigCredentialSource = "kubeconfig"
 )
 
 var (
@@ -62,7 +73,13 @@ var (
 		# a valid RFC 1123 subdomain name. Cluster context
 		# must be specified if the cluster name is different
 		# than the cluster's context in the local kubeconfig.
-		kubefedctl join foo --host-cluster-context=bar`
+		kubefedctl join foo --host-cluster-context=bar
+
+		# Join a cluster with the client certificate, token or
+		# exec plugin of its context in the local kubeconfig
+		# instead of the token of a service account.  The command
+		# of an exec plugin must be allowed by the KubefedConfig.
+		kubefedctl join foo --host-cluster-context=bar --credential-source=kubeconfig`
 
 	// Policy rules allowing full access to resources in the cluster
 	// or namespace.
@@ -89,9 +106,11 @@ type joinFederation struct {
 }
 
 type joinFederationOptions struct {
-	secretName      string
-	Scope           apiextv1b1.ResourceScope
-	errorOnExisting bool
+	secretName       string
+	credentialSource string
+	proxyURL         string
+	Scope            apiextv1b1.ResourceScope
+	errorOnExisting  bool
 }
 
 // Bind adds the join specific arguments to the flagset passed in as an
@@ -101,6 +120,10 @@ func (o *joinFederationOptions) Bind(flags *pflag.FlagSet) {
 		"Name of the secret where the cluster's credentials will be stored in the host cluster. This name should be a valid RFC 1035 label. If unspecified, defaults to a generated name containing the cluster name.")
 	flags.BoolVar(&o.errorOnExisting, "error-on-existing", false,
 		"Whether the join operation will throw an error if it encounters existing artifacts with the same name as those it's trying to create. If false, the join operation will update existing artifacts to match its own specification.")
+	flags.StringVar(&o.credentialSource, "credential-source", ServiceAccountCredentialSource,
+		"The credentials the control plane uses to access the cluster. With 'serviceaccount', a service account is created in the cluster and its token is used. With 'kubeconfig', the token, client certificate or exec plugin of the cluster context is used.")
+	flags.StringVar(&o.proxyURL, "proxy-url", "",
+		"The URL of the proxy the control plane uses to access the cluster.")
 }
 
 // NewCmdJoin defines the `join` command that joins a cluster to a
@@ -154,9 +177,13 @@ func (j *joinFederation) Complete(args []string) error {
 		klog.Fatal("host-cluster-name must be set if the name of the host cluster context contains one of \":\" or \"/\"")
 	}
 
-	klog.V(2).Infof("Args and flags: name %s, host: %s, host-system-namespace: %s, kubeconfig: %s, cluster-context: %s, secret-name: %s, dry-run: %v",
+	if j.credentialSource != ServiceAccountCredentialSource && j.credentialSource != KubeconfigCredentialSource {
+		return errors.Errorf("credential-source must be one of %q or %q", ServiceAccountCredentialSource, KubeconfigCredentialSource)
+	}
+
+	klog.V(2).Infof("Args and flags: name %s, host: %s, host-system-namespace: %s, kubeconfig: %s, cluster-context: %s, secret-name: %s, credential-source: %s, proxy-url: %s, dry-run: %v",
 		j.ClusterName, j.HostClusterContext, j.KubefedNamespace, j.Kubeconfig, j.ClusterContext,
-		j.secretName, j.DryRun)
+		j.secretName, j.credentialSource, j.proxyURL, j.DryRun)
 
 	return nil
 }
@@ -188,13 +215,15 @@ func (j *joinFederation) Run(cmdOut io.Writer, config util.FedConfig) error {
 	}
 
 	return JoinCluster(hostConfig, clusterConfig, j.KubefedNamespace,
-		hostClusterName, j.ClusterName, j.secretName, j.Scope, j.DryRun, j.errorOnExisting)
+		hostClusterName, j.ClusterName, j.secretName, j.credentialSource, j.proxyURL,
+		j.Scope, j.DryRun, j.errorOnExisting)
 }
 
 // JoinCluster performs all the necessary steps to join a cluster to the
 // federation provided the required set of parameters are passed in.
 func JoinCluster(hostConfig, clusterConfig *rest.Config, kubefedNamespace,
-	hostClusterName, joiningClusterName, secretName string, Scope apiextv1b1.ResourceScope, dryRun, errorOnExisting bool) error {
+	hostClusterName, joiningClusterName, secretName, credentialSource, proxyURL string,
+	Scope apiextv1b1.ResourceScope, dryRun, errorOnExisting bool) error {
 	hostClientset, err := util.HostClientset(hostConfig)
 	if err != nil {
 		klog.V(2).Infof("Failed to get host cluster clientset: %v", err)
@@ -229,12 +258,19 @@ func JoinCluster(hostConfig, clusterConfig *rest.Config, kubefedNamespace,
 	}
 	klog.V(2).Infof("Created %s namespace in joining cluster", kubefedNamespace)
 
-	// Create a service account and use its credentials.
 	klog.V(2).Info("Creating cluster credentials secret")
 
-	secret, err := createRBACSecret(hostClientset, clusterClientset,
-		kubefedNamespace, joiningClusterName, hostClusterName,
-		secretName, Scope, dryRun, errorOnExisting)
+	var secret *corev1.Secret
+	if credentialSource == KubeconfigCredentialSource {
+		// Use the credentials of the kubeconfig.
+		secret, err = createKubeconfigSecret(hostClientset, clusterConfig,
+			kubefedNamespace, joiningClusterName, secretName, dryRun)
+	} else {
+		// Create a service account and use its credentials.
+		secret, err = createRBACSecret(hostClientset, clusterClientset,
+			kubefedNamespace, joiningClusterName, hostClusterName,
+			secretName, Scope, dryRun, errorOnExisting)
+	}
 	if err != nil {
 		klog.V(2).Infof("Could not create cluster credentials secret: %v", err)
 		return err
@@ -244,8 +280,10 @@ func JoinCluster(hostConfig, clusterConfig *rest.Config, kubefedNamespace,
 
 	klog.V(2).Info("Creating federated cluster resource")
 
-	_, err = createKubefedCluster(client, joiningClusterName, clusterConfig.Host,
-		secret.Name, kubefedNamespace, dryRun, errorOnExisting)
+	spec := kubefedClusterSpec(clusterConfig, secret.Name, proxyURL,
+		credentialSource == KubeconfigCredentialSource)
+	_, err = createKubefedCluster(client, joiningClusterName, spec,
+		kubefedNamespace, dryRun, errorOnExisting)
 	if err != nil {
 		klog.V(2).Infof("Failed to create federated cluster resource: %v", err)
 		return err
@@ -277,21 +315,48 @@ func performPreflightChecks(clusterClientset kubeclient.Interface, name, hostClu
 	}
 }
 
+// kubefedClusterSpec returns the spec of the federated cluster resource
+// for a cluster accessed with the given config and secret.  The exec
+// plugin of the config is only included if the credentials of the
+// config are used.
+func kubefedClusterSpec(clusterConfig *rest.Config, secretName, proxyURL string,
+	useConfigCredentials bool) fedv1a1.KubefedClusterSpec {
+	spec := fedv1a1.KubefedClusterSpec{
+		APIEndpoint: clusterConfig.Host,
+		SecretRef: fedv1a1.LocalSecretReference{
+			Name: secretName,
+		},
+		ProxyURL:              proxyURL,
+		TLSServerName:         clusterConfig.ServerName,
+		InsecureSkipTLSVerify: clusterConfig.Insecure,
+	}
+	exec := clusterConfig.ExecProvider
+	if useConfigCredentials && exec != nil {
+		spec.Exec = &fedv1a1.ExecCredentialConfig{
+			Command:    exec.Command,
+			Args:       exec.Args,
+			APIVersion: exec.APIVersion,
+		}
+		for _, envVar := range exec.Env {
+			spec.Exec.Env = append(spec.Exec.Env, fedv1a1.ExecEnvVar{
+				Name:  envVar.Name,
+				Value: envVar.Value,
+			})
+		}
+	}
+	return spec
+}
+
 // createKubefedCluster creates a federated cluster resource that associates
 // the cluster and secret.
-func createKubefedCluster(client genericclient.Client, joiningClusterName, apiEndpoint,
-	secretName, kubefedNamespace string, dryRun, errorOnExisting bool) (*fedv1a1.KubefedCluster, error) {
+func createKubefedCluster(client genericclient.Client, joiningClusterName string,
+	spec fedv1a1.KubefedClusterSpec, kubefedNamespace string, dryRun, errorOnExisting bool) (*fedv1a1.KubefedCluster, error) {
 	fedCluster := &fedv1a1.KubefedCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: kubefedNamespace,
 			Name:      joiningClusterName,
 		},
-		Spec: fedv1a1.KubefedClusterSpec{
-			APIEndpoint: apiEndpoint,
-			SecretRef: fedv1a1.LocalSecretReference{
-				Name: secretName,
-			},
-		},
+		Spec: spec,
 	}
 
 	if dryRun {
@@ -668,7 +733,7 @@ func createRoleAndBinding(clientset kubeclient.Interface, saName, namespace, clu
 
 // createHealthCheckClusterRoleAndBinding creates an RBAC cluster role and
 // binding that allows the service account identified by saName to
-// access the health check path of the cluster.
+// access the health check paths of the cluster.
 func createHealthCheckClusterRoleAndBinding(clientset kubeclient.Interface, saName, namespace, clusterName string, dryRun, errorOnExisting bool) error {
 	if dryRun {
 		return nil
@@ -683,7 +748,7 @@ func createHealthCheckClusterRoleAndBinding(clientset kubeclient.Interface, saNa
 		Rules: []rbacv1.PolicyRule{
 			{
 				Verbs:           []string{"Get"},
-				NonResourceURLs: []string{"/healthz"},
+				NonResourceURLs: []string{"/healthz", "/readyz"},
 			},
 			// The cluster client expects to be able to list nodes to retrieve zone and region details.
 			// TODO(marun) Consider making zone/region retrieval optional
@@ -818,11 +883,69 @@ func populateSecretInHostCluster(clusterClientset, hostClientset kubeclient.Inte
 	}
 
 	// Create a parallel secret in the host cluster.
+	return createSecretInHostCluster(hostClientset, namespace, joiningClusterName, secretName, secret.Data)
+}
+
+// createKubeconfigSecret creates a secret in the host cluster holding
+// the token or client certificate, and the ca bundle of the given
+// config of the joining cluster.  A config that authenticates with an
+// exec plugin only contributes its ca bundle.
+func createKubeconfigSecret(hostClientset kubeclient.Interface, clusterConfig *rest.Config,
+	namespace, joiningClusterName, secretName string, dryRun bool) (*corev1.Secret, error) {
+	if clusterConfig.AuthProvider != nil || clusterConfig.Username != "" {
+		return nil, errors.Errorf("The kubeconfig of cluster %s uses an auth provider or basic auth, which are not supported as credentials for the control plane", joiningClusterName)
+	}
+
+	// Read the credentials that the config references by file.
+	config := rest.CopyConfig(clusterConfig)
+	err := rest.LoadTLSFiles(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to load the tls files of the kubeconfig of cluster %s", joiningClusterName)
+	}
+	token := config.BearerToken
+	if token == "" && config.BearerTokenFile != "" {
+		tokenBytes, err := ioutil.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the token file of the kubeconfig of cluster %s", joiningClusterName)
+		}
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	data := map[string][]byte{}
+	if len(config.CAData) > 0 && !config.Insecure {
+		data[ctlutil.CASecretKey] = config.CAData
+	}
+	switch {
+	case config.ExecProvider != nil:
+		// The exec plugin is configured in the federated cluster resource.
+	case len(config.CertData) > 0 && len(config.KeyData) > 0:
+		data[ctlutil.ClientCertificateSecretKey] = config.CertData
+		data[ctlutil.ClientKeySecretKey] = config.KeyData
+	case token != "":
+		data[ctlutil.TokenSecretKey] = []byte(token)
+	default:
+		return nil, errors.Errorf("The kubeconfig of cluster %s does not have a token, client certificate or exec plugin", joiningClusterName)
+	}
+
+	if dryRun {
+		dryRunSecret := &corev1.Secret{}
+		dryRunSecret.Name = secretName
+		return dryRunSecret, nil
+	}
+
+	return createSecretInHostCluster(hostClientset, namespace, joiningClusterName, secretName, data)
+}
+
+// createSecretInHostCluster creates a secret with the given data in the
+// host cluster.  The name of the secret is generated from the name of
+// the joining cluster if secretName is empty.
+func createSecretInHostCluster(hostClientset kubeclient.Interface, namespace, joiningClusterName,
+	secretName string, data map[string][]byte) (*corev1.Secret, error) {
 	v1Secret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
 		},
-		Data: secret.Data,
+		Data: data,
 	}
 
 	if secretName == "" {
@@ -60,6 +60,7 @@ func NewKubeFedCtlCommand(out io.Writer) *cobra.Command {
 	rootCmd.AddCommand(federate.NewCmdFederateResource(out, fedConfig))
 	rootCmd.AddCommand(NewCmdJoin(out, fedConfig))
 	rootCmd.AddCommand(NewCmdUnjoin(out, fedConfig))
+	rootCmd.AddCommand(NewCmdPreview(out, fedConfig))
 	rootCmd.AddCommand(NewCmdVersion(out))
 
 	return rootCmd
@@ -0,0 +1,514 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubefedctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	apiextv1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/kubefed/pkg/apis/core/common"
+	"sigs.k8s.io/kubefed/pkg/apis/core/typeconfig"
+	fedv1a1 "sigs.k8s.io/kubefed/pkg/apis/core/v1alpha1"
+	genericclient "sigs.k8s.io/kubefed/pkg/client/generic"
+	"sigs.k8s.io/kubefed/pkg/controller/sync"
+	"sigs.k8s.io/kubefed/pkg/controller/sync/dispatch"
+	ctlutil "sigs.k8s.io/kubefed/pkg/controller/util"
+	"sigs.k8s.io/kubefed/pkg/kubefedctl/federate"
+	"sigs.k8s.io/kubefed/pkg/kubefedctl/options"
+	"sigs.k8s.io/kubefed/pkg/kubefedctl/util"
+)
+
+var (
+	preview_long = `
+		Previews the changes that propagation of a federated resource
+		would make to member clusters, without writing to the host
+		cluster or to member clusters.  The placement, overrides and
+		retained fields of the federated resource are evaluated
+		against the current state of the member clusters and the
+		resulting operation is printed for each cluster, along with
+		a diff of the resource that member clusters report for a
+		server-side dry run of the operation.
+
+		Current context is assumed to be a Kubernetes cluster hosting
+		the kubefed control plane. Please use the
+		--host-cluster-context flag otherwise.`
+
+	preview_example = `
+		# Preview the propagation of the federated resources in the
+		# file my-deployment.yaml
+		kubefedctl preview -f my-deployment.yaml --host-cluster-context=cluster1`
+)
+
+type previewResource struct {
+	options.GlobalSubcommandOptions
+	previewResourceOptions
+}
+
+type previewResourceOptions struct {
+	filename string
+}
+
+// Bind adds the preview specific arguments to the flagset passed in as an
+// argument.
+func (o *previewResourceOptions) Bind(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.filename, "filename", "f", "", "File containing the federated resources to preview, or '-' to read from stdin.")
+}
+
+// NewCmdPreview defines the `preview` command that previews the
+// changes propagation of federated resources would make to member
+// clusters.
+func NewCmdPreview(cmdOut io.Writer, config util.FedConfig) *cobra.Command {
+	opts := &previewResource{}
+
+	cmd := &cobra.Command{
+		Use:     "preview -f FILENAME",
+		Short:   "Preview the changes propagation of federated resources would make to member clusters",
+		Long:    preview_long,
+		Example: preview_example,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := opts.Complete(args)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+
+			err = opts.Run(cmdOut, config)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+		},
+	}
+
+	flags := cmd.Flags()
+	opts.GlobalSubcommandBind(flags)
+	opts.Bind(flags)
+
+	return cmd
+}
+
+// Complete ensures that options are valid and marshals them if necessary.
+func (j *previewResource) Complete(args []string) error {
+	if len(args) > 0 {
+		return errors.New("preview does not accept positional arguments")
+	}
+	if len(j.filename) == 0 {
+		return errors.New("--filename is required")
+	}
+	return nil
+}
+
+// Run is the implementation of the `preview` command.
+func (j *previewResource) Run(cmdOut io.Writer, config util.FedConfig) error {
+	hostConfig, err := config.HostConfig(j.HostClusterContext, j.Kubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get host cluster config")
+	}
+
+	resources, err := federate.DecodeUnstructuredFromFile(j.filename)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load federated resources from %q", j.filename)
+	}
+
+	client, err := genericclient.New(hostConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get federation clientset")
+	}
+
+	fedConfig := &fedv1a1.KubefedConfig{}
+	err = client.Get(context.TODO(), fedConfig, j.KubefedNamespace, ctlutil.KubefedConfigName)
+	if err != nil {
+		config := ctlutil.QualifiedName{
+			Namespace: j.KubefedNamespace,
+			Name:      ctlutil.KubefedConfigName,
+		}
+		return errors.Wrapf(err, "Error retrieving KubefedConfig %q", config)
+	}
+	scope := fedConfig.Spec.Scope
+	defaultAdoptionPolicy := fedv1a1.AdoptionPolicyAlways
+	if fedConfig.Spec.SyncController.SkipAdoptingResources {
+		defaultAdoptionPolicy = fedv1a1.AdoptionPolicyNever
+	}
+
+	typeConfigs := &fedv1a1.FederatedTypeConfigList{}
+	err = client.List(context.TODO(), typeConfigs, j.KubefedNamespace)
+	if err != nil {
+		return errors.Wrap(err, "Error listing FederatedTypeConfigs")
+	}
+
+	clusterList := &fedv1a1.KubefedClusterList{}
+	err = client.List(context.TODO(), clusterList, j.KubefedNamespace)
+	if err != nil {
+		return errors.Wrap(err, "Error listing KubefedClusters")
+	}
+	clusters := []*fedv1a1.KubefedCluster{}
+	clusterConfigs := make(map[string]*rest.Config)
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		clusterConfig, err := ctlutil.BuildClusterConfig(cluster, client, j.KubefedNamespace, fedConfig.Spec.ClusterCredentials.AllowedExecCommands)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to build config for cluster %q", cluster.Name)
+		}
+		clusters = append(clusters, cluster)
+		clusterConfigs[cluster.Name] = clusterConfig
+	}
+
+	for _, resource := range resources {
+		typeConfig, err := typeConfigForFederatedResource(typeConfigs, resource)
+		if err != nil {
+			return err
+		}
+		input := &sync.PreviewInput{
+			TypeConfig:        typeConfig,
+			FederatedResource: resource,
+			Clusters:          clusters,
+			LimitedScope:      scope == apiextv1b1.NamespaceScoped,
+
+			DefaultAdoptionPolicy: defaultAdoptionPolicy,
+		}
+		clusterClients, err := j.populatePreviewInput(hostConfig, client, typeConfigs, clusterConfigs, input)
+		if err != nil {
+			return err
+		}
+
+		previews, err := sync.Preview(input)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to preview %s %q", resource.GetKind(), ctlutil.NewQualifiedName(resource))
+		}
+		err = writePreviews(cmdOut, resource, previews, clusterClients, typeConfig.GetServerSideApply())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// populatePreviewInput retrieves the state the sync controller would
+// consider in propagating the federated resource of the given input.
+// Dry-run clients for the target type in ready member clusters are
+// returned, keyed by cluster name.
+func (j *previewResource) populatePreviewInput(hostConfig *rest.Config, client genericclient.Client,
+	typeConfigs *fedv1a1.FederatedTypeConfigList, clusterConfigs map[string]*rest.Config, input *sync.PreviewInput) (map[string]ctlutil.ResourceClient, error) {
+
+	resource := input.FederatedResource
+	typeConfig := input.TypeConfig
+	namespace := resource.GetNamespace()
+	if typeConfig.GetNamespaced() && len(namespace) == 0 {
+		return nil, errors.Errorf("%s %q must specify a namespace", resource.GetKind(), resource.GetName())
+	}
+
+	targetName := ctlutil.NewQualifiedName(resource)
+	targetAPIResource := typeConfig.GetTarget()
+	if targetAPIResource.Kind == ctlutil.NamespaceKind {
+		targetName.Namespace = ""
+
+		hostClient, err := ctlutil.NewResourceClient(hostConfig, &targetAPIResource)
+		if err != nil {
+			return nil, err
+		}
+		input.Namespace, err = getResource(hostClient, targetName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error retrieving namespace %q", targetName.Name)
+		}
+		if input.Namespace == nil {
+			return nil, errors.Errorf("Namespace %q does not exist in the host cluster", targetName.Name)
+		}
+	}
+
+	if typeConfig.GetNamespaced() {
+		namespaceTypeConfig, err := typeConfigByName(typeConfigs, ctlutil.NamespaceName)
+		if err != nil {
+			return nil, err
+		}
+		fedNamespaceAPIResource := namespaceTypeConfig.GetFederatedType()
+		fedNamespaceClient, err := ctlutil.NewResourceClient(hostConfig, &fedNamespaceAPIResource)
+		if err != nil {
+			return nil, err
+		}
+		fedNamespaceName := ctlutil.QualifiedName{Namespace: namespace, Name: namespace}
+		input.FederatedNamespace, err = getResource(fedNamespaceClient, fedNamespaceName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error retrieving %s %q", fedNamespaceAPIResource.Kind, fedNamespaceName)
+		}
+	}
+
+	propagationPolicies := &fedv1a1.PropagationPolicyList{}
+	overridePolicies := &fedv1a1.OverridePolicyList{}
+	if len(namespace) > 0 {
+		if err := client.List(context.TODO(), propagationPolicies, namespace); err != nil {
+			return nil, errors.Wrap(err, "Error listing PropagationPolicies")
+		}
+		if err := client.List(context.TODO(), overridePolicies, namespace); err != nil {
+			return nil, errors.Wrap(err, "Error listing OverridePolicies")
+		}
+	}
+	input.PropagationPolicies = propagationPolicies.Items
+	input.OverridePolicies = overridePolicies.Items
+	if !input.LimitedScope {
+		clusterPropagationPolicies := &fedv1a1.ClusterPropagationPolicyList{}
+		if err := client.List(context.TODO(), clusterPropagationPolicies, metav1.NamespaceAll); err != nil {
+			return nil, errors.Wrap(err, "Error listing ClusterPropagationPolicies")
+		}
+		clusterOverridePolicies := &fedv1a1.ClusterOverridePolicyList{}
+		if err := client.List(context.TODO(), clusterOverridePolicies, metav1.NamespaceAll); err != nil {
+			return nil, errors.Wrap(err, "Error listing ClusterOverridePolicies")
+		}
+		input.ClusterPropagationPolicies = clusterPropagationPolicies.Items
+		input.ClusterOverridePolicies = clusterOverridePolicies.Items
+		if len(namespace) > 0 {
+			hostClientset, err := util.HostClientset(hostConfig)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to get host clientset")
+			}
+			resourceNamespace, err := hostClientset.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "Error retrieving namespace %q", namespace)
+			}
+			if err == nil {
+				input.ResourceNamespace = resourceNamespace
+			}
+		}
+	}
+
+	propagatedVersion, err := getPropagatedVersion(client, typeConfig, resource)
+	if err != nil {
+		return nil, err
+	}
+	input.PropagatedVersion = propagatedVersion
+
+	clusterClients := make(map[string]ctlutil.ResourceClient)
+	input.ClusterObjects = make(map[string]*unstructured.Unstructured)
+	for _, cluster := range input.Clusters {
+		if !ctlutil.IsClusterReady(&cluster.Status) {
+			continue
+		}
+		clusterClient, err := ctlutil.NewDryRunResourceClient(clusterConfigs[cluster.Name], &targetAPIResource)
+		if err != nil {
+			return nil, err
+		}
+		clusterClients[cluster.Name] = clusterClient
+		clusterObj, err := getResource(clusterClient, targetName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error retrieving %s %q from cluster %q", targetAPIResource.Kind, targetName, cluster.Name)
+		}
+		if clusterObj != nil {
+			input.ClusterObjects[cluster.Name] = clusterObj
+		}
+	}
+
+	return clusterClients, nil
+}
+
+// getPropagatedVersion returns the status of the propagated version
+// of the given federated resource, or nil if it has not been
+// propagated.
+func getPropagatedVersion(client genericclient.Client, typeConfig typeconfig.Interface, resource *unstructured.Unstructured) (*fedv1a1.PropagatedVersionStatus, error) {
+	name := common.PropagatedVersionName(typeConfig.GetTarget().Kind, resource.GetName())
+	var err error
+	var status *fedv1a1.PropagatedVersionStatus
+	if typeConfig.GetNamespaced() {
+		version := &fedv1a1.PropagatedVersion{}
+		err = client.Get(context.TODO(), version, resource.GetNamespace(), name)
+		status = &version.Status
+	} else {
+		version := &fedv1a1.ClusterPropagatedVersion{}
+		err = client.Get(context.TODO(), version, "", name)
+		status = &version.Status
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error retrieving the propagated version of %s %q", resource.GetKind(), ctlutil.NewQualifiedName(resource))
+	}
+	return status, nil
+}
+
+func typeConfigForFederatedResource(typeConfigs *fedv1a1.FederatedTypeConfigList, resource *unstructured.Unstructured) (*fedv1a1.FederatedTypeConfig, error) {
+	gvk := resource.GroupVersionKind()
+	for i := range typeConfigs.Items {
+		typeConfig := &typeConfigs.Items[i]
+		federatedType := typeConfig.GetFederatedType()
+		if federatedType.Group == gvk.Group && federatedType.Kind == gvk.Kind {
+			return typeConfig, nil
+		}
+	}
+	return nil, errors.Errorf("No FederatedTypeConfig found for federated type %q", gvk.GroupKind())
+}
+
+func typeConfigByName(typeConfigs *fedv1a1.FederatedTypeConfigList, name string) (*fedv1a1.FederatedTypeConfig, error) {
+	for i := range typeConfigs.Items {
+		if typeConfigs.Items[i].Name == name {
+			return &typeConfigs.Items[i], nil
+		}
+	}
+	return nil, errors.Errorf("FederatedTypeConfig %q does not exist", name)
+}
+
+// getResource returns the named resource, or nil if it does not exist.
+func getResource(client ctlutil.ResourceClient, qualifiedName ctlutil.QualifiedName) (*unstructured.Unstructured, error) {
+	obj, err := client.Resources(qualifiedName.Namespace).Get(qualifiedName.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+func writePreviews(w io.Writer, resource *unstructured.Unstructured, previews []sync.ClusterPreview,
+	clusterClients map[string]ctlutil.ResourceClient, serverSideApply *fedv1a1.ServerSideApply) error {
+
+	_, err := fmt.Fprintf(w, "%s %q:\n", resource.GetKind(), ctlutil.NewQualifiedName(resource))
+	if err != nil {
+		return err
+	}
+	if len(previews) == 0 {
+		_, err = fmt.Fprintf(w, "  No clusters selected\n")
+		return err
+	}
+	for _, preview := range previews {
+		_, err = fmt.Fprintf(w, "  Cluster %q: %s\n", preview.ClusterName, preview.Operation)
+		if err != nil {
+			return err
+		}
+		writtenObj, err := dryRun(clusterClients[preview.ClusterName], preview, serverSideApply)
+		if apierrors.IsConflict(err) && preview.ApplyObject != nil {
+			_, err = fmt.Fprintf(w, "  The apply would conflict with fields managed by another actor: %v\n", err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Failed to perform a dry run for cluster %q", preview.ClusterName)
+		}
+		diff, err := previewDiff(preview, writtenObj)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to compute diff for cluster %q", preview.ClusterName)
+		}
+		_, err = io.WriteString(w, diff)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dryRun performs a server-side dry run of the write of the given
+// preview, in the same way as the sync controller would perform the
+// write, and returns the resulting resource.  Nil is returned if the
+// preview does not write to the member cluster.
+func dryRun(client ctlutil.ResourceClient, preview sync.ClusterPreview, serverSideApply *fedv1a1.ServerSideApply) (*unstructured.Unstructured, error) {
+	desiredObj := preview.DesiredObject
+	switch preview.Operation {
+	case sync.PreviewCreate:
+		if serverSideApply != nil {
+			return client.Create(desiredObj, ctlutil.FieldManager)
+		}
+		return client.Resources(desiredObj.GetNamespace()).Create(desiredObj, metav1.CreateOptions{})
+	case sync.PreviewAdopt, sync.PreviewUpdate:
+		if preview.ApplyObject != nil {
+			force := serverSideApply.Force || !dispatch.AppliedByFieldManager(preview.ClusterObject, ctlutil.FieldManager)
+			return client.Apply(preview.ApplyObject, ctlutil.FieldManager, force)
+		}
+		return client.Resources(desiredObj.GetNamespace()).Update(desiredObj, metav1.UpdateOptions{})
+	}
+	return nil, nil
+}
+
+// previewDiff returns a unified diff between the resource in a member
+// cluster and the given resource resulting from a dry run of the
+// write of the preview.  Status and the metadata maintained by the
+// API server are omitted.  Since the written resource is defaulted by
+// the API server, fields that would be removed are included.
+func previewDiff(preview sync.ClusterPreview, writtenObj *unstructured.Unstructured) (string, error) {
+	var before, after interface{}
+	switch preview.Operation {
+	case sync.PreviewCreate, sync.PreviewAdopt, sync.PreviewUpdate:
+		after = managedFields(writtenObj.Object)
+		if preview.ClusterObject != nil {
+			before = managedFields(preview.ClusterObject.Object)
+		}
+	case sync.PreviewDelete:
+		before = managedFields(preview.ClusterObject.Object)
+	default:
+		return "", nil
+	}
+
+	beforeYAML, err := toYAML(before)
+	if err != nil {
+		return "", err
+	}
+	afterYAML, err := toYAML(after)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(beforeYAML),
+		B:        difflib.SplitLines(afterYAML),
+		FromFile: fmt.Sprintf("%s (current)", preview.ClusterName),
+		ToFile:   fmt.Sprintf("%s (propagated)", preview.ClusterName),
+		Context:  3,
+	})
+}
+
+// managedFields returns a copy of the given object without status or
+// the metadata maintained by the API server.
+func managedFields(obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range obj {
+		switch key {
+		case ctlutil.StatusField:
+			continue
+		case ctlutil.MetadataField:
+			metadata := make(map[string]interface{})
+			if metadataMap, ok := value.(map[string]interface{}); ok {
+				for _, field := range []string{"name", "namespace", "labels", "annotations"} {
+					if fieldValue, ok := metadataMap[field]; ok {
+						metadata[field] = fieldValue
+					}
+				}
+			}
+			value = metadata
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func toYAML(obj interface{}) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
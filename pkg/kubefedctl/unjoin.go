@@ -317,9 +317,14 @@ func deleteServiceAccount(clusterClientset kubeclient.Interface, saName,
 		return nil
 	}
 
-	// Delete a service account.
-	return clusterClientset.CoreV1().ServiceAccounts(namespace).Delete(saName,
+	// Delete a service account.  A cluster joined with the credentials
+	// of a kubeconfig does not have one.
+	err := clusterClientset.CoreV1().ServiceAccounts(namespace).Delete(saName,
 		&metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
 }
 
 // deleteClusterRoleAndBinding deletes an RBAC cluster role and binding that
@@ -0,0 +1,299 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the prometheus metrics exposed by the
+// kubefed controller manager.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	namespace = "kubefed"
+
+	// DispatchSucceeded is the result recorded for a dispatched
+	// operation that completed successfully.
+	DispatchSucceeded = "Succeeded"
+	// DispatchFailed is the result recorded for a dispatched
+	// operation that completed with an error.
+	DispatchFailed = "Failed"
+	// DispatchTimedOut is the result recorded for a dispatched
+	// operation that did not complete before the dispatch timeout.
+	DispatchTimedOut = "TimedOut"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Time taken to reconcile a federated resource, by controller and federated type config.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"controller", "type_config"},
+	)
+
+	dispatchOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dispatch",
+			Name:      "operations_total",
+			Help:      "Number of operations dispatched to member clusters, by target kind, cluster, operation and result.",
+		},
+		[]string{"kind", "cluster", "operation", "result"},
+	)
+
+	dispatchErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dispatch",
+			Name:      "errors_total",
+			Help:      "Number of errors propagating to member clusters, by target kind, cluster and propagation status.",
+		},
+		[]string{"kind", "cluster", "status"},
+	)
+
+	clusterReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "ready",
+			Help:      "Whether a member cluster is ready (1) or not (0) as of the last health check.",
+		},
+		[]string{"cluster"},
+	)
+
+	clusterOffline = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "offline",
+			Help:      "Whether a member cluster is offline (1) or not (0) as of the last health check.",
+		},
+		[]string{"cluster"},
+	)
+
+	clusterHealthCheckDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "health_check_duration_seconds",
+			Help:      "Time taken to check the health of a member cluster.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"cluster"},
+	)
+
+	workerRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "worker",
+			Name:      "retries_total",
+			Help:      "Number of items requeued with backoff after a failed reconcile, by worker.",
+		},
+		[]string{"name"},
+	)
+
+	delivererPending = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "deliverer",
+			Name:      "pending_items",
+			Help:      "Number of items waiting for their delivery time, by deliverer.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "depth",
+			Help:      "Current depth of a work queue.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueAdds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "adds_total",
+			Help:      "Number of adds handled by a work queue.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLatency = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "queue_latency_microseconds",
+			Help:      "How long an item stays in a work queue before being requested.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueWorkDuration = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "work_duration_microseconds",
+			Help:      "How long processing an item from a work queue takes.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "unfinished_work_seconds",
+			Help:      "How many seconds of work has been done that is in progress and hasn't been observed by work_duration.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLongestRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "longest_running_processor_microseconds",
+			Help:      "How many microseconds the longest running processor of a work queue has been running.",
+		},
+		[]string{"name"},
+	)
+
+	workqueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workqueue",
+			Name:      "retries_total",
+			Help:      "Number of retries handled by a work queue.",
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		reconcileDuration,
+		dispatchOperations,
+		dispatchErrors,
+		clusterReady,
+		clusterOffline,
+		clusterHealthCheckDuration,
+		workerRetries,
+		delivererPending,
+		workqueueDepth,
+		workqueueAdds,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueUnfinishedWork,
+		workqueueLongestRunning,
+		workqueueRetries,
+	)
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// ReconcileDurationFromStart records the time elapsed since the given
+// start time for a reconcile performed by the named controller for
+// the given federated type config.
+func ReconcileDurationFromStart(controller, typeConfigName string, start time.Time) {
+	reconcileDuration.WithLabelValues(controller, typeConfigName).Observe(time.Since(start).Seconds())
+}
+
+// DispatchOperation records the result of an operation dispatched to
+// a member cluster.
+func DispatchOperation(kind, clusterName, operation, result string) {
+	dispatchOperations.WithLabelValues(kind, clusterName, operation, result).Inc()
+}
+
+// DispatchError records a propagation error for a member cluster.
+func DispatchError(kind, clusterName, propagationStatus string) {
+	dispatchErrors.WithLabelValues(kind, clusterName, propagationStatus).Inc()
+}
+
+// ClusterHealth records the result of a health check of a member
+// cluster.
+func ClusterHealth(clusterName string, ready, offline bool, duration time.Duration) {
+	clusterReady.WithLabelValues(clusterName).Set(boolToFloat(ready))
+	clusterOffline.WithLabelValues(clusterName).Set(boolToFloat(offline))
+	clusterHealthCheckDuration.WithLabelValues(clusterName).Observe(duration.Seconds())
+}
+
+// ClusterRemoved removes the metrics recorded for a member cluster
+// that is no longer joined.
+func ClusterRemoved(clusterName string) {
+	clusterReady.DeleteLabelValues(clusterName)
+	clusterOffline.DeleteLabelValues(clusterName)
+	clusterHealthCheckDuration.DeleteLabelValues(clusterName)
+}
+
+// WorkerRetry records that the named worker requeued an item with
+// backoff.
+func WorkerRetry(workerName string) {
+	workerRetries.WithLabelValues(workerName).Inc()
+}
+
+// DelivererPendingItems records the number of items the named
+// deliverer is holding until their delivery time.
+func DelivererPendingItems(delivererName string, count int) {
+	delivererPending.WithLabelValues(delivererName).Set(float64(count))
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// workqueueMetricsProvider exposes the metrics of named work queues.
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorMicrosecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunning.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
@@ -36,7 +36,7 @@ import (
 )
 
 const (
-	replicasPath = "spec.replicas"
+	replicasPath = "/spec/replicas"
 )
 
 type Plugin struct {
@@ -199,33 +199,54 @@ func setOverrides(obj *unstructured.Unstructured, overridesMap util.OverridesMap
 
 func updateOverridesMap(overridesMap util.OverridesMap, replicasMap map[string]int64) {
 	// Remove replicas override for clusters that are not scheduled
-	for clusterName, clusterOverridesMap := range overridesMap {
+	for clusterName, clusterOverrides := range overridesMap {
 		if _, ok := replicasMap[clusterName]; !ok {
-			delete(clusterOverridesMap, replicasPath)
+			for i, overrideItem := range clusterOverrides {
+				if overrideItem.Path == replicasPath {
+					clusterOverrides = append(clusterOverrides[:i], clusterOverrides[i+1:]...)
+					if len(clusterOverrides) == 0 {
+						// delete empty ClusterOverrides item
+						delete(overridesMap, clusterName)
+					} else {
+						overridesMap[clusterName] = clusterOverrides
+					}
+					break
+				}
+			}
 		}
 	}
 	// Add/update replicas override for clusters that are scheduled
 	for clusterName, replicas := range replicasMap {
-		clusterOverridesMap, ok := overridesMap[clusterName]
-		if !ok {
-			clusterOverridesMap = make(util.ClusterOverridesMap)
-			overridesMap[clusterName] = clusterOverridesMap
+		replicasOverrideFound := false
+		for idx, overrideItem := range overridesMap[clusterName] {
+			if overrideItem.Path == replicasPath {
+				overridesMap[clusterName][idx].Value = replicas
+				replicasOverrideFound = true
+				break
+			}
+		}
+		if !replicasOverrideFound {
+			clusterOverrides, exist := overridesMap[clusterName]
+			if !exist {
+				clusterOverrides = util.ClusterOverrides{}
+			}
+			clusterOverrides = append(clusterOverrides, util.ClusterOverride{Path: replicasPath, Value: replicas})
+			overridesMap[clusterName] = clusterOverrides
 		}
-		clusterOverridesMap[replicasPath] = replicas
 	}
 }
 
 func OverrideUpdateNeeded(overridesMap util.OverridesMap, result map[string]int64) bool {
 	resultLen := len(result)
 	checkLen := 0
-	for clusterName, clusterOverridesMap := range overridesMap {
-		for path, rawValue := range clusterOverridesMap {
-			if path != replicasPath {
+	for clusterName, clusterOverrides := range overridesMap {
+		for _, clusterOverride := range clusterOverrides {
+			if clusterOverride.Path != replicasPath {
 				continue
 			}
 			// The type of the value will be float64 due to how json
 			// marshalling works for interfaces.
-			floatValue, ok := rawValue.(float64)
+			floatValue, ok := clusterOverride.Value.(float64)
 			if !ok {
 				return true
 			}
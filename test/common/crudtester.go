@@ -172,7 +172,7 @@ func (c *FederatedTypeCrudTester) CheckUpdate(fedObject *unstructured.Unstructur
 	kind := apiResource.Kind
 	qualifiedName := util.NewQualifiedName(fedObject)
 
-	key := "metadata.labels"
+	key := "/metadata/labels"
 	value := map[string]interface{}{
 		"crudtester-operation":           "update",
 		util.ManagedByFederationLabelKey: util.ManagedByFederationLabelValue,
@@ -185,16 +185,13 @@ func (c *FederatedTypeCrudTester) CheckUpdate(fedObject *unstructured.Unstructur
 			c.tl.Fatalf("Error retrieving overrides for %s %q: %v", kind, qualifiedName, err)
 		}
 		for clusterName := range c.testClusters {
-			clusterOverrides, ok := overrides[clusterName]
-			if !ok {
-				clusterOverrides = make(util.ClusterOverridesMap)
-				overrides[clusterName] = clusterOverrides
-			}
-			_, ok = clusterOverrides[key]
-			if ok {
-				c.tl.Fatalf("An override for %q already exists for cluster %q", key, clusterName)
+			clusterOverrides := overrides[clusterName]
+			for _, clusterOverride := range clusterOverrides {
+				if clusterOverride.Path == key {
+					c.tl.Fatalf("An override for %q already exists for cluster %q", key, clusterName)
+				}
 			}
-			clusterOverrides[key] = value
+			overrides[clusterName] = append(clusterOverrides, util.ClusterOverride{Path: key, Value: value})
 		}
 
 		if err := util.SetOverrides(obj, overrides); err != nil {
@@ -544,7 +541,7 @@ func (c *FederatedTypeCrudTester) checkHostNamespaceUnlabeled(client util.Resour
 	}
 }
 
-func (c *FederatedTypeCrudTester) waitForResource(client util.ResourceClient, qualifiedName util.QualifiedName, expectedOverrides util.ClusterOverridesMap, expectedVersionFunc func() string) error {
+func (c *FederatedTypeCrudTester) waitForResource(client util.ResourceClient, qualifiedName util.QualifiedName, expectedOverrides util.ClusterOverrides, expectedVersionFunc func() string) error {
 	err := wait.PollImmediate(c.waitInterval, c.clusterWaitTimeout, func() (bool, error) {
 		expectedVersion := expectedVersionFunc()
 		if len(expectedVersion) == 0 {
@@ -564,15 +561,24 @@ func (c *FederatedTypeCrudTester) waitForResource(client util.ResourceClient, qu
 
 			// Validate that the expected override was applied
 			if len(expectedOverrides) > 0 {
-				for path, expectedValue := range expectedOverrides {
-					pathEntries := strings.Split(path, ".")
+				for _, expectedOverride := range expectedOverrides {
+					path := expectedOverride.Path
+					pathEntries := strings.Split(strings.TrimPrefix(path, "/"), "/")
 					value, ok, err := unstructured.NestedFieldCopy(clusterObj.Object, pathEntries...)
 					if err != nil {
 						c.tl.Fatalf("Error retrieving overridden path: %v", err)
 					}
+					if expectedOverride.Op == util.RemoveOp {
+						if ok {
+							c.tl.Errorf("Expected field %s to be removed", path)
+							return false, nil
+						}
+						continue
+					}
 					if !ok {
 						c.tl.Fatalf("Missing overridden path %s", path)
 					}
+					expectedValue := expectedOverride.Value
 					// Because the result of deserializing an override field differs from the value
 					// retrieved by NestedFieldCopy, reflection is not able to accurately compare
 					// numeric types that should otherwise be equal. For example, an override value
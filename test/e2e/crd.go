@@ -189,7 +189,7 @@ template:
     - bal
 overrides:
   - clusterOverrides:
-    - path: bar
+    - path: "/bar"
       value:
       - fiz
       - bang
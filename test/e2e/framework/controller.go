@@ -42,6 +42,7 @@ func NewSyncControllerFixture(tl common.TestLogger, controllerConfig *util.Contr
 	f := &ControllerFixture{
 		stopChan: make(chan struct{}),
 	}
+	controllerConfig = f.withPolicyInformers(tl, controllerConfig)
 	err := sync.StartFederationSyncController(controllerConfig, f.stopChan, typeConfig, namespacePlacement)
 	if err != nil {
 		tl.Fatalf("Error starting sync controller: %v", err)
@@ -61,6 +62,7 @@ func NewFederatedTypeConfigControllerFixture(tl common.TestLogger, config *util.
 	f := &ControllerFixture{
 		stopChan: make(chan struct{}),
 	}
+	config = f.withPolicyInformers(tl, config)
 
 	err := federatedtypeconfig.StartController(config, f.stopChan)
 	if err != nil {
@@ -69,6 +71,19 @@ func NewFederatedTypeConfigControllerFixture(tl common.TestLogger, config *util.
 	return f
 }
 
+// withPolicyInformers returns a copy of the given configuration with
+// policy informers that run until the fixture is torn down.
+func (f *ControllerFixture) withPolicyInformers(tl common.TestLogger, config *util.ControllerConfig) *util.ControllerConfig {
+	policyInformers, err := util.NewPolicyInformers(config)
+	if err != nil {
+		tl.Fatalf("Error initializing policy informers: %v", err)
+	}
+	policyInformers.Run(f.stopChan)
+	configCopy := *config
+	configCopy.PolicyInformers = policyInformers
+	return &configCopy
+}
+
 // NewServiceDNSControllerFixture initializes a new service-dns controller fixture.
 func NewServiceDNSControllerFixture(tl common.TestLogger, config *util.ControllerConfig) *ControllerFixture {
 	f := &ControllerFixture{
@@ -133,10 +133,11 @@ func (a *testClusterVersionAdapter) FederatedTypeInstance() pkgruntime.Object {
 }
 
 type testVersionedResource struct {
-	federatedName   util.QualifiedName
-	object          *unstructured.Unstructured
-	templateVersion string
-	overrideVersion string
+	federatedName    util.QualifiedName
+	object           *unstructured.Unstructured
+	templateVersion  string
+	overrideVersion  string
+	overrideVersions map[string]string
 }
 
 func (r *testVersionedResource) FederatedName() util.QualifiedName {
@@ -154,6 +155,10 @@ func (r *testVersionedResource) OverrideVersion() (string, error) {
 	return r.overrideVersion, nil
 }
 
+func (r *testVersionedResource) ClusterOverrideVersions() (map[string]string, error) {
+	return r.overrideVersions, nil
+}
+
 func newTestVersionAdapter(client genericclient.Client, kubeClient kubeclientset.Interface, namespaced bool) testVersionAdapter {
 	adapter := version.NewVersionAdapter(namespaced)
 	if namespaced {